@@ -3,12 +3,24 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/service"
 )
 
+// Backup event stream settings.
+const (
+	backupEventStatus      = "status" // first event of each stream, with the current status
+	eventKeepAliveInterval = 15 * time.Second
+)
+
 // BackupDeleteResponse represents the response format for backup delete operations.
 type BackupDeleteResponse struct {
 	Message  string `json:"message"`
@@ -48,6 +60,81 @@ func (s *Server) handleBackupStatus(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, s.service.Backup.Status())
 }
 
+func (s *Server) handleBackupSchedule(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, s.scheduler.BackupSchedule())
+}
+
+func (s *Server) handlePauseBackupScheduler(w http.ResponseWriter, r *http.Request) {
+	schedule, err := s.scheduler.PauseBackups()
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, schedule)
+}
+
+func (s *Server) handleResumeBackupScheduler(w http.ResponseWriter, r *http.Request) {
+	schedule, err := s.scheduler.ResumeBackups()
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, schedule)
+}
+
+func (s *Server) handleBackupEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	events, unsubscribe := s.service.Backup.SubscribeEvents()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Start with the current status, so clients need no separate status request.
+	if err := writeEvent(w, service.BackupEvent{Type: backupEventStatus, Time: time.Now(), Status: s.service.Backup.Status()}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a backup event in Server-Sent Events format, named after its type.
+func writeEvent(w io.Writer, event service.BackupEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
+
 func (s *Server) handleDownloadBackupFile(w http.ResponseWriter, r *http.Request) {
 	filename := chi.URLParam(r, "filename")
 
@@ -58,10 +145,158 @@ func (s *Server) handleDownloadBackupFile(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// The server has no global WriteTimeout, so bound the download to release connections of stalled clients.
+	timeout := s.service.Config().Backup.GetDownloadTimeout()
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		slog.Debug("Could not set backup download deadline", "filename", filename, "error", err)
+	}
+
+	// Per-table backups are directories, so they are sent as an uncompressed tar archive.
+	if service.IsTableSet(filename) {
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename+".tar")
+		w.WriteHeader(http.StatusOK)
+		if err := s.service.Backup.WriteArchive(w, []service.BackupInfo{{Filename: filename}}, 0); err != nil {
+			slog.Warn("Backup download incomplete", "filename", filename, "error", err, "client_error", r.Context().Err())
+		}
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
 
-	http.ServeFile(w, r, filePath)
+	cw := &countingResponseWriter{ResponseWriter: w}
+	http.ServeFile(cw, r, filePath)
+
+	// Range requests intentionally transfer part of the file, so only full downloads are checked.
+	if r.Header.Get("Range") != "" || cw.status != http.StatusOK {
+		return
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return
+	}
+
+	if cw.written < info.Size() {
+		slog.Warn("Backup download incomplete",
+			"filename", filename,
+			"bytes_sent", cw.written,
+			"bytes_expected", info.Size(),
+			"timeout", timeout,
+			"client_error", r.Context().Err())
+		return
+	}
+
+	slog.Debug("Backup download completed", "filename", filename, "bytes_sent", cw.written)
+}
+
+func (s *Server) handleValidateAllBackups(w http.ResponseWriter, r *http.Request) {
+	var req service.ValidateAllRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
+		respondError(w, http.StatusBadRequest, "Invalid request content")
+		return
+	}
+
+	result, err := s.service.Backup.ValidateAll(r.Context(), req)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleDownloadBackupArchive(w http.ResponseWriter, r *http.Request) {
+	var compression int
+	if value := r.URL.Query().Get("compress"); value != "" {
+		level, err := strconv.Atoi(value)
+		if err != nil || level < 0 || level > 9 {
+			respondError(w, http.StatusBadRequest, "Invalid compress value: use 0-9")
+			return
+		}
+		compression = level
+	}
+
+	list, err := s.service.Backup.List()
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(s.service.Config().Backup.GetDownloadTimeout())); err != nil {
+		slog.Debug("Could not set backup archive deadline", "error", err)
+	}
+
+	filename := "aeron-backups-" + time.Now().Format("2006-01-02-150405") + ".tar"
+	contentType := "application/x-tar"
+	if compression > 0 {
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so failures can only be logged.
+	if err := s.service.Backup.WriteArchive(w, list.Backups, compression); err != nil {
+		slog.Warn("Backup archive download incomplete", "error", err, "client_error", r.Context().Err())
+		return
+	}
+
+	slog.Debug("Backup archive download completed", "backups", len(list.Backups), "compress", compression)
+}
+
+// countingResponseWriter records the status code and number of body bytes written to the client.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (c *countingResponseWriter) WriteHeader(statusCode int) {
+	if c.status == 0 {
+		c.status = statusCode
+	}
+	c.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements http.ResponseWriter.
+func (c *countingResponseWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	n, err := c.ResponseWriter.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+// ReadFrom implements io.ReaderFrom, so http.ServeFile keeps using sendfile when the
+// underlying ResponseWriter supports it.
+func (c *countingResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if rf, ok := c.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(writerOnly{c.ResponseWriter}, src)
+	}
+	c.written += n
+	return n, err
+}
+
+// writerOnly hides every method but Write, so io.Copy does not call back into ReadFrom.
+type writerOnly struct {
+	io.Writer
+}
+
+// Unwrap returns the underlying ResponseWriter for use with http.ResponseController.
+func (c *countingResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
 }
 
 func (s *Server) handleDeleteBackup(w http.ResponseWriter, r *http.Request) {
@@ -86,6 +321,30 @@ func (s *Server) handleDeleteBackup(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleRestoreBackup(w http.ResponseWriter, r *http.Request) {
+	const confirmHeader = "X-Confirm-Restore"
+	const confirmValue = "RESTORE"
+
+	if r.Header.Get(confirmHeader) != confirmValue {
+		respondError(w, http.StatusBadRequest, "Missing confirmation header: "+confirmHeader)
+		return
+	}
+
+	var req service.RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request content")
+		return
+	}
+
+	result, err := s.service.Backup.Restore(req)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
 func (s *Server) handleValidateBackup(w http.ResponseWriter, r *http.Request) {
 	filename := chi.URLParam(r, "filename")
 
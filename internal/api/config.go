@@ -0,0 +1,8 @@
+// Package api provides the HTTP API server for the Aeron radio automation system.
+package api
+
+import "net/http"
+
+func (s *Server) handleConfig(w http.ResponseWriter, _ *http.Request) {
+	respondJSON(w, http.StatusOK, s.service.Config().Effective())
+}
@@ -0,0 +1,101 @@
+// Package api provides the HTTP API server for the Aeron radio automation system.
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+)
+
+// corsAllowedHeaders lists the request headers browsers may send cross-origin.
+var corsAllowedHeaders = []string{
+	"Content-Type",
+	"X-API-Key",
+	"Idempotency-Key",
+	"If-None-Match",
+	"Range",
+	"X-Confirm-Bulk-Delete",
+	"X-Confirm-Vacuum-Full",
+	"X-Confirm-Restore",
+}
+
+// corsExposedHeaders lists the response headers browsers may read cross-origin, besides the CORS-safelisted ones.
+var corsExposedHeaders = []string{
+	"Content-Disposition",
+	"ETag",
+	"Retry-After",
+	"Idempotent-Replayed",
+	"X-Original-Size",
+	"X-Optimized-Size",
+	"X-Savings-Percent",
+	"X-Image-Unchanged",
+	"X-Perceptual-Hash",
+}
+
+// corsMaxAge is how long browsers may cache a preflight response, in seconds.
+const corsMaxAge = "600"
+
+// corsMiddleware adds CORS headers for origins in api.cors.allowed_origins and answers preflight requests.
+// Without allowed origins it passes requests through unchanged.
+func corsMiddleware(cfg *config.CORSConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(cfg.AllowedOrigins) == 0 {
+			return next
+		}
+
+		allowAll := slices.Contains(cfg.AllowedOrigins, "*")
+		allowedMethods := strings.Join(cfg.GetAllowedMethods(), ", ")
+		allowedHeaders := strings.Join(corsAllowedHeaders, ", ")
+		exposedHeaders := strings.Join(corsExposedHeaders, ", ")
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+			if origin == "" || (!allowAll && !slices.Contains(cfg.AllowedOrigins, origin)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Credentials are never allowed with a wildcard origin, so the origin is echoed instead.
+			if allowAll && !cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+				w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// trustCORSOrigins lets requests from the allowed CORS origins pass the cross-origin protection,
+// which would otherwise reject cross-origin requests with unsafe methods. With the wildcard origin,
+// the protection is bypassed entirely; the API authenticates with a header, not with cookies.
+func trustCORSOrigins(cop *http.CrossOriginProtection, cfg *config.CORSConfig) error {
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			cop.AddInsecureBypassPattern("/")
+			return nil
+		}
+		if err := cop.AddTrustedOrigin(origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,13 +2,22 @@
 package api
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/image"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/service"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/util"
@@ -23,11 +32,30 @@ type ImageUploadRequest struct {
 	Image string `json:"image"`
 }
 
+// ArtistUpdateRequest represents the JSON request body for updating artist fields.
+type ArtistUpdateRequest = service.ArtistUpdate
+
+// TrackUpdateRequest represents the JSON request body for updating track fields.
+type TrackUpdateRequest = service.TrackUpdate
+
+// TrackBatchRequest represents the JSON request body for batch track lookups.
+type TrackBatchRequest struct {
+	IDs []string `json:"ids"`
+}
+
 // ImageStatsResponse represents the response format for statistics endpoints.
 type ImageStatsResponse struct {
-	Total         int `json:"total"`
-	WithImages    int `json:"with_images"`
-	WithoutImages int `json:"without_images"`
+	Total         int       `json:"total"`
+	WithImages    int       `json:"with_images"`
+	WithoutImages int       `json:"without_images"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
+
+// StatsRefreshResponse represents the response for the statistics refresh endpoint.
+type StatsRefreshResponse struct {
+	Artists     ImageStatsResponse `json:"artists"`
+	Tracks      ImageStatsResponse `json:"tracks"`
+	RefreshedAt time.Time          `json:"refreshed_at"`
 }
 
 // HealthResponse represents the response for the health check endpoint.
@@ -45,6 +73,13 @@ type ImageUploadResponse struct {
 	OriginalSize         int     `json:"original_size"`
 	OptimizedSize        int     `json:"optimized_size"`
 	SizeReductionPercent float64 `json:"savings_percent"`
+	Format               string  `json:"format,omitempty"`
+	Quality              int     `json:"quality,omitzero"`
+	Encoder              string  `json:"encoder,omitempty"`
+	PerceptualHash       string  `json:"perceptual_hash,omitempty"`
+	Unchanged            bool    `json:"unchanged"`
+	DetectedFormat       string  `json:"detected_format,omitempty"` // set with claimed_format when the declared format was wrong
+	ClaimedFormat        string  `json:"claimed_format,omitempty"`
 }
 
 // BulkDeleteResponse represents the response for bulk delete operations.
@@ -87,23 +122,414 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleStats(entityType types.EntityType) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		stats, err := s.service.Media.GetStatistics(r.Context(), entityType)
+		if entityType == types.EntityTypeTrack && r.URL.Query().Has("added_since") {
+			s.handleTracksAddedSince(w, r)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "prometheus" {
+			respondError(w, http.StatusBadRequest, "format must be json or prometheus")
+			return
+		}
+
+		activeOnly := parseQueryBoolParam(r.URL.Query().Get("active_only"))
+		stats, err := s.service.Media.GetStatistics(r.Context(), entityType, activeOnly != nil && *activeOnly)
 		if err != nil {
 			slog.Error("Failed to retrieve statistics", "entityType", entityType, "error", err)
 			respondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
+		if format == "prometheus" {
+			writePrometheusStats(w, entityType, activeOnly != nil && *activeOnly, stats)
+			return
+		}
+
 		response := ImageStatsResponse{
 			Total:         stats.Total,
 			WithImages:    stats.WithImages,
 			WithoutImages: stats.WithoutImages,
+			ComputedAt:    stats.ComputedAt,
 		}
 
 		respondJSON(w, http.StatusOK, response)
 	}
 }
 
+// writePrometheusStats writes image statistics as Prometheus gauges in the text exposition format.
+func writePrometheusStats(w http.ResponseWriter, entityType types.EntityType, activeOnly bool, stats *service.ImageStats) {
+	labels := fmt.Sprintf(`{entity_type=%q,active_only="%t"}`, entityType, activeOnly)
+	gauges := []struct {
+		name  string
+		help  string
+		value int
+	}{
+		{"aeron_entities", "Number of entities.", stats.Total},
+		{"aeron_entities_with_image", "Number of entities with an image.", stats.WithImages},
+		{"aeron_entities_without_image", "Number of entities without an image.", stats.WithoutImages},
+	}
+
+	var b strings.Builder
+	for _, gauge := range gauges {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s%s %d\n", gauge.name, gauge.help, gauge.name, gauge.name, labels, gauge.value)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		slog.Debug("Failed to write metrics to client", "error", err)
+	}
+}
+
+func (s *Server) handleClassifications(w http.ResponseWriter, _ *http.Request) {
+	respondJSON(w, http.StatusOK, s.service.Config().Metadata.GetClassifications())
+}
+
+func (s *Server) handleUpdateArtist(w http.ResponseWriter, r *http.Request) {
+	artistID := s.validateAndGetEntityID(w, r, types.EntityTypeArtist)
+	if artistID == "" {
+		return
+	}
+
+	var req ArtistUpdateRequest
+	if err := decodeStrictJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	artist, err := s.service.Media.UpdateArtist(r.Context(), artistID, &req)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, artist)
+}
+
+func (s *Server) handleUpdateTrack(w http.ResponseWriter, r *http.Request) {
+	trackID := s.validateAndGetEntityID(w, r, types.EntityTypeTrack)
+	if trackID == "" {
+		return
+	}
+
+	var req TrackUpdateRequest
+	if err := decodeStrictJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	track, err := s.service.Media.UpdateTrack(r.Context(), trackID, &req)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, track)
+}
+
+// decodeStrictJSON decodes the request body into v and rejects fields v does not have,
+// so a misspelled field is reported instead of silently ignored.
+func decodeStrictJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return fmt.Errorf("unknown field %s", field)
+		}
+		return errors.New("invalid request content")
+	}
+	return nil
+}
+
+// Body size allowances for batch requests. A batch body may hold api.max_batch_size items of the
+// per-item allowance, so an oversized batch is rejected while it is read instead of after decoding.
+const (
+	batchTrackIDBytes      = 64      // a quoted UUID with separator and whitespace
+	batchImageItemOverhead = 4 << 10 // entity type, ID, URL, data URL prefix and JSON syntax of an image job item
+	batchRequestOverhead   = 1 << 10
+)
+
+// decodeBatchRequest decodes a JSON batch request of at most maxItems items of itemBytes each into v.
+// It responds with 413 Request Entity Too Large when the body is larger and with 400 Bad Request when
+// it is invalid, and reports whether decoding succeeded.
+func decodeBatchRequest(w http.ResponseWriter, r *http.Request, v any, maxItems int, itemBytes int64) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxItems)*itemBytes+batchRequestOverhead)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes (api.max_batch_size)", maxBytesErr.Limit))
+			return false
+		}
+		respondError(w, http.StatusBadRequest, "Invalid request content")
+		return false
+	}
+	return true
+}
+
+// parsePagination parses the limit and offset query parameters. A missing parameter is returned as 0,
+// so the service applies its default.
+func parsePagination(query url.Values) (limit, offset int, err error) {
+	if limit, err = parseLimit(query, "limit"); err != nil {
+		return 0, 0, err
+	}
+	if value := query.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return 0, 0, types.NewValidationError("offset", "invalid offset: use zero or a positive number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// parseLimit parses a positive limit from the named query parameter. A missing parameter is returned as 0,
+// so the service applies its default.
+func parseLimit(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, types.NewValidationError(name, fmt.Sprintf("invalid %s: use a positive number", name))
+	}
+	return limit, nil
+}
+
+func (s *Server) handleArtistTracks(w http.ResponseWriter, r *http.Request) {
+	artistID := s.validateAndGetEntityID(w, r, types.EntityTypeArtist)
+	if artistID == "" {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, offset, err := parsePagination(query)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	result, err := s.service.Media.GetArtistTracks(r.Context(), artistID, limit, offset)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// handleTracksAddedSince lists tracks added at or after the added_since query parameter,
+// which is a date (YYYY-MM-DD) or a time without time zone (YYYY-MM-DDTHH:MM:SS).
+func (s *Server) handleTracksAddedSince(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	addedSince := query.Get("added_since")
+	since, err := time.Parse(playlistTimeLayout, addedSince)
+	if err != nil {
+		since, err = time.Parse(time.DateOnly, addedSince)
+	}
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid added_since: use YYYY-MM-DD or YYYY-MM-DDTHH:MM:SS")
+		return
+	}
+
+	limit, offset, err := parsePagination(query)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	result, err := s.service.Media.GetTracksAddedSince(r.Context(), since, limit, offset)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleListArtists(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := service.ArtistListOptions{
+		HasImage: parseQueryBoolParam(query.Get("has_image")),
+	}
+	var err error
+	if opts.Limit, opts.Offset, err = parsePagination(query); err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		respondError(w, http.StatusBadRequest, "invalid order: use 'asc' or 'desc'")
+		return
+	}
+
+	result, err := s.service.Media.ListArtists(r.Context(), opts)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleListTracks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := service.TrackListOptions{
+		Query:    query.Get("q"),
+		HasImage: parseQueryBoolParam(query.Get("has_image")),
+	}
+	var err error
+	if opts.Limit, opts.Offset, err = parsePagination(query); err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	result, err := s.service.Media.ListTracks(r.Context(), opts)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	artistLimit, err := parseLimit(query, "artist_limit")
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+	trackLimit, err := parseLimit(query, "track_limit")
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	result, err := s.service.Media.Search(r.Context(), query.Get("q"), artistLimit, trackLimit)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleTrackBatch(w http.ResponseWriter, r *http.Request) {
+	var req TrackBatchRequest
+	if !decodeBatchRequest(w, r, &req, s.service.Config().API.GetMaxBatchSize(), batchTrackIDBytes) {
+		return
+	}
+
+	result, err := s.service.Media.GetTracks(r.Context(), req.IDs)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleRefreshStats(w http.ResponseWriter, r *http.Request) {
+	response := StatsRefreshResponse{RefreshedAt: time.Now()}
+	activeOnly := parseQueryBoolParam(r.URL.Query().Get("active_only"))
+
+	for entityType, target := range map[types.EntityType]*ImageStatsResponse{
+		types.EntityTypeArtist: &response.Artists,
+		types.EntityTypeTrack:  &response.Tracks,
+	} {
+		stats, err := s.service.Media.RefreshStatistics(r.Context(), entityType, activeOnly != nil && *activeOnly)
+		if err != nil {
+			slog.Error("Failed to refresh statistics", "entityType", entityType, "error", err)
+			respondError(w, errorCode(err), err.Error())
+			return
+		}
+		*target = ImageStatsResponse{
+			Total:         stats.Total,
+			WithImages:    stats.WithImages,
+			WithoutImages: stats.WithoutImages,
+			ComputedAt:    stats.ComputedAt,
+		}
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleAttentionStats(w http.ResponseWriter, r *http.Request) {
+	activeOnly := parseQueryBoolParam(r.URL.Query().Get("active_only"))
+	result, err := s.service.Media.GetAttentionCounts(r.Context(), activeOnly != nil && *activeOnly)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleImageSizeStats(w http.ResponseWriter, r *http.Request) {
+	result, err := s.service.Media.GetImageSizeStats(r.Context())
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// handleSimilarImages streams groups of near-duplicate images as newline-delimited JSON.
+// Errors before the first group are reported as a normal JSON error response.
+func (s *Server) handleSimilarImages(w http.ResponseWriter, r *http.Request) {
+	threshold := service.DefaultSimilarityThreshold
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > service.MaxSimilarityThreshold {
+			respondError(w, http.StatusBadRequest, "Invalid threshold: use 0-"+strconv.Itoa(service.MaxSimilarityThreshold))
+			return
+		}
+		threshold = parsed
+	}
+
+	rc := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	groups := 0
+	err := s.service.Media.FindSimilarImages(r.Context(), threshold, func(group service.SimilarImageGroup) error {
+		if !started {
+			start()
+		}
+		if err := encoder.Encode(group); err != nil {
+			return err
+		}
+		groups++
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			respondError(w, errorCode(err), err.Error())
+			return
+		}
+		// The status is already sent, so failures can only be logged.
+		slog.Warn("Similar images report incomplete", "groups", groups, "error", err)
+		return
+	}
+
+	if !started {
+		start()
+	}
+	slog.Debug("Similar images report completed", "groups", groups, "threshold", threshold)
+}
+
 func (s *Server) handleEntityByID(entityType types.EntityType) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		entityID := s.validateAndGetEntityID(w, r, entityType)
@@ -137,11 +563,20 @@ func (s *Server) uploadResponse(result *service.ImageUploadResult, entityType ty
 		OriginalSize:         result.OriginalSize,
 		OptimizedSize:        result.OptimizedSize,
 		SizeReductionPercent: result.SizeReductionPercent,
+		Format:               result.Format,
+		Quality:              result.Quality,
+		Encoder:              result.Encoder,
+		PerceptualHash:       result.PerceptualHash,
+		Unchanged:            result.Unchanged,
 	}
 
 	if entityType == types.EntityTypeTrack {
 		response.Track = result.TrackTitle
 	}
+	if result.FormatMismatch() {
+		response.DetectedFormat = result.DetectedFormat
+		response.ClaimedFormat = result.ClaimedFormat
+	}
 
 	return response
 }
@@ -172,6 +607,10 @@ func (s *Server) handleBulkDelete(entityType types.EntityType) http.HandlerFunc
 	}
 }
 
+// resizedImageCacheControl lets clients reuse images resized with ?size= for a short while, so
+// lists of thumbnails do not hit the API on every render, while replaced images still show up soon.
+const resizedImageCacheControl = "private, max-age=300"
+
 func (s *Server) handleGetImage(entityType types.EntityType) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		entityID := s.validateAndGetEntityID(w, r, entityType)
@@ -179,21 +618,105 @@ func (s *Server) handleGetImage(entityType types.EntityType) http.HandlerFunc {
 			return
 		}
 
-		imageData, err := s.service.Media.GetImage(r.Context(), entityType, entityID)
+		query := r.URL.Query()
+		size, variant := query.Get("size"), query.Get("variant")
+		if size != "" && variant != "" {
+			respondError(w, http.StatusBadRequest, "use either size or variant, not both")
+			return
+		}
+		ifNoneMatch := r.Header.Get("If-None-Match")
+
+		// For the full image the database computes the hash, so an unchanged image is never transferred.
+		if ifNoneMatch != "" && size == "" && (variant == "" || variant == service.ImageVariantFull) {
+			hash, err := s.service.Media.GetImageHash(r.Context(), entityType, entityID)
+			if err == nil && hash != "" && etagMatches(ifNoneMatch, quoteETag(hash)) {
+				writeNotModified(w, quoteETag(hash))
+				return
+			}
+		}
+
+		var imageData []byte
+		var err error
+		if size != "" {
+			imageData, err = s.service.Media.GetImageSize(r.Context(), entityType, entityID, size)
+		} else {
+			imageData, err = s.service.Media.GetImageVariant(r.Context(), entityType, entityID, variant)
+		}
 		if err != nil {
 			statusCode := errorCode(err)
 			respondError(w, statusCode, err.Error())
 			return
 		}
 
-		w.Header().Del("Content-Type")
-		w.Header().Set("Content-Type", detectImageContentType(imageData))
-		w.Header().Set("Content-Length", strconv.Itoa(len(imageData)))
+		if size != "" {
+			w.Header().Set("Cache-Control", resizedImageCacheControl)
+		}
+		etag := imageETag(imageData)
+		if etagMatches(ifNoneMatch, etag) {
+			writeNotModified(w, etag)
+			return
+		}
+		w.Header().Set("ETag", etag)
 
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write(imageData); err != nil {
-			slog.Debug("Failed to write image to client", "error", err)
+		contentType := detectImageContentType(imageData)
+
+		if download := parseQueryBoolParam(query.Get("download")); download != nil && *download {
+			name, err := s.service.Media.GetEntityName(r.Context(), entityType, entityID)
+			if err != nil {
+				respondError(w, errorCode(err), err.Error())
+				return
+			}
+			filename := util.SanitizeFilename(name, entityID) + imageExtension(contentType)
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
 		}
+
+		writeImage(w, imageData, contentType)
+	}
+}
+
+// imageETag returns a strong ETag for image data. It is the MD5 hash PostgreSQL's md5() computes
+// for the stored picture, so the full image can be revalidated without reading it.
+func imageETag(data []byte) string {
+	sum := md5.Sum(data) // Used for cache validation only
+	return quoteETag(hex.EncodeToString(sum[:]))
+}
+
+// quoteETag formats a hash as an ETag value.
+func quoteETag(hash string) string {
+	return `"` + hash + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag. Weak validators match too,
+// as If-None-Match uses weak comparison.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified answers a conditional request whose cached copy is still current.
+func writeNotModified(w http.ResponseWriter, etag string) {
+	w.Header().Del("Content-Type")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// writeImage writes raw image bytes with the given content type, replacing the default JSON content type.
+func writeImage(w http.ResponseWriter, imageData []byte, contentType string) {
+	w.Header().Del("Content-Type")
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(imageData)))
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(imageData); err != nil {
+		slog.Debug("Failed to write image to client", "error", err)
 	}
 }
 
@@ -204,27 +727,12 @@ func (s *Server) handleImageUpload(entityType types.EntityType) http.HandlerFunc
 			return
 		}
 
-		var req ImageUploadRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid request content")
+		params, err := s.imageUploadParams(r, entityType, entityID)
+		if err != nil {
+			respondError(w, errorCode(err), err.Error())
 			return
 		}
 
-		params := &service.ImageUploadParams{
-			EntityType: entityType,
-			ID:         entityID,
-			ImageURL:   req.URL,
-		}
-
-		if req.Image != "" {
-			imageData, err := service.DecodeBase64(req.Image)
-			if err != nil {
-				respondError(w, http.StatusBadRequest, "Invalid base64 image")
-				return
-			}
-			params.ImageData = imageData
-		}
-
 		result, err := s.service.Media.UploadImage(r.Context(), params)
 		if err != nil {
 			statusCode := errorCode(err)
@@ -232,11 +740,99 @@ func (s *Server) handleImageUpload(entityType types.EntityType) http.HandlerFunc
 			return
 		}
 
+		// Return the stored image directly so clients can display it without a follow-up GET.
+		if returnImage := parseQueryBoolParam(r.URL.Query().Get("return_image")); returnImage != nil && *returnImage {
+			w.Header().Set("X-Original-Size", strconv.Itoa(result.OriginalSize))
+			w.Header().Set("X-Optimized-Size", strconv.Itoa(result.OptimizedSize))
+			w.Header().Set("X-Savings-Percent", strconv.FormatFloat(result.SizeReductionPercent, 'f', 1, 64))
+			w.Header().Set("X-Image-Unchanged", strconv.FormatBool(result.Unchanged))
+			if result.PerceptualHash != "" {
+				w.Header().Set("X-Perceptual-Hash", result.PerceptualHash)
+			}
+			writeImage(w, result.ImageData, detectImageContentType(result.ImageData))
+			return
+		}
+
 		response := s.uploadResponse(result, entityType)
 		respondJSON(w, http.StatusOK, response)
 	}
 }
 
+// imageUploadParams reads an image upload from either a multipart/form-data body with an "image" file part,
+// or a JSON body with a url or base64 image.
+func (s *Server) imageUploadParams(r *http.Request, entityType types.EntityType, entityID string) (*service.ImageUploadParams, error) {
+	params := &service.ImageUploadParams{
+		EntityType: entityType,
+		ID:         entityID,
+	}
+	maxSize := s.service.Config().Image.GetMaxDownloadBytes()
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		imageData, claimedFormat, err := readMultipartImage(r, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		params.ImageData = imageData
+		params.ClaimedFormat = claimedFormat
+		return params, nil
+	}
+
+	var req ImageUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, types.NewValidationError("body", "Invalid request content")
+	}
+	params.ImageURL = req.URL
+
+	if req.Image != "" {
+		imageData, err := service.DecodeBase64(req.Image, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		params.ImageData = imageData
+		params.ClaimedFormat = image.FormatFromDataURL(req.Image)
+	}
+	return params, nil
+}
+
+// readMultipartImage reads the "image" part of a multipart/form-data request. The part is streamed,
+// so no more than maxSize bytes are read into memory; other parts are skipped.
+// The claimed format comes from the part's Content-Type, or else from its filename extension.
+func readMultipartImage(r *http.Request, maxSize int64) (data []byte, claimedFormat string, err error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", types.NewValidationError("body", "Invalid multipart request")
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", types.NewValidationError("image", "multipart request has no image part")
+		}
+		if err != nil {
+			return nil, "", types.NewValidationError("body", "Invalid multipart request")
+		}
+		if part.FormName() != "image" {
+			_ = part.Close()
+			continue
+		}
+
+		data, err = io.ReadAll(io.LimitReader(part, maxSize+1))
+		_ = part.Close()
+		if err != nil {
+			return nil, "", types.NewValidationError("image", "failed to read image part")
+		}
+		if int64(len(data)) > maxSize {
+			return nil, "", types.NewValidationError("image", fmt.Sprintf("image exceeds maximum size of %d bytes", maxSize))
+		}
+
+		claimedFormat = image.FormatFromMediaType(part.Header.Get("Content-Type"))
+		if claimedFormat == "" {
+			claimedFormat = image.FormatFromFilename(part.FileName())
+		}
+		return data, claimedFormat, nil
+	}
+}
+
 func (s *Server) handleDeleteImage(entityType types.EntityType) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		entityID := s.validateAndGetEntityID(w, r, entityType)
@@ -264,19 +860,43 @@ func (s *Server) handleDeleteImage(entityType types.EntityType) http.HandlerFunc
 	}
 }
 
-func parsePlaylistOptions(query url.Values) service.PlaylistOptions {
+func parsePlaylistOptions(query url.Values) (service.PlaylistOptions, error) {
 	opts := service.DefaultPlaylistOptions()
 	opts.BlockID = query.Get("block_id")
+	if date := query.Get("date"); date != "" {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return opts, types.NewValidationError("date", "invalid date: use YYYY-MM-DD")
+		}
+		opts.Date = date
+	}
 
-	if limit := query.Get("limit"); limit != "" {
-		if l, err := strconv.Atoi(limit); err == nil && l > 0 {
-			opts.Limit = l
+	if modes := query.Get("mode"); modes != "" {
+		parsed, err := parseIntList(modes, "mode")
+		if err != nil {
+			return opts, err
 		}
+		opts.Modes = parsed
 	}
-	if offset := query.Get("offset"); offset != "" {
-		if o, err := strconv.Atoi(offset); err == nil && o >= 0 {
-			opts.Offset = o
+	if exportTypes := query.Get("exclude_export_types"); exportTypes != "" {
+		parsed, err := parseIntList(exportTypes, "exclude_export_types")
+		if err != nil {
+			return opts, err
 		}
+		opts.ExportTypes = parsed
+	}
+	if modeLabels := parseQueryBoolParam(query.Get("mode_labels")); modeLabels != nil {
+		opts.ModeLabels = *modeLabels
+	}
+	if hasArtist := query.Get("has_artist"); hasArtist != "" {
+		opts.HasArtist = parseQueryBoolParam(hasArtist)
+	}
+	if omit := parseQueryBoolParam(query.Get("omit_missing_artist")); omit != nil {
+		opts.OmitMissingArtist = *omit
+	}
+
+	var err error
+	if opts.Limit, opts.Offset, err = parsePagination(query); err != nil {
+		return opts, err
 	}
 
 	if trackImage := query.Get("track_image"); trackImage != "" {
@@ -286,26 +906,69 @@ func parsePlaylistOptions(query url.Values) service.PlaylistOptions {
 		opts.ArtistImage = parseQueryBoolParam(artistImage)
 	}
 
-	if sort := query.Get("sort"); sort != "" {
-		opts.SortBy = sort
-	}
 	if query.Get("desc") == "true" {
 		opts.SortDesc = true
 	}
+	if sort := query.Get("sort"); strings.ContainsAny(sort, ",:") {
+		parsed, err := parsePlaylistSort(sort, opts.SortDesc)
+		if err != nil {
+			return opts, err
+		}
+		opts.Sort = parsed
+	} else if sort != "" {
+		opts.SortBy = sort
+	}
 
-	return opts
+	return opts, nil
+}
+
+// parsePlaylistSort parses a comma-separated list of sort fields, each optionally suffixed with
+// ":asc" or ":desc". Fields without a direction are sorted descending if desc is set.
+func parsePlaylistSort(value string, desc bool) ([]service.PlaylistSort, error) {
+	var result []service.PlaylistSort
+	for part := range strings.SplitSeq(value, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		sort := service.PlaylistSort{Field: field, Desc: desc}
+		switch direction {
+		case "":
+		case "asc":
+			sort.Desc = false
+		case "desc":
+			sort.Desc = true
+		default:
+			return nil, types.NewValidationError("sort", "invalid sort direction: use 'asc' or 'desc'")
+		}
+		result = append(result, sort)
+	}
+	return result, nil
+}
+
+// parseIntList parses a comma-separated list of integers from a query parameter.
+func parseIntList(value, field string) ([]int, error) {
+	var result []int
+	for part := range strings.SplitSeq(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, types.NewValidationError(field, "invalid "+field+": use comma-separated numbers")
+		}
+		result = append(result, n)
+	}
+	return result, nil
 }
 
 func (s *Server) handlePlaylist(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
+	opts, err := parsePlaylistOptions(r.URL.Query())
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
 
 	// Single block with items
-	if query.Get("block_id") != "" {
-		opts := parsePlaylistOptions(query)
+	if opts.BlockID != "" {
 		playlist, err := s.service.Media.GetPlaylist(r.Context(), &opts)
 		if err != nil {
 			slog.Error("Failed to retrieve playlist", "block_id", opts.BlockID, "error", err)
-			respondError(w, http.StatusInternalServerError, err.Error())
+			respondError(w, errorCode(err), err.Error())
 			return
 		}
 		respondJSON(w, http.StatusOK, playlist)
@@ -313,13 +976,70 @@ func (s *Server) handlePlaylist(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// All blocks with tracks for a date
-	date := query.Get("date")
-	result, err := s.service.Media.GetPlaylistWithTracks(r.Context(), date)
+	result, err := s.service.Media.GetPlaylistWithTracks(r.Context(), &opts)
 	if err != nil {
-		slog.Error("Failed to retrieve playlist with tracks", "date", date, "error", err)
+		slog.Error("Failed to retrieve playlist with tracks", "date", opts.Date, "error", err)
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, result)
 }
+
+func (s *Server) handlePlaylistStats(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	stats, err := s.service.Media.GetPlaylistStats(r.Context(), date)
+	if err != nil {
+		slog.Error("Failed to retrieve playlist statistics", "date", date, "error", err)
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// PlaylistItemUpdateRequest represents the JSON request body for moving a playlist item.
+type PlaylistItemUpdateRequest struct {
+	Start string `json:"start"`
+}
+
+// PlaylistItemUpdateResponse represents the JSON response after moving a playlist item.
+type PlaylistItemUpdateResponse struct {
+	ItemID  int64  `json:"itemid"`
+	BlockID string `json:"blockid"`
+	Start   string `json:"start"`
+}
+
+// playlistTimeLayout is the format of playlist timestamps in requests and responses; Aeron stores them without a time zone.
+const playlistTimeLayout = "2006-01-02T15:04:05"
+
+func (s *Server) handleUpdatePlaylistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondError(w, http.StatusBadRequest, "Invalid playlist item ID")
+		return
+	}
+
+	var req PlaylistItemUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request content")
+		return
+	}
+	start, err := time.Parse(playlistTimeLayout, req.Start)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid start: use YYYY-MM-DDTHH:MM:SS")
+		return
+	}
+
+	schedule, err := s.service.Media.UpdatePlaylistItemStart(r.Context(), id, start)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, PlaylistItemUpdateResponse{
+		ItemID:  schedule.ItemID,
+		BlockID: schedule.BlockID,
+		Start:   schedule.Start.Format(playlistTimeLayout),
+	})
+}
@@ -0,0 +1,281 @@
+// Package api provides the HTTP API server for the Aeron radio automation system.
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxIdempotencyKeyLength is the longest accepted Idempotency-Key header value.
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotencyEntries caps the number of remembered keys. When it is reached, the recorded
+// response that expires first makes room for the new key.
+const maxIdempotencyEntries = 10000
+
+// maxIdempotencyUnreadBytes is how much of a request body left unread by the handler is still read to
+// complete its hash. Responses to requests with more unread data are not recorded.
+const maxIdempotencyUnreadBytes = 1 << 20
+
+// maxIdempotencyResponseBytes is the largest response body that is recorded. Larger responses, such as
+// uploads that return the image, are passed through but not recorded, so a retry executes again.
+const maxIdempotencyResponseBytes = 1 << 20
+
+// idempotencyEntry is the recorded response of the first request with a given key.
+type idempotencyEntry struct {
+	done     chan struct{} // closed once the response has been recorded
+	status   int
+	header   http.Header
+	body     []byte
+	bodyHash [sha256.Size]byte // hash of the request body, to detect a key reused for another request
+	expires  time.Time
+}
+
+// idempotencyStore keeps responses of mutating requests in memory, keyed by their Idempotency-Key header.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+// newIdempotencyStore creates an empty idempotencyStore.
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{entries: make(map[string]*idempotencyEntry)}
+}
+
+// claim returns the entry for key. If owner is true, the caller must execute the request and
+// then call finish or release; otherwise the entry belongs to an earlier request.
+// A nil entry means the store is full of requests in progress, so the request cannot be recorded.
+func (s *idempotencyStore) claim(key string) (entry *idempotencyEntry, owner bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeLocked(time.Now())
+
+	if entry, ok := s.entries[key]; ok {
+		return entry, false
+	}
+	if len(s.entries) >= maxIdempotencyEntries && !s.evictLocked() {
+		return nil, true
+	}
+	entry = &idempotencyEntry{done: make(chan struct{})}
+	s.entries[key] = entry
+	return entry, true
+}
+
+// purgeLocked removes entries whose recorded response has expired. Caller must hold s.mu.
+func (s *idempotencyStore) purgeLocked(now time.Time) {
+	for key, entry := range s.entries {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// evictLocked removes the recorded response that expires first. It reports false if every entry
+// belongs to a request in progress. Caller must hold s.mu.
+func (s *idempotencyStore) evictLocked() bool {
+	var oldestKey string
+	var oldest *idempotencyEntry
+	for key, entry := range s.entries {
+		if !entry.expires.IsZero() && (oldest == nil || entry.expires.Before(oldest.expires)) {
+			oldestKey, oldest = key, entry
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(s.entries, oldestKey)
+	return true
+}
+
+// finish records the response for an entry and keeps it until ttl has passed.
+func (s *idempotencyStore) finish(entry *idempotencyEntry, status int, header http.Header, body []byte, bodyHash [sha256.Size]byte, ttl time.Duration) {
+	s.mu.Lock()
+	entry.status = status
+	entry.header = header
+	entry.body = body
+	entry.bodyHash = bodyHash
+	entry.expires = time.Now().Add(ttl)
+	s.mu.Unlock()
+	close(entry.done)
+}
+
+// release forgets an entry without a recorded response, so a retry executes the request again.
+// Requests waiting on the entry execute as well.
+func (s *idempotencyStore) release(key string, entry *idempotencyEntry) {
+	s.mu.Lock()
+	if s.entries[key] == entry {
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+	close(entry.done)
+}
+
+// hashingBody hashes a request body as the handler reads it.
+type hashingBody struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+// Read implements io.Reader.
+func (b *hashingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	return n, err
+}
+
+// sum reads the part of the body the handler left unread, up to maxIdempotencyUnreadBytes, and returns
+// the hash of the whole body. It reports false if the body could not be read completely.
+func (b *hashingBody) sum() (sum [sha256.Size]byte, ok bool) {
+	n, err := io.Copy(io.Discard, io.LimitReader(b, maxIdempotencyUnreadBytes+1))
+	if err != nil || n > maxIdempotencyUnreadBytes {
+		return sum, false
+	}
+	copy(sum[:], b.hash.Sum(nil))
+	return sum, true
+}
+
+// hashRequestBody returns the hash of a complete request body.
+func hashRequestBody(body io.Reader) (sum [sha256.Size]byte, err error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// recordingResponseWriter passes a response through to the client while keeping a copy of it.
+// The copy is dropped once the body exceeds maxIdempotencyResponseBytes.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (rw *recordingResponseWriter) WriteHeader(statusCode int) {
+	if rw.status == 0 {
+		rw.status = statusCode
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements http.ResponseWriter.
+func (rw *recordingResponseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	if !rw.truncated {
+		if rw.body.Len()+len(p) > maxIdempotencyResponseBytes {
+			rw.truncated = true
+			rw.body = bytes.Buffer{}
+		} else {
+			rw.body.Write(p)
+		}
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying ResponseWriter for use with http.ResponseController.
+func (rw *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// idempotencyMiddleware replays the recorded response when a mutating request is retried with the same
+// Idempotency-Key header, instead of executing it again. Keys are scoped to the API key, method, and path.
+// A key reused with a different request body is rejected with 422 Unprocessable Entity.
+// Server errors and responses larger than maxIdempotencyResponseBytes are not recorded, so such a request
+// is executed again when retried with the same key.
+func (s *Server) idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		scopedKey := r.Header.Get("X-API-Key") + "\x00" + r.Method + " " + r.URL.Path + "\x00" + key
+		for {
+			entry, owner := s.idempotency.claim(scopedKey)
+			if entry == nil {
+				slog.Warn("Idempotency store is full, executing request without recording it", "method", r.Method, "path", r.URL.Path)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if owner {
+				s.executeIdempotent(w, r, next, scopedKey, entry)
+				return
+			}
+
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				respondError(w, http.StatusServiceUnavailable, "Request cancelled while waiting for an identical request")
+				return
+			}
+			if entry.status == 0 {
+				// The earlier request failed and released the key; try to claim it again.
+				continue
+			}
+
+			// The body is only read now, so a request that claims a released key can still execute.
+			bodyHash, err := hashRequestBody(r.Body)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			if bodyHash != entry.bodyHash {
+				respondError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+				return
+			}
+
+			slog.Debug("Replaying idempotent response", "method", r.Method, "path", r.URL.Path, "status", entry.status)
+			for name, values := range entry.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(entry.status)
+			if _, err := w.Write(entry.body); err != nil {
+				slog.Debug("Failed to write replayed response to client", "error", err)
+			}
+			return
+		}
+	})
+}
+
+// executeIdempotent runs the request and records its response for later replays.
+func (s *Server) executeIdempotent(w http.ResponseWriter, r *http.Request, next http.Handler, key string, entry *idempotencyEntry) {
+	recorder := &recordingResponseWriter{ResponseWriter: w}
+	body := &hashingBody{ReadCloser: r.Body, hash: sha256.New()}
+	r.Body = body
+	defer func() {
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError || recorder.truncated {
+			s.idempotency.release(key, entry)
+			return
+		}
+		bodyHash, ok := body.sum()
+		if !ok {
+			s.idempotency.release(key, entry)
+			return
+		}
+		// The body is recorded before response compression, so encoding headers are left to the replay.
+		header := w.Header().Clone()
+		header.Del("Content-Encoding")
+		header.Del("Content-Length")
+		s.idempotency.finish(entry, recorder.status, header, recorder.body.Bytes(), bodyHash, s.service.Config().API.GetIdempotencyTTL())
+	}()
+
+	next.ServeHTTP(recorder, r)
+}
@@ -0,0 +1,207 @@
+// Package api provides the HTTP API server for the Aeron radio automation system.
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/image"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/service"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/util"
+)
+
+// ImageJobRequest represents the JSON request body for submitting a batch image job.
+type ImageJobRequest struct {
+	Items []ImageJobItemRequest `json:"items"`
+}
+
+// ImageJobItemRequest represents a single image upload within a batch job.
+type ImageJobItemRequest struct {
+	EntityType string `json:"entity_type"`
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Image      string `json:"image"`
+}
+
+// ImageBenchmarkRequest represents the optional JSON request body for an image benchmark.
+type ImageBenchmarkRequest struct {
+	Image string `json:"image"`
+}
+
+// ReoptimizeRequest represents the optional JSON request body for starting a bulk reoptimization.
+type ReoptimizeRequest struct {
+	Workers int    `json:"workers"`
+	Order   string `json:"order"`
+}
+
+// ImageJobStartResponse is the response for a submitted image job.
+type ImageJobStartResponse struct {
+	JobID   string `json:"job_id"`
+	Message string `json:"message"`
+	Check   string `json:"check"`
+}
+
+func (s *Server) handleSubmitImageJob(w http.ResponseWriter, r *http.Request) {
+	cfg := s.service.Config()
+	maxSize := cfg.Image.GetMaxDownloadBytes()
+	itemBytes := int64(base64.StdEncoding.EncodedLen(int(maxSize))) + batchImageItemOverhead
+
+	var req ImageJobRequest
+	if !decodeBatchRequest(w, r, &req, cfg.API.GetMaxBatchSize(), itemBytes) {
+		return
+	}
+
+	// Check the item count before decoding the base64 images.
+	if err := service.CheckBatchSize(&cfg.API, "items", len(req.Items)); err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	items := make([]service.ImageUploadParams, len(req.Items))
+	for i, item := range req.Items {
+		entityType := types.EntityType(item.EntityType)
+		if err := util.ValidateEntityID(item.ID, string(entityType)); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("item %d: %v", i, err))
+			return
+		}
+
+		items[i] = service.ImageUploadParams{
+			EntityType: entityType,
+			ID:         item.ID,
+			ImageURL:   item.URL,
+		}
+
+		if item.Image != "" {
+			imageData, err := service.DecodeBase64(item.Image, maxSize)
+			if err != nil {
+				respondError(w, errorCode(err), fmt.Sprintf("item %d: %v", i, err))
+				return
+			}
+			items[i].ImageData = imageData
+			items[i].ClaimedFormat = image.FormatFromDataURL(item.Image)
+		}
+	}
+
+	job, err := s.service.ImageJobs.Submit(items)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, ImageJobStartResponse{
+		JobID:   job.ID,
+		Message: fmt.Sprintf("Image job started with %d items", job.Total),
+		Check:   "/api/images/jobs/" + job.ID,
+	})
+}
+
+func (s *Server) handleImageJobStatus(w http.ResponseWriter, r *http.Request) {
+	job, err := s.service.ImageJobs.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleImageBenchmark(w http.ResponseWriter, r *http.Request) {
+	var count int
+	if value := r.URL.Query().Get("count"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "count must be a positive integer")
+			return
+		}
+		count = parsed
+	}
+
+	// The body is optional; without an image a sample image is generated.
+	var req ImageBenchmarkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "Invalid request content")
+		return
+	}
+
+	var sample []byte
+	if req.Image != "" {
+		imageData, err := service.DecodeBase64(req.Image, s.service.Config().Image.GetMaxDownloadBytes())
+		if err != nil {
+			respondError(w, errorCode(err), err.Error())
+			return
+		}
+		sample = imageData
+	}
+
+	result, err := s.service.Media.BenchmarkImages(r.Context(), count, sample)
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleStartReoptimize(w http.ResponseWriter, r *http.Request) {
+	// The body is optional; without one the configured defaults are used.
+	var req ReoptimizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "Invalid request content")
+		return
+	}
+
+	job, err := s.service.Reoptimize.Start(service.ReoptimizeOptions{Workers: req.Workers, Order: req.Order})
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, ImageJobStartResponse{
+		JobID:   job.ID,
+		Message: fmt.Sprintf("Image reoptimization started with %d workers", job.Workers),
+		Check:   "/api/images/reoptimize",
+	})
+}
+
+func (s *Server) handleReoptimizeStatus(w http.ResponseWriter, r *http.Request) {
+	job, err := s.service.Reoptimize.Get()
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handlePauseReoptimize(w http.ResponseWriter, r *http.Request) {
+	job, err := s.service.Reoptimize.Pause()
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleResumeReoptimize(w http.ResponseWriter, r *http.Request) {
+	job, err := s.service.Reoptimize.Resume()
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleCancelReoptimize(w http.ResponseWriter, r *http.Request) {
+	job, err := s.service.Reoptimize.Cancel()
+	if err != nil {
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,56 @@
+// Package api provides the HTTP API server for the Aeron radio automation system.
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/logbuffer"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// defaultLogLimit is the number of log records returned when no limit is given.
+const defaultLogLimit = 100
+
+// LogsResponse represents the response for the recent logs endpoint.
+type LogsResponse struct {
+	Entries  []logbuffer.Entry `json:"entries"`
+	Count    int               `json:"count"`
+	Capacity int               `json:"capacity"`
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if s.logs == nil {
+		err := types.NewConfigError("log.buffer_size", "log buffer is not enabled")
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	minLevel := slog.LevelDebug
+	if value := query.Get("level"); value != "" {
+		if err := minLevel.UnmarshalText([]byte(value)); err != nil {
+			respondError(w, http.StatusBadRequest, "level must be one of debug, info, warn, error")
+			return
+		}
+	}
+
+	limit := defaultLogLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	entries := s.logs.Recent(minLevel, limit)
+	respondJSON(w, http.StatusOK, LogsResponse{
+		Entries:  entries,
+		Count:    len(entries),
+		Capacity: s.logs.Capacity(),
+	})
+}
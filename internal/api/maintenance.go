@@ -9,10 +9,15 @@ import (
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/service"
 )
 
+// confirmVacuumFullHeader confirms a VACUUM FULL run whose disk space check asks for confirmation.
+const confirmVacuumFullHeader = "X-Confirm-Vacuum-Full"
+
 // VacuumRequest represents the JSON request body for vacuum operations.
 type VacuumRequest struct {
 	Tables  []string `json:"tables"`  // Tables specifies which tables to vacuum
 	Analyze bool     `json:"analyze"` // Analyze indicates whether to run ANALYZE after VACUUM
+	Full    bool     `json:"full"`    // Full runs VACUUM FULL, which rewrites tables to return space to the operating system
+	DryRun  bool     `json:"dry_run"` // DryRun only reports the disk space estimate of VACUUM FULL
 }
 
 // AnalyzeRequest represents the JSON request body for analyze operations.
@@ -31,6 +36,21 @@ func (s *Server) handleDatabaseHealth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, health)
 }
 
+func (s *Server) handleSchemaCheck(w http.ResponseWriter, r *http.Request) {
+	result, err := s.service.Maintenance.CheckSchema(r.Context())
+	if err != nil {
+		slog.Error("Schema check failed", "error", err)
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	if !result.Valid {
+		slog.Warn("Database schema does not match expected columns", "issues", len(result.Issues))
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
 func (s *Server) handleVacuum(w http.ResponseWriter, r *http.Request) {
 	var req VacuumRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
@@ -38,20 +58,31 @@ func (s *Server) handleVacuum(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := s.service.Maintenance.StartVacuum(service.VacuumOptions{
-		Tables:  req.Tables,
-		Analyze: req.Analyze,
+	if req.DryRun {
+		s.handleVacuumFullEstimate(w, r, req)
+		return
+	}
+
+	err := s.service.Maintenance.StartVacuum(r.Context(), service.VacuumOptions{
+		Tables:    req.Tables,
+		Analyze:   req.Analyze,
+		Full:      req.Full,
+		Confirmed: r.Header.Get(confirmVacuumFullHeader) == "true",
 	})
 	if err != nil {
-		slog.Error("Failed to start vacuum", "tables", req.Tables, "error", err)
+		slog.Error("Failed to start vacuum", "tables", req.Tables, "full", req.Full, "error", err)
 		respondError(w, errorCode(err), err.Error())
 		return
 	}
 
-	msg := "Vacuum started"
+	msg := "Vacuum"
+	if req.Full {
+		msg += " full"
+	}
 	if req.Analyze {
-		msg = "Vacuum with analyze started"
+		msg += " with analyze"
 	}
+	msg += " started"
 	slog.Info(msg, "tables", req.Tables)
 	respondJSON(w, http.StatusAccepted, AsyncStartResponse{
 		Message: msg,
@@ -59,6 +90,22 @@ func (s *Server) handleVacuum(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleVacuumFullEstimate(w http.ResponseWriter, r *http.Request, req VacuumRequest) {
+	if !req.Full {
+		respondError(w, http.StatusBadRequest, "dry_run is only supported together with full")
+		return
+	}
+
+	estimate, err := s.service.Maintenance.EstimateVacuumFull(r.Context(), req.Tables)
+	if err != nil {
+		slog.Error("VACUUM FULL estimate failed", "tables", req.Tables, "error", err)
+		respondError(w, errorCode(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, estimate)
+}
+
 func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
 	var req AnalyzeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
@@ -0,0 +1,119 @@
+// Package api provides the HTTP API server for the Aeron radio automation system.
+package api
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+)
+
+// rateLimitPurgeInterval is how often buckets of idle clients are removed.
+const rateLimitPurgeInterval = time.Minute
+
+// tokenBucket tracks the remaining requests of one client.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter limits requests per client with a token bucket: each client may send burst requests
+// at once, after which tokens are refilled at the configured rate.
+type rateLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPurge time.Time
+}
+
+// newRateLimiter creates a rateLimiter from the configuration, or returns nil when rate limiting is disabled.
+func newRateLimiter(cfg *config.RateLimitConfig) *rateLimiter {
+	if cfg.RequestsPerMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:      float64(cfg.RequestsPerMinute) / 60,
+		burst:     float64(cfg.GetBurst()),
+		buckets:   make(map[string]*tokenBucket),
+		lastPurge: time.Now(),
+	}
+}
+
+// allow takes a token from the bucket of key. If none is left, it returns how long until the next token.
+func (l *rateLimiter) allow(key string) (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPurge) >= rateLimitPurgeInterval {
+		l.purgeLocked(now)
+	}
+
+	bucket, found := l.buckets[key]
+	if !found {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// purgeLocked removes buckets that have refilled completely, as they behave like new ones. Caller must hold l.mu.
+func (l *rateLimiter) purgeLocked(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPurge = now
+}
+
+// rateLimitMiddleware rejects requests with 429 Too Many Requests once a client exceeds api.rate_limit.
+// Clients are identified by their API key, or by IP address when they send no valid key.
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.rateLimiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := "ip:" + clientIP(r)
+		if apiKey := r.Header.Get("X-API-Key"); s.isValidAPIKey(apiKey) {
+			key = "key:" + apiKey
+		}
+
+		if ok, retryAfter := s.rateLimiter.allow(key); !ok {
+			slog.Debug("Rate limit exceeded",
+				"path", r.URL.Path,
+				"method", r.Method,
+				"remote_addr", r.RemoteAddr)
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			respondError(w, http.StatusTooManyRequests, "Too many requests")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the IP address of the client. The RealIP middleware has already applied proxy headers.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
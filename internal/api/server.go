@@ -3,6 +3,7 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"slices"
@@ -10,30 +11,50 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/logbuffer"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/service"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
 )
 
 // Server represents the HTTP API server for the Aeron radio automation system.
 type Server struct {
-	service *service.AeronService
-	version string
-	server  *http.Server
+	service     *service.AeronService
+	scheduler   *service.Scheduler
+	version     string
+	server      *http.Server
+	metricsSrv  *http.Server // nil when metrics are served on the API port
+	metrics     *httpMetrics
+	idempotency *idempotencyStore
+	rateLimiter *rateLimiter      // nil when rate limiting is disabled
+	logs        *logbuffer.Buffer // nil when the log buffer is disabled
+	shutdown    chan struct{}     // closed on shutdown to end event streams
 }
 
-// New creates a new Server instance.
-func New(svc *service.AeronService, version string) *Server {
+// New creates a new Server instance. logs may be nil when recent log records are not kept.
+func New(svc *service.AeronService, scheduler *service.Scheduler, version string, logs *logbuffer.Buffer) *Server {
 	return &Server{
-		service: svc,
-		version: version,
+		service:     svc,
+		scheduler:   scheduler,
+		version:     version,
+		metrics:     newHTTPMetrics(svc.Metrics),
+		idempotency: newIdempotencyStore(),
+		rateLimiter: newRateLimiter(&svc.Config().API.RateLimit),
+		logs:        logs,
+		shutdown:    make(chan struct{}),
 	}
 }
 
 // Start initializes and starts the HTTP server on the specified port.
 func (s *Server) Start(port string) error {
 	router := chi.NewRouter()
+	router.Use(s.metrics.middleware)
 
+	corsConfig := &s.service.Config().API.CORS
 	cop := http.NewCrossOriginProtection()
+	if err := trustCORSOrigins(cop, corsConfig); err != nil {
+		return fmt.Errorf("invalid api.cors.allowed_origins: %w", err)
+	}
+	router.Use(corsMiddleware(corsConfig))
 	router.Use(func(next http.Handler) http.Handler {
 		return cop.Handler(next)
 	})
@@ -48,6 +69,12 @@ func (s *Server) Start(port string) error {
 		respondError(w, http.StatusNotFound, "Endpoint not found")
 	})
 
+	if metricsPort := s.service.Config().API.MetricsPort; metricsPort == "" {
+		router.Method(http.MethodGet, "/metrics", metricsHandler(s.service.Metrics))
+	} else {
+		s.startMetricsServer(metricsPort)
+	}
+
 	router.Route("/api", func(r chi.Router) {
 		r.Use(middleware.SetHeader("Content-Type", "application/json; charset=utf-8"))
 
@@ -59,15 +86,42 @@ func (s *Server) Start(port string) error {
 
 		// Routes with standard request timeout
 		r.Group(func(r chi.Router) {
+			r.Use(s.rateLimitMiddleware)
 			r.Use(s.authMiddleware)
 			r.Use(middleware.Timeout(s.service.Config().API.GetRequestTimeout()))
+			r.Use(s.idempotencyMiddleware)
 
 			s.setupEntityRoutes(r, "/artists", types.EntityTypeArtist)
 			s.setupEntityRoutes(r, "/tracks", types.EntityTypeTrack)
+			r.Get("/search", s.handleSearch)
+			r.Post("/stats/refresh", s.handleRefreshStats)
+			r.Get("/stats/attention", s.handleAttentionStats)
+			r.Get("/stats/image-sizes", s.handleImageSizeStats)
+			r.Get("/stats/similar-images", s.handleSimilarImages)
+			r.Get("/metadata/classifications", s.handleClassifications)
+			r.With(s.adminKeyMiddleware).Get("/config", s.handleConfig)
+			r.With(s.adminKeyMiddleware).Get("/logs", s.handleLogs)
+
+			r.With(s.adminKeyMiddleware).Post("/images/benchmark", s.handleImageBenchmark)
+			r.Route("/images/jobs", func(r chi.Router) {
+				r.Post("/", s.handleSubmitImageJob)
+				r.Get("/{id}", s.handleImageJobStatus)
+			})
+			r.Route("/images/reoptimize", func(r chi.Router) {
+				r.Get("/", s.handleReoptimizeStatus)
+				r.With(s.adminKeyMiddleware).Post("/", s.handleStartReoptimize)
+				r.With(s.adminKeyMiddleware).Post("/pause", s.handlePauseReoptimize)
+				r.With(s.adminKeyMiddleware).Post("/resume", s.handleResumeReoptimize)
+				r.With(s.adminKeyMiddleware).Post("/cancel", s.handleCancelReoptimize)
+			})
 
 			r.Get("/playlist", s.handlePlaylist)
+			r.Get("/playlist/stats", s.handlePlaylistStats)
+			r.With(s.writeKeyMiddleware).Patch("/playlist/items/{id}", s.handleUpdatePlaylistItem)
 
 			r.Route("/db", func(r chi.Router) {
+				r.Get("/schema-check", s.handleSchemaCheck)
+
 				// Maintenance endpoints (async)
 				r.Route("/maintenance", func(r chi.Router) {
 					r.Get("/health", s.handleDatabaseHealth)
@@ -79,7 +133,11 @@ func (s *Server) Start(port string) error {
 				// Backup endpoints
 				r.Get("/backups", s.handleListBackups)
 				r.Get("/backup/status", s.handleBackupStatus)
+				r.Get("/backup/schedule", s.handleBackupSchedule)
+				r.With(s.adminKeyMiddleware).Post("/backup/scheduler/pause", s.handlePauseBackupScheduler)
+				r.With(s.adminKeyMiddleware).Post("/backup/scheduler/resume", s.handleResumeBackupScheduler)
 				r.Get("/backups/{filename}/validate", s.handleValidateBackup)
+				r.Post("/backups/validate-all", s.handleValidateAllBackups)
 				r.Delete("/backups/{filename}", s.handleDeleteBackup)
 			})
 		})
@@ -87,12 +145,32 @@ func (s *Server) Start(port string) error {
 		// Backup routes - no special timeout needed
 		// POST /backup returns immediately (async), downloads are served via http.ServeFile
 		r.Group(func(r chi.Router) {
+			r.Use(s.rateLimitMiddleware)
 			r.Use(s.authMiddleware)
 			r.Use(middleware.Timeout(s.service.Config().API.GetRequestTimeout()))
+			r.Use(s.idempotencyMiddleware)
 
 			r.Post("/db/backup", s.handleCreateBackup)
+			r.Get("/db/backups/archive", s.handleDownloadBackupArchive)
 			r.Get("/db/backups/{filename}", s.handleDownloadBackupFile)
 		})
+
+		// Event streams stay open until the client disconnects, so they have no request timeout
+		r.Group(func(r chi.Router) {
+			r.Use(s.rateLimitMiddleware)
+			r.Use(s.authMiddleware)
+
+			r.Get("/db/backup/events", s.handleBackupEvents)
+		})
+
+		// Restores block until pg_restore finishes, bounded by backup.timeout_minutes instead of the request timeout
+		r.Group(func(r chi.Router) {
+			r.Use(s.rateLimitMiddleware)
+			r.Use(s.authMiddleware)
+			r.Use(s.adminKeyMiddleware)
+
+			r.Post("/db/restore", s.handleRestoreBackup)
+		})
 	})
 
 	s.server = &http.Server{
@@ -100,12 +178,38 @@ func (s *Server) Start(port string) error {
 		Handler:           router,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
+	s.server.RegisterOnShutdown(func() {
+		close(s.shutdown)
+	})
 
 	return s.server.ListenAndServe()
 }
 
+// startMetricsServer serves /metrics on a separate port, so it can be kept off the network that reaches the API.
+func (s *Server) startMetricsServer(port string) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metricsHandler(s.service.Metrics))
+	s.metricsSrv = &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		slog.Info("Metrics server started", "port", port)
+		if err := s.metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Metrics server failed", "port", port, "error", err)
+		}
+	}()
+}
+
 // Shutdown gracefully shuts down the server.
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.metricsSrv != nil {
+		if err := s.metricsSrv.Shutdown(ctx); err != nil {
+			slog.Warn("Metrics server shutdown failed", "error", err)
+		}
+	}
 	if s.server == nil {
 		return nil
 	}
@@ -116,9 +220,23 @@ func (s *Server) setupEntityRoutes(r chi.Router, path string, entityType types.E
 	r.Route(path, func(r chi.Router) {
 		r.Get("/", s.handleStats(entityType))
 		r.Delete("/bulk-delete", s.handleBulkDelete(entityType))
+		if entityType == types.EntityTypeArtist {
+			r.Get("/list", s.handleListArtists)
+		}
+		if entityType == types.EntityTypeTrack {
+			r.Get("/list", s.handleListTracks)
+			r.Post("/batch", s.handleTrackBatch)
+		}
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", s.handleEntityByID(entityType))
+			if entityType == types.EntityTypeTrack {
+				r.With(s.writeKeyMiddleware).Patch("/", s.handleUpdateTrack)
+			}
+			if entityType == types.EntityTypeArtist {
+				r.With(s.writeKeyMiddleware).Patch("/", s.handleUpdateArtist)
+				r.Get("/tracks", s.handleArtistTracks)
+			}
 			r.Route("/image", func(r chi.Router) {
 				r.Get("/", s.handleGetImage(entityType))
 				r.Post("/", s.handleImageUpload(entityType))
@@ -153,14 +271,85 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// writeKeyMiddleware only admits requests with a key from api.write_keys.
+// Without configured write keys the wrapped endpoints are unavailable, even when authentication is disabled.
+func (s *Server) writeKeyMiddleware(next http.Handler) http.Handler {
+	return scopedKeyMiddleware(next, "write", s.service.Config().API.WriteKeys)
+}
+
+// adminKeyMiddleware only admits requests with a key from api.admin_keys.
+// Without configured admin keys the wrapped endpoints are unavailable, even when authentication is disabled.
+func (s *Server) adminKeyMiddleware(next http.Handler) http.Handler {
+	return scopedKeyMiddleware(next, "admin", s.service.Config().API.AdminKeys)
+}
+
+// scopedKeyMiddleware only admits requests whose API key is one of keys; scope names the kind of key in responses and logs.
+func scopedKeyMiddleware(next http.Handler, scope string, keys []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(keys) == 0 {
+			respondError(w, http.StatusForbidden, "Forbidden: no "+scope+" keys configured")
+			return
+		}
+
+		apiKey := r.Header.Get("X-API-Key")
+		if !slices.Contains(keys, apiKey) {
+			slog.Warn("Authorization failed",
+				"reason", "missing_"+scope+"_key",
+				"path", r.URL.Path,
+				"method", r.Method,
+				"remote_addr", r.RemoteAddr)
+
+			respondError(w, http.StatusForbidden, "Forbidden: this endpoint requires a key from api."+scope+"_keys")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isValidAPIKey reports whether key is a configured API key; write and admin keys are valid API keys too.
 func (s *Server) isValidAPIKey(key string) bool {
-	return key != "" && slices.Contains(s.service.Config().API.Keys, key)
+	cfg := s.service.Config()
+	return key != "" && (slices.Contains(cfg.API.Keys, key) || slices.Contains(cfg.API.WriteKeys, key) || slices.Contains(cfg.API.AdminKeys, key))
 }
 
+// detectImageContentType sniffs the content type of stored image data. Aeron keeps no content type
+// next to the picture, so JPEG, WebP and AVIF images are recognized by their signature.
 func detectImageContentType(data []byte) string {
+	if isAVIF(data) {
+		return "image/avif"
+	}
 	return http.DetectContentType(data)
 }
 
+// isAVIF reports whether data starts with an ISO BMFF file type box with an AVIF brand,
+// which http.DetectContentType does not recognize.
+func isAVIF(data []byte) bool {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+	brand := string(data[8:12])
+	return brand == "avif" || brand == "avis"
+}
+
+// imageExtension returns the file extension matching a detected image content type.
+func imageExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	case "image/avif":
+		return ".avif"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".bin"
+	}
+}
+
 func parseQueryBoolParam(value string) *bool {
 	switch value {
 	case "yes", "true", "1":
@@ -5,8 +5,11 @@ import (
 	"cmp"
 	"encoding/json"
 	"fmt"
+	"image/color"
 	"log/slog"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,28 +24,84 @@ type DatabaseConfig struct {
 	Port                   string `json:"port" validate:"required"`
 	Name                   string `json:"name" validate:"required"`
 	User                   string `json:"user" validate:"required"`
-	Password               string `json:"password" validate:"required"`
+	Password               string `json:"password" validate:"required_without=PasswordFile"`
+	PasswordFile           string `json:"password_file"` // read at load time, takes precedence over password
 	Schema                 string `json:"schema" validate:"required,identifier"`
 	SSLMode                string `json:"sslmode" validate:"required"`
 	MaxOpenConns           int    `json:"max_open_conns" validate:"gte=0"`
 	MaxIdleConns           int    `json:"max_idle_conns" validate:"gte=0"`
 	ConnMaxLifetimeMinutes int    `json:"conn_max_lifetime_minutes" validate:"gte=0"`
+	HealthCheckConns       int    `json:"health_check_conns" validate:"gte=0"` // separate pool so health checks never wait for busy connections
 }
 
 // ImageConfig contains image processing and optimization settings.
 type ImageConfig struct {
-	TargetWidth               int   `json:"target_width" validate:"required,gt=0"`
-	TargetHeight              int   `json:"target_height" validate:"required,gt=0"`
-	Quality                   int   `json:"quality" validate:"required,min=1,max=100"`
-	RejectSmaller             bool  `json:"reject_smaller"`
-	MaxImageDownloadSizeBytes int64 `json:"max_image_download_size_bytes" validate:"gte=0"`
+	TargetWidth                int                      `json:"target_width" validate:"required,gt=0"`
+	TargetHeight               int                      `json:"target_height" validate:"required,gt=0"`
+	MaxWidth                   int                      `json:"max_width" validate:"gte=0"`  // absolute maximum output width, also when target_width is larger
+	MaxHeight                  int                      `json:"max_height" validate:"gte=0"` // absolute maximum output height, also when target_height is larger
+	Quality                    int                      `json:"quality" validate:"required,min=1,max=100"`
+	AdaptiveQuality            bool                     `json:"adaptive_quality"` // pick the quality per image between min_quality and max_quality
+	MinQuality                 int                      `json:"min_quality" validate:"omitempty,min=1,max=100"`
+	MaxQuality                 int                      `json:"max_quality" validate:"omitempty,min=1,max=100"`
+	RejectSmaller              bool                     `json:"reject_smaller"`
+	MinSourceBytes             int64                    `json:"min_source_bytes" validate:"gte=0"`                                  // reject smaller source files as likely low quality, 0 disables the check
+	RejectFormatMismatch       bool                     `json:"reject_format_mismatch"`                                             // reject uploads whose content differs from the declared format
+	SkipOptimizationBelowBytes int64                    `json:"skip_optimization_below_bytes" validate:"gte=0"`                     // store smaller sources within the target dimensions as-is, 0 always optimizes
+	NotSmallerPolicy           string                   `json:"not_smaller_policy" validate:"omitempty,oneof=keep reencode reject"` // what to do when optimizing does not reduce the size
+	OutputFormat               string                   `json:"output_format" validate:"omitempty,oneof=jpeg webp auto"`            // auto keeps the smaller of JPEG and WebP
+	EnableAVIF                 bool                     `json:"enable_avif"`                                                        // also encode AVIF and keep it when at least 15% smaller
+	EnableHEIC                 bool                     `json:"enable_heic"`                                                        // accept HEIC/HEIF uploads and convert them to the output format
+	BackgroundColor            string                   `json:"background_color" validate:"omitempty,rgbhex"`                       // fills transparent areas when encoding to JPEG
+	StoreVariants              bool                     `json:"store_variants"`                                                     // also store thumb and medium variants of each uploaded image
+	CreateVariantTable         bool                     `json:"create_variant_table"`                                               // let the toolbox create the variant table in the Aeron schema when it is missing
+	ThumbSize                  int                      `json:"thumb_size" validate:"gte=0"`
+	MediumSize                 int                      `json:"medium_size" validate:"gte=0"`
+	ThumbnailSizes             map[string]ThumbnailSize `json:"thumbnail_sizes" validate:"dive"` // sizes that can be requested with ?size= and are resized on the fly
+	ThumbnailCacheEntries      int                      `json:"thumbnail_cache_entries" validate:"gte=0"`
+	PerceptualHash             bool                     `json:"perceptual_hash"` // return a perceptual hash of each uploaded image
+	StripMetadata              bool                     `json:"strip_metadata"`  // remove EXIF, XMP, IPTC and ICC metadata, also from images stored as-is
+	MaxImageDownloadSizeBytes  int64                    `json:"max_image_download_size_bytes" validate:"gte=0"`
+	MaxImageDownloadRedirects  *int                     `json:"max_image_download_redirects" validate:"omitempty,gte=0"` // redirects followed per download, 0 follows none; 5 when unset
+	MaxImagePixels             int64                    `json:"max_image_pixels" validate:"gte=0"`
+	JobWorkers                 int                      `json:"job_workers" validate:"gte=0"`
+	JobRetentionMinutes        int                      `json:"job_retention_minutes" validate:"gte=0"`
+	ReoptimizeWorkers          int                      `json:"reoptimize_workers" validate:"gte=0"` // images reprocessed concurrently by a bulk reoptimization
+	StatsCache                 bool                     `json:"stats_cache"`                         // serve image statistics from a periodically refreshed cache
+	StatsRefreshMinutes        int                      `json:"stats_refresh_minutes" validate:"gte=0"`
+}
+
+// ThumbnailSize is the bounding box an image is resized to when requested with ?size=.
+type ThumbnailSize struct {
+	Width  int `json:"width" validate:"gt=0"`
+	Height int `json:"height" validate:"gt=0"`
 }
 
 // APIConfig contains API authentication and server settings.
 type APIConfig struct {
-	Enabled               bool     `json:"enabled"`
-	Keys                  []string `json:"keys" validate:"required_if=Enabled true,dive,required"`
-	RequestTimeoutSeconds int      `json:"request_timeout_seconds" validate:"gte=0"`
+	Enabled               bool            `json:"enabled"`
+	Keys                  []string        `json:"keys" validate:"required_if=Enabled true,dive,required"`
+	WriteKeys             []string        `json:"write_keys" validate:"dive,required"` // keys that may also change playlist data
+	AdminKeys             []string        `json:"admin_keys" validate:"dive,required"` // keys that may also run administrative endpoints
+	RequestTimeoutSeconds int             `json:"request_timeout_seconds" validate:"gte=0"`
+	IdempotencyTTLMinutes int             `json:"idempotency_ttl_minutes" validate:"gte=0"` // how long responses are kept for Idempotency-Key replays
+	MaxBatchSize          int             `json:"max_batch_size" validate:"gte=0"`          // most items accepted by any batch endpoint
+	RateLimit             RateLimitConfig `json:"rate_limit"`
+	CORS                  CORSConfig      `json:"cors"`
+	MetricsPort           string          `json:"metrics_port" validate:"omitempty,numeric"` // serves /metrics on a separate port instead of the API port
+}
+
+// CORSConfig controls which browser origins may call the API directly. CORS is disabled without allowed origins.
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins" validate:"dive,required"` // exact origins such as https://dashboard.example.com, or *
+	AllowedMethods   []string `json:"allowed_methods" validate:"dive,required"`
+	AllowCredentials bool     `json:"allow_credentials"`
+}
+
+// RateLimitConfig limits the request rate per API key, or per IP address for requests without a valid key.
+type RateLimitConfig struct {
+	RequestsPerMinute int `json:"requests_per_minute" validate:"gte=0"` // 0 disables rate limiting
+	Burst             int `json:"burst" validate:"gte=0"`               // requests allowed at once, defaults to requests_per_minute
 }
 
 // MaintenanceConfig contains thresholds and settings for database maintenance operations.
@@ -54,7 +113,13 @@ type MaintenanceConfig struct {
 	ToastSizeWarningBytes    int64           `json:"toast_size_warning_bytes" validate:"gte=0"`
 	StaleStatsThresholdPct   int             `json:"stale_stats_threshold_pct" validate:"gte=0,lte=100"`
 	SeqScanRatioThreshold    float64         `json:"seq_scan_ratio_threshold" validate:"gte=0"`
+	PoolServerSharePct       int             `json:"pool_server_share_pct" validate:"gte=0,lte=100"` // warn when the pool may use more of the server's max_connections
+	PoolWaitCountThreshold   int64           `json:"pool_wait_count_threshold" validate:"gte=0"`
 	TimeoutMinutes           int             `json:"timeout_minutes" validate:"gte=0"`
+	LockTimeoutSeconds       int             `json:"lock_timeout_seconds" validate:"gte=0"`     // how long a maintenance statement waits for a table lock
+	DataDirectory            string          `json:"data_directory"`                            // local path on the disk holding the PostgreSQL data, checked for free space before VACUUM FULL
+	HealthCacheSeconds       int             `json:"health_cache_seconds" validate:"gte=0"`     // how long a health report is served from the cache, 0 disables caching
+	HealthMaxStaleSeconds    int             `json:"health_max_stale_seconds" validate:"gte=0"` // age until which an expired report is still served while it is refreshed
 	Scheduler                SchedulerConfig `json:"scheduler"`
 }
 
@@ -62,38 +127,113 @@ type MaintenanceConfig struct {
 type SchedulerConfig struct {
 	Enabled  bool   `json:"enabled"`
 	Schedule string `json:"schedule" validate:"required_if=Enabled true"`
+	Timezone string `json:"timezone" validate:"omitempty,timezone"` // IANA name such as Europe/Amsterdam, the system timezone when empty
 }
 
 // S3Config contains settings for S3-compatible storage synchronization.
 type S3Config struct {
+	Enabled             bool   `json:"enabled"`
+	Bucket              string `json:"bucket" validate:"required_if=Enabled true"`
+	Region              string `json:"region"`
+	Endpoint            string `json:"endpoint"`
+	AccessKeyID         string `json:"access_key_id" validate:"required_if=Enabled true"`
+	SecretAccessKey     string `json:"secret_access_key"`
+	SecretAccessKeyFile string `json:"secret_access_key_file"` // read at load time, takes precedence over secret_access_key
+	PathPrefix          string `json:"path_prefix"`
+	ForcePathStyle      bool   `json:"force_path_style"`
+
+	MaxConcurrentUploads    int   `json:"max_concurrent_uploads" validate:"gte=0"`
+	MaxUploadBytesPerSecond int64 `json:"max_upload_bytes_per_second" validate:"gte=0"`
+	ApplyRetention          bool  `json:"apply_retention"` // prune S3 objects using retention_days and max_backups
+}
+
+// GCSConfig contains settings for Google Cloud Storage synchronization.
+type GCSConfig struct {
 	Enabled         bool   `json:"enabled"`
 	Bucket          string `json:"bucket" validate:"required_if=Enabled true"`
-	Region          string `json:"region"`
-	Endpoint        string `json:"endpoint"`
-	AccessKeyID     string `json:"access_key_id" validate:"required_if=Enabled true"`
-	SecretAccessKey string `json:"secret_access_key" validate:"required_if=Enabled true"`
+	CredentialsFile string `json:"credentials_file"` // service account key in JSON format
 	PathPrefix      string `json:"path_prefix"`
-	ForcePathStyle  bool   `json:"force_path_style"`
+	ApplyRetention  bool   `json:"apply_retention"` // prune GCS objects using retention_days and max_backups
+}
+
+// AzureConfig contains settings for Azure Blob Storage synchronization.
+type AzureConfig struct {
+	Enabled        bool   `json:"enabled"`
+	AccountName    string `json:"account_name" validate:"required_if=Enabled true"`
+	AccountKey     string `json:"account_key"`
+	AccountKeyFile string `json:"account_key_file"` // read at load time, takes precedence over account_key
+	Container      string `json:"container" validate:"required_if=Enabled true"`
+	Endpoint       string `json:"endpoint"` // defaults to https://<account_name>.blob.core.windows.net/
+	PathPrefix     string `json:"path_prefix"`
+	ApplyRetention bool   `json:"apply_retention"` // prune Azure blobs using retention_days and max_backups
 }
 
 // BackupConfig contains settings for database backup functionality.
 type BackupConfig struct {
-	Enabled            bool            `json:"enabled"`
-	Path               string          `json:"path" validate:"required_if=Enabled true"`
-	RetentionDays      int             `json:"retention_days" validate:"gte=0"`
-	MaxBackups         int             `json:"max_backups" validate:"gte=0"`
-	DefaultCompression int             `json:"default_compression" validate:"gte=0,lte=9"`
-	TimeoutMinutes     int             `json:"timeout_minutes" validate:"gte=0"`
-	PgDumpPath         string          `json:"pg_dump_path"`
-	PgRestorePath      string          `json:"pg_restore_path"`
-	Scheduler          SchedulerConfig `json:"scheduler"`
-	S3                 S3Config        `json:"s3"`
-}
+	Enabled                   bool                 `json:"enabled"`
+	Path                      string               `json:"path" validate:"required_if=Enabled true"`
+	SubdirLayout              string               `json:"subdir_layout" validate:"omitempty,oneof=flat daily monthly"` // store backups in YYYY/MM/DD or YYYY/MM subdirectories
+	RetentionDays             int                  `json:"retention_days" validate:"gte=0"`
+	MaxBackups                int                  `json:"max_backups" validate:"gte=0"`
+	MaxTotalBytes             int64                `json:"max_total_bytes" validate:"gte=0"`
+	MinBackups                int                  `json:"min_backups" validate:"gte=0"`
+	RetentionTiers            RetentionTiersConfig `json:"retention_tiers"`
+	DefaultCompression        int                  `json:"default_compression" validate:"gte=0,lte=9"`
+	PerTable                  bool                 `json:"per_table"` // write one dump file per table instead of a single dump
+	ExcludeTables             []string             `json:"exclude_tables" validate:"dive,identifier"`
+	TimeoutMinutes            int                  `json:"timeout_minutes" validate:"gte=0"`
+	DownloadTimeoutMinutes    int                  `json:"download_timeout_minutes" validate:"gte=0"` // limits how long a single download may hold its connection
+	Validate                  *bool                `json:"validate"`                                  // check each backup with pg_restore --list, true when unset
+	ValidateTimeoutSeconds    int                  `json:"validate_timeout_seconds" validate:"gte=0"` // per dump file
+	AsyncValidation           bool                 `json:"async_validation"`                          // validate after the backup is reported done
+	PgDumpPath                string               `json:"pg_dump_path"`
+	PgRestorePath             string               `json:"pg_restore_path"`
+	PostCommand               string               `json:"post_command"` // executable run with the backup path after each successful backup
+	PostCommandTimeoutSeconds int                  `json:"post_command_timeout_seconds" validate:"gte=0"`
+	OverlapPolicy             string               `json:"overlap_policy" validate:"omitempty,oneof=skip queue cancel-previous"` // what a scheduled backup does while another backup runs
+	OverlapGraceMinutes       int                  `json:"overlap_grace_minutes" validate:"gte=0"`                               // how long a scheduled backup waits for a running backup to end
+	Scheduler                 SchedulerConfig      `json:"scheduler"`
+	StorageBackend            string               `json:"storage_backend" validate:"omitempty,oneof=s3 gcs azure"` // remote storage that backups are synchronized to
+	S3                        S3Config             `json:"s3"`
+	GCS                       GCSConfig            `json:"gcs"`
+	Azure                     AzureConfig          `json:"azure"`
+	Encryption                EncryptionConfig     `json:"encryption"`
+}
+
+// EncryptionConfig contains settings for encrypting backups with age before they are stored.
+type EncryptionConfig struct {
+	Enabled      bool   `json:"enabled"`
+	PublicKey    string `json:"public_key" validate:"required_if=Enabled true"` // age recipient (age1...)
+	IdentityFile string `json:"identity_file"`                                  // age identity for validating and restoring, optional
+}
+
+// RetentionTiersConfig contains grandfather-father-son backup retention settings.
+// Each value is the number of most recent days, weeks, or months for which the newest backup is kept.
+type RetentionTiersConfig struct {
+	Daily   int `json:"daily" validate:"gte=0"`
+	Weekly  int `json:"weekly" validate:"gte=0"`
+	Monthly int `json:"monthly" validate:"gte=0"`
+}
+
+// Enabled reports whether any retention tier is configured.
+func (c *RetentionTiersConfig) Enabled() bool {
+	return c.Daily > 0 || c.Weekly > 0 || c.Monthly > 0
+}
+
+// MetadataConfig contains labels for Aeron's numeric track and playlist classification codes.
+type MetadataConfig struct {
+	// Classifications maps a track field (gender, language, mood, tempo, exporttype) or the playlist item mode to code labels.
+	Classifications map[string]map[int]string `json:"classifications" validate:"dive,keys,oneof=gender language mood tempo exporttype mode,endkeys"`
+}
+
+// ClassificationFields lists the track and playlist item fields that hold classification codes.
+var ClassificationFields = []string{"gender", "language", "mood", "tempo", "exporttype", "mode"}
 
 // LogConfig contains logging configuration.
 type LogConfig struct {
-	Level  string `json:"level" validate:"omitempty,oneof=debug info warn error"`
-	Format string `json:"format" validate:"omitempty,oneof=text json"`
+	Level      string `json:"level" validate:"omitempty,oneof=debug info warn error"`
+	Format     string `json:"format" validate:"omitempty,oneof=text json"`
+	BufferSize int    `json:"buffer_size" validate:"gte=0,lte=100000"` // keep the last records in memory for GET /api/logs, 0 disables
 }
 
 // Config represents the complete application configuration.
@@ -103,6 +243,7 @@ type Config struct {
 	API         APIConfig         `json:"api"`
 	Maintenance MaintenanceConfig `json:"maintenance"`
 	Backup      BackupConfig      `json:"backup"`
+	Metadata    MetadataConfig    `json:"metadata"`
 	Log         LogConfig         `json:"log"`
 }
 
@@ -110,8 +251,28 @@ const (
 	DefaultMaxOpenConnections        = 25
 	DefaultMaxIdleConnections        = 5
 	DefaultConnMaxLifetimeMinutes    = 5
+	DefaultHealthCheckConnections    = 1
 	DefaultMaxImageDownloadSizeBytes = 50 * 1024 * 1024
+	DefaultMaxImageDownloadRedirects = 5
+	DefaultMaxImagePixels            = 50_000_000
+	DefaultMaxImageWidth             = 4096
+	DefaultMaxImageHeight            = 4096
+	DefaultNotSmallerPolicy          = "keep"
+	DefaultOutputFormat              = "jpeg"
+	DefaultBackgroundColor           = "#FFFFFF"
+	DefaultMinImageQuality           = 60
+	DefaultMaxImageQuality           = 90
+	DefaultThumbSize                 = 160
+	DefaultMediumSize                = 320
+	DefaultSmallSize                 = 240
+	DefaultThumbnailCacheEntries     = 500
+	DefaultImageJobWorkers           = 4
+	DefaultImageJobRetentionMinutes  = 60
+	DefaultReoptimizeWorkers         = 1
+	DefaultStatsRefreshMinutes       = 15
 	DefaultRequestTimeoutSeconds     = 30
+	DefaultIdempotencyTTLMinutes     = 60
+	DefaultMaxBatchSize              = 100
 	DefaultBloatThreshold            = 10.0
 	DefaultDeadTupleThreshold        = 10000
 	DefaultVacuumStalenessDays       = 7
@@ -119,12 +280,24 @@ const (
 	DefaultToastSizeWarningBytes     = 500 * 1024 * 1024
 	DefaultStaleStatsThresholdPct    = 10
 	DefaultSeqScanRatioThreshold     = 10.0
+	DefaultPoolServerSharePct        = 50
+	DefaultPoolWaitCountThreshold    = 100
 	DefaultMaintenanceTimeoutMinutes = 30
+	DefaultLockTimeoutSeconds        = 30
 	DefaultBackupRetentionDays       = 30
 	DefaultBackupMaxBackups          = 10
+	DefaultBackupMinBackups          = 1
 	DefaultBackupCompression         = 9
 	DefaultBackupPath                = "./backups"
+	DefaultBackupSubdirLayout        = "flat"
 	DefaultBackupTimeoutMinutes      = 30
+	DefaultDownloadTimeoutMinutes    = 60
+	DefaultValidateTimeoutSeconds    = 30
+	DefaultPostCommandTimeoutSeconds = 300
+	DefaultOverlapPolicy             = "skip"
+	DefaultOverlapGraceMinutes       = 60
+	DefaultS3MaxConcurrentUploads    = 1
+	DefaultStorageBackend            = "s3"
 )
 
 // GetMaxDownloadBytes returns the maximum allowed image download size in bytes.
@@ -132,11 +305,145 @@ func (c *ImageConfig) GetMaxDownloadBytes() int64 {
 	return cmp.Or(c.MaxImageDownloadSizeBytes, DefaultMaxImageDownloadSizeBytes)
 }
 
+// GetMaxDownloadRedirects returns the maximum number of redirects followed when downloading an image.
+// An explicit 0 follows no redirects; the default only applies when the setting is absent.
+func (c *ImageConfig) GetMaxDownloadRedirects() int {
+	if c.MaxImageDownloadRedirects == nil {
+		return DefaultMaxImageDownloadRedirects
+	}
+	return *c.MaxImageDownloadRedirects
+}
+
+// GetMaxPixels returns the maximum number of pixels (width × height) an image may have before it is decoded.
+func (c *ImageConfig) GetMaxPixels() int64 {
+	return cmp.Or(c.MaxImagePixels, DefaultMaxImagePixels)
+}
+
+// GetMaxWidth returns the absolute maximum width of optimized images.
+func (c *ImageConfig) GetMaxWidth() int {
+	return cmp.Or(c.MaxWidth, DefaultMaxImageWidth)
+}
+
+// GetMaxHeight returns the absolute maximum height of optimized images.
+func (c *ImageConfig) GetMaxHeight() int {
+	return cmp.Or(c.MaxHeight, DefaultMaxImageHeight)
+}
+
+// GetMinQuality returns the lowest encoding quality used in adaptive mode.
+func (c *ImageConfig) GetMinQuality() int {
+	return cmp.Or(c.MinQuality, DefaultMinImageQuality)
+}
+
+// GetMaxQuality returns the highest encoding quality used in adaptive mode.
+func (c *ImageConfig) GetMaxQuality() int {
+	return cmp.Or(c.MaxQuality, DefaultMaxImageQuality)
+}
+
+// GetThumbSize returns the maximum width and height of the thumb image variant.
+func (c *ImageConfig) GetThumbSize() int {
+	return cmp.Or(c.ThumbSize, DefaultThumbSize)
+}
+
+// GetMediumSize returns the maximum width and height of the medium image variant.
+func (c *ImageConfig) GetMediumSize() int {
+	return cmp.Or(c.MediumSize, DefaultMediumSize)
+}
+
+// GetThumbnailSizes returns the sizes images can be resized to on the fly. Without configured sizes,
+// thumb and medium match the stored variants and small sits in between.
+func (c *ImageConfig) GetThumbnailSizes() map[string]ThumbnailSize {
+	if len(c.ThumbnailSizes) > 0 {
+		return c.ThumbnailSizes
+	}
+	return map[string]ThumbnailSize{
+		"thumb":  {Width: c.GetThumbSize(), Height: c.GetThumbSize()},
+		"small":  {Width: DefaultSmallSize, Height: DefaultSmallSize},
+		"medium": {Width: c.GetMediumSize(), Height: c.GetMediumSize()},
+	}
+}
+
+// GetThumbnailCacheEntries returns how many resized images are kept in memory.
+func (c *ImageConfig) GetThumbnailCacheEntries() int {
+	return cmp.Or(c.ThumbnailCacheEntries, DefaultThumbnailCacheEntries)
+}
+
+// GetNotSmallerPolicy returns how images that cannot be optimized smaller are handled.
+func (c *ImageConfig) GetNotSmallerPolicy() string {
+	return cmp.Or(c.NotSmallerPolicy, DefaultNotSmallerPolicy)
+}
+
+// GetOutputFormat returns the format optimized images are encoded in.
+func (c *ImageConfig) GetOutputFormat() string {
+	return cmp.Or(c.OutputFormat, DefaultOutputFormat)
+}
+
+// GetBackgroundColor returns the hex color that transparent areas are filled with when encoding to JPEG.
+func (c *ImageConfig) GetBackgroundColor() string {
+	return cmp.Or(c.BackgroundColor, DefaultBackgroundColor)
+}
+
+// BackgroundRGBA returns the background color as an opaque color.RGBA.
+// The color is validated at load time, so it is always a #RGB or #RRGGBB value.
+func (c *ImageConfig) BackgroundRGBA() color.RGBA {
+	hex := strings.TrimPrefix(c.GetBackgroundColor(), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	value, _ := strconv.ParseUint(hex, 16, 32)
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xFF}
+}
+
+// GetJobWorkers returns the number of images processed concurrently by background image jobs.
+func (c *ImageConfig) GetJobWorkers() int {
+	return cmp.Or(c.JobWorkers, DefaultImageJobWorkers)
+}
+
+// GetJobRetention returns how long completed image jobs remain available for status queries.
+func (c *ImageConfig) GetJobRetention() time.Duration {
+	return time.Duration(cmp.Or(c.JobRetentionMinutes, DefaultImageJobRetentionMinutes)) * time.Minute
+}
+
+// GetReoptimizeWorkers returns the default number of images reprocessed concurrently by a bulk reoptimization.
+func (c *ImageConfig) GetReoptimizeWorkers() int {
+	return cmp.Or(c.ReoptimizeWorkers, DefaultReoptimizeWorkers)
+}
+
+// GetStatsRefreshInterval returns how often cached image statistics are recomputed.
+func (c *ImageConfig) GetStatsRefreshInterval() time.Duration {
+	return time.Duration(cmp.Or(c.StatsRefreshMinutes, DefaultStatsRefreshMinutes)) * time.Minute
+}
+
 // GetRequestTimeout returns the HTTP request timeout as a Duration.
 func (c *APIConfig) GetRequestTimeout() time.Duration {
 	return time.Duration(cmp.Or(c.RequestTimeoutSeconds, DefaultRequestTimeoutSeconds)) * time.Second
 }
 
+// GetIdempotencyTTL returns how long a response is replayed for requests with the same Idempotency-Key.
+func (c *APIConfig) GetIdempotencyTTL() time.Duration {
+	return time.Duration(cmp.Or(c.IdempotencyTTLMinutes, DefaultIdempotencyTTLMinutes)) * time.Minute
+}
+
+// DefaultCORSAllowedMethods are the methods allowed in cross-origin requests unless configured otherwise.
+var DefaultCORSAllowedMethods = []string{"GET", "POST", "PATCH", "DELETE"}
+
+// GetAllowedMethods returns the methods allowed in cross-origin requests.
+func (c *CORSConfig) GetAllowedMethods() []string {
+	if len(c.AllowedMethods) == 0 {
+		return DefaultCORSAllowedMethods
+	}
+	return c.AllowedMethods
+}
+
+// GetBurst returns how many requests a client may send at once before the rate limit applies.
+func (c *RateLimitConfig) GetBurst() int {
+	return cmp.Or(c.Burst, c.RequestsPerMinute)
+}
+
+// GetMaxBatchSize returns the maximum number of items accepted in a single batch request.
+func (c *APIConfig) GetMaxBatchSize() int {
+	return cmp.Or(c.MaxBatchSize, DefaultMaxBatchSize)
+}
+
 // GetMaxOpenConns returns the maximum number of open database connections.
 func (c *DatabaseConfig) GetMaxOpenConns() int {
 	return cmp.Or(c.MaxOpenConns, DefaultMaxOpenConnections)
@@ -152,6 +459,11 @@ func (c *DatabaseConfig) GetConnMaxLifetime() time.Duration {
 	return time.Duration(cmp.Or(c.ConnMaxLifetimeMinutes, DefaultConnMaxLifetimeMinutes)) * time.Minute
 }
 
+// GetHealthCheckConns returns the number of connections reserved for health checks.
+func (c *DatabaseConfig) GetHealthCheckConns() int {
+	return cmp.Or(c.HealthCheckConns, DefaultHealthCheckConnections)
+}
+
 // GetBloatThreshold returns the table bloat percentage that triggers maintenance recommendations.
 func (c *MaintenanceConfig) GetBloatThreshold() float64 {
 	return cmp.Or(c.BloatThreshold, DefaultBloatThreshold)
@@ -192,11 +504,37 @@ func (c *MaintenanceConfig) GetSeqScanRatioThreshold() float64 {
 	return cmp.Or(c.SeqScanRatioThreshold, DefaultSeqScanRatioThreshold)
 }
 
+// GetPoolServerSharePct returns the percentage of the server's max_connections the connection pool may claim before a warning.
+func (c *MaintenanceConfig) GetPoolServerSharePct() int {
+	return cmp.Or(c.PoolServerSharePct, DefaultPoolServerSharePct)
+}
+
+// GetPoolWaitCountThreshold returns how often requests may have waited for a pooled connection before a warning.
+func (c *MaintenanceConfig) GetPoolWaitCountThreshold() int64 {
+	return cmp.Or(c.PoolWaitCountThreshold, DefaultPoolWaitCountThreshold)
+}
+
 // GetTimeout returns the maximum duration for maintenance operations.
 func (c *MaintenanceConfig) GetTimeout() time.Duration {
 	return time.Duration(cmp.Or(c.TimeoutMinutes, DefaultMaintenanceTimeoutMinutes)) * time.Minute
 }
 
+// GetHealthCacheTTL returns how long a database health report is served from the cache, or 0 when caching is disabled.
+func (c *MaintenanceConfig) GetHealthCacheTTL() time.Duration {
+	return time.Duration(c.HealthCacheSeconds) * time.Second
+}
+
+// GetHealthMaxStale returns the age until which an expired health report may still be served while it is refreshed.
+// It is never shorter than the cache TTL, so without health_max_stale_seconds expired reports are not served.
+func (c *MaintenanceConfig) GetHealthMaxStale() time.Duration {
+	return time.Duration(max(c.HealthMaxStaleSeconds, c.HealthCacheSeconds)) * time.Second
+}
+
+// GetLockTimeout returns how long a maintenance statement waits for a table lock before it fails.
+func (c *MaintenanceConfig) GetLockTimeout() time.Duration {
+	return time.Duration(cmp.Or(c.LockTimeoutSeconds, DefaultLockTimeoutSeconds)) * time.Second
+}
+
 // GetPath returns the directory path where backup files are stored.
 func (c *BackupConfig) GetPath() string {
 	return cmp.Or(c.Path, DefaultBackupPath)
@@ -212,25 +550,150 @@ func (c *BackupConfig) GetMaxBackups() int {
 	return cmp.Or(c.MaxBackups, DefaultBackupMaxBackups)
 }
 
+// GetMaxTotalBytes returns the maximum combined size of all backup files, or 0 for no limit.
+func (c *BackupConfig) GetMaxTotalBytes() int64 {
+	return c.MaxTotalBytes
+}
+
+// GetMinBackups returns the number of most recent backups that cleanup always preserves.
+func (c *BackupConfig) GetMinBackups() int {
+	return cmp.Or(c.MinBackups, DefaultBackupMinBackups)
+}
+
 // GetDefaultCompression returns the compression level (0-9) for backups.
 func (c *BackupConfig) GetDefaultCompression() int {
 	return min(cmp.Or(c.DefaultCompression, DefaultBackupCompression), 9)
 }
 
+// GetSubdirLayout returns how backups are organized in subdirectories of the backup path.
+func (c *BackupConfig) GetSubdirLayout() string {
+	return cmp.Or(c.SubdirLayout, DefaultBackupSubdirLayout)
+}
+
 // GetTimeout returns the maximum duration for backup operations.
 func (c *BackupConfig) GetTimeout() time.Duration {
 	return time.Duration(cmp.Or(c.TimeoutMinutes, DefaultBackupTimeoutMinutes)) * time.Minute
 }
 
+// GetDownloadTimeout returns the maximum duration for sending a backup file to a client.
+func (c *BackupConfig) GetDownloadTimeout() time.Duration {
+	return time.Duration(cmp.Or(c.DownloadTimeoutMinutes, DefaultDownloadTimeoutMinutes)) * time.Minute
+}
+
+// GetOverlapPolicy returns what a scheduled backup does when another backup is still running.
+func (c *BackupConfig) GetOverlapPolicy() string {
+	return cmp.Or(c.OverlapPolicy, DefaultOverlapPolicy)
+}
+
+// GetOverlapGrace returns how long a scheduled backup waits for a running backup to finish or stop.
+func (c *BackupConfig) GetOverlapGrace() time.Duration {
+	return time.Duration(cmp.Or(c.OverlapGraceMinutes, DefaultOverlapGraceMinutes)) * time.Minute
+}
+
+// GetValidate reports whether backups are validated with pg_restore --list. Validation is on unless disabled explicitly.
+func (c *BackupConfig) GetValidate() bool {
+	return c.Validate == nil || *c.Validate
+}
+
+// GetValidateTimeout returns how long pg_restore --list may take to validate a single dump file.
+func (c *BackupConfig) GetValidateTimeout() time.Duration {
+	return time.Duration(cmp.Or(c.ValidateTimeoutSeconds, DefaultValidateTimeoutSeconds)) * time.Second
+}
+
+// GetLocation returns the timezone in which the schedule is interpreted.
+// The name is validated at load time, so an unknown timezone does not occur.
+func (c *SchedulerConfig) GetLocation() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+// GetPostCommandTimeout returns how long backup.post_command may run before it is killed.
+func (c *BackupConfig) GetPostCommandTimeout() time.Duration {
+	return time.Duration(cmp.Or(c.PostCommandTimeoutSeconds, DefaultPostCommandTimeoutSeconds)) * time.Second
+}
+
+// GetStorageBackend returns the remote storage that backups are synchronized to.
+func (c *BackupConfig) GetStorageBackend() string {
+	return cmp.Or(c.StorageBackend, DefaultStorageBackend)
+}
+
+// StorageEnabled reports whether the remote storage selected by storage_backend is enabled.
+func (c *BackupConfig) StorageEnabled() bool {
+	switch c.GetStorageBackend() {
+	case "gcs":
+		return c.GCS.Enabled
+	case "azure":
+		return c.Azure.Enabled
+	default:
+		return c.S3.Enabled
+	}
+}
+
+// StorageApplyRetention reports whether the retention rules also apply to the selected remote storage.
+func (c *BackupConfig) StorageApplyRetention() bool {
+	switch c.GetStorageBackend() {
+	case "gcs":
+		return c.GCS.ApplyRetention
+	case "azure":
+		return c.Azure.ApplyRetention
+	default:
+		return c.S3.ApplyRetention
+	}
+}
+
 // GetPathPrefix returns the S3 path prefix for constructing object keys.
 func (c *S3Config) GetPathPrefix() string {
-	prefix := c.PathPrefix
+	return pathPrefix(c.PathPrefix)
+}
+
+// GetPathPrefix returns the GCS path prefix for constructing object names.
+func (c *GCSConfig) GetPathPrefix() string {
+	return pathPrefix(c.PathPrefix)
+}
+
+// GetPathPrefix returns the Azure path prefix for constructing blob names.
+func (c *AzureConfig) GetPathPrefix() string {
+	return pathPrefix(c.PathPrefix)
+}
+
+// GetEndpoint returns the Blob Storage service URL of the storage account.
+func (c *AzureConfig) GetEndpoint() string {
+	return cmp.Or(c.Endpoint, fmt.Sprintf("https://%s.blob.core.windows.net/", c.AccountName))
+}
+
+// pathPrefix returns prefix with a trailing slash, or an empty string when no prefix is set.
+func pathPrefix(prefix string) string {
 	if prefix != "" && !strings.HasSuffix(prefix, "/") {
 		prefix += "/"
 	}
 	return prefix
 }
 
+// GetMaxConcurrentUploads returns the maximum number of backups uploaded to S3 at the same time.
+func (c *S3Config) GetMaxConcurrentUploads() int {
+	return cmp.Or(c.MaxConcurrentUploads, DefaultS3MaxConcurrentUploads)
+}
+
+// GetClassifications returns the code labels for every classification field.
+// Fields without configured labels map to an empty set.
+func (c *MetadataConfig) GetClassifications() map[string]map[int]string {
+	result := make(map[string]map[int]string, len(ClassificationFields))
+	for _, field := range ClassificationFields {
+		labels := c.Classifications[field]
+		if labels == nil {
+			labels = map[int]string{}
+		}
+		result[field] = labels
+	}
+	return result
+}
+
 // GetLevel returns the configured log level as an slog.Level.
 func (c *LogConfig) GetLevel() slog.Level {
 	switch strings.ToLower(c.Level) {
@@ -274,6 +737,10 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("config file error: %w", err)
 	}
 
+	if err := config.loadSecretFiles(); err != nil {
+		return nil, err
+	}
+
 	if envLevel := os.Getenv("LOG_LEVEL"); envLevel != "" {
 		config.Log.Level = envLevel
 	}
@@ -285,6 +752,51 @@ func Load(configPath string) (*Config, error) {
 	return config, nil
 }
 
+// loadSecretFiles replaces secrets with the contents of their configured secret files.
+func (c *Config) loadSecretFiles() error {
+	if c.Database.PasswordFile != "" {
+		password, err := readSecretFile(c.Database.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("database.password_file: %w", err)
+		}
+		c.Database.Password = password
+	}
+
+	if c.Backup.S3.SecretAccessKeyFile != "" {
+		key, err := readSecretFile(c.Backup.S3.SecretAccessKeyFile)
+		if err != nil {
+			return fmt.Errorf("backup.s3.secret_access_key_file: %w", err)
+		}
+		c.Backup.S3.SecretAccessKey = key
+	}
+
+	if c.Backup.Azure.AccountKeyFile != "" {
+		key, err := readSecretFile(c.Backup.Azure.AccountKeyFile)
+		if err != nil {
+			return fmt.Errorf("backup.azure.account_key_file: %w", err)
+		}
+		c.Backup.Azure.AccountKey = key
+	}
+
+	return nil
+}
+
+// readSecretFile returns the contents of a secret file with surrounding whitespace removed.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return secret, nil
+}
+
+// rgbHexPattern matches colors written as #RGB or #RRGGBB.
+var rgbHexPattern = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
 // configValidator is the singleton validator instance with custom validations.
 var configValidator = newConfigValidator()
 
@@ -294,20 +806,63 @@ func newConfigValidator() *validator.Validate {
 	_ = v.RegisterValidation("identifier", func(fl validator.FieldLevel) bool {
 		return types.IsValidIdentifier(fl.Field().String())
 	})
+	_ = v.RegisterValidation("rgbhex", func(fl validator.FieldLevel) bool {
+		return rgbHexPattern.MatchString(fl.Field().String())
+	})
 
-	v.RegisterStructValidation(validateS3Config, S3Config{})
+	v.RegisterStructValidation(validateBackupConfig, BackupConfig{})
+	v.RegisterStructValidation(validateImageConfig, ImageConfig{})
 
 	return v
 }
 
-// validateS3Config checks that S3 has either a region or custom endpoint when enabled.
-func validateS3Config(sl validator.StructLevel) {
-	s3 := sl.Current().Interface().(S3Config)
-	if !s3.Enabled {
+// validateBackupConfig checks that only the remote storage selected by storage_backend is enabled,
+// and that it has the credentials it needs.
+func validateBackupConfig(sl validator.StructLevel) {
+	backup := sl.Current().Interface().(BackupConfig)
+	backend := backup.GetStorageBackend()
+
+	for _, storage := range []struct {
+		name, field string
+		enabled     bool
+	}{
+		{"s3", "S3.Enabled", backup.S3.Enabled},
+		{"gcs", "GCS.Enabled", backup.GCS.Enabled},
+		{"azure", "Azure.Enabled", backup.Azure.Enabled},
+	} {
+		if storage.enabled && storage.name != backend {
+			sl.ReportError(storage.enabled, storage.field, "Enabled", "storage_backend", backend)
+		}
+	}
+	if !backup.StorageEnabled() {
 		return
 	}
-	if s3.Region == "" && s3.Endpoint == "" {
-		sl.ReportError(s3.Region, "region", "Region", "required_without_endpoint", "")
+
+	switch backend {
+	case "gcs":
+		if backup.GCS.CredentialsFile == "" {
+			sl.ReportError(backup.GCS.CredentialsFile, "GCS.CredentialsFile", "CredentialsFile", "required_if", "Enabled true")
+		}
+	case "azure":
+		if backup.Azure.AccountKey == "" && backup.Azure.AccountKeyFile == "" {
+			sl.ReportError(backup.Azure.AccountKey, "Azure.AccountKey", "AccountKey", "required_without", "AccountKeyFile")
+		}
+	default:
+		if backup.S3.SecretAccessKey == "" && backup.S3.SecretAccessKeyFile == "" {
+			sl.ReportError(backup.S3.SecretAccessKey, "S3.SecretAccessKey", "SecretAccessKey", "required_without", "SecretAccessKeyFile")
+		}
+		if backup.S3.Region == "" && backup.S3.Endpoint == "" {
+			sl.ReportError(backup.S3.Region, "S3.Region", "Region", "required_without_endpoint", "")
+		}
+	}
+}
+
+// validateImageConfig checks that the medium image variant is not smaller than the thumb variant.
+// Both sizes are compared after applying their defaults.
+func validateImageConfig(sl validator.StructLevel) {
+	image := sl.Current().Interface().(ImageConfig)
+	if image.GetMediumSize() < image.GetThumbSize() {
+		sl.ReportError(image.MediumSize, "MediumSize", "MediumSize", "gte_thumb_size", strconv.Itoa(image.GetThumbSize()))
 	}
 }
 
@@ -342,8 +897,14 @@ func tagMessage(tag, param string) string {
 		return "is required"
 	case "required_if":
 		return "is required when enabled"
+	case "required_without":
+		return "is required when no secret file is specified"
 	case "required_without_endpoint":
 		return "is required when no endpoint is specified"
+	case "gte_thumb_size":
+		return fmt.Sprintf("must be at least image.thumb_size (%s)", param)
+	case "storage_backend":
+		return fmt.Sprintf("is set but backup.storage_backend is %s", param)
 	case "gt":
 		return fmt.Sprintf("must be greater than %s", param)
 	case "gte":
@@ -356,6 +917,10 @@ func tagMessage(tag, param string) string {
 		return fmt.Sprintf("must be one of [%s]", param)
 	case "identifier":
 		return "contains invalid characters (only letters, numbers and underscores allowed)"
+	case "rgbhex":
+		return "must be a hex color like #FFFFFF"
+	case "timezone":
+		return "must be an IANA timezone name like Europe/Amsterdam"
 	default:
 		return fmt.Sprintf("is invalid (%s)", tag)
 	}
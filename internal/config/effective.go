@@ -0,0 +1,36 @@
+// Package config provides application configuration management.
+package config
+
+import "slices"
+
+// redacted replaces secret values in the effective configuration.
+const redacted = "***"
+
+// Effective returns a copy of the configuration with unset optional settings replaced by
+// their default values and secrets replaced by "***". The receiver is not modified.
+// Secrets that are not set stay empty, so the copy still shows which ones are configured.
+func (c *Config) Effective() *Config {
+	effective := *c
+	effective.API.Keys = slices.Clone(c.API.Keys)
+	effective.API.WriteKeys = slices.Clone(c.API.WriteKeys)
+	effective.API.AdminKeys = slices.Clone(c.API.AdminKeys)
+	effective.Backup.ExcludeTables = slices.Clone(c.Backup.ExcludeTables)
+	effective.applyDefaults()
+
+	redact(&effective.Database.Password)
+	redact(&effective.Backup.S3.SecretAccessKey)
+	redact(&effective.Backup.Azure.AccountKey)
+	for _, keys := range [][]string{effective.API.Keys, effective.API.WriteKeys, effective.API.AdminKeys} {
+		for i := range keys {
+			redact(&keys[i])
+		}
+	}
+	return &effective
+}
+
+// redact replaces a non-empty secret with a placeholder.
+func redact(secret *string) {
+	if *secret != "" {
+		*secret = redacted
+	}
+}
@@ -0,0 +1,132 @@
+// Package config provides application configuration management.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MigrateYAML converts a legacy YAML configuration into the JSON format read by Load.
+// The YAML keys map one-to-one onto the JSON keys (e.g. database.max_open_conns, backup.s3.path_prefix).
+// Unknown keys are rejected so that no setting is silently dropped, and optional settings
+// that were left unset are written out with their default values.
+func MigrateYAML(data []byte) ([]byte, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("YAML config error: %w", err)
+	}
+
+	intermediate, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("YAML config conversion failed: %w", err)
+	}
+
+	config := &Config{}
+	decoder := json.NewDecoder(bytes.NewReader(intermediate))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("YAML config error: %w", err)
+	}
+
+	config.applyDefaults()
+
+	if err := validate(config); err != nil {
+		return nil, fmt.Errorf("configuration invalid: %w", err)
+	}
+
+	out, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("JSON config encoding failed: %w", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// applyDefaults replaces unset optional settings with their effective default values.
+func (c *Config) applyDefaults() {
+	c.Database.MaxOpenConns = c.Database.GetMaxOpenConns()
+	c.Database.MaxIdleConns = c.Database.GetMaxIdleConns()
+	c.Database.ConnMaxLifetimeMinutes = int(c.Database.GetConnMaxLifetime().Minutes())
+	c.Database.HealthCheckConns = c.Database.GetHealthCheckConns()
+
+	c.Image.MaxImageDownloadSizeBytes = c.Image.GetMaxDownloadBytes()
+	if c.Image.MaxImageDownloadRedirects == nil {
+		redirects := DefaultMaxImageDownloadRedirects
+		c.Image.MaxImageDownloadRedirects = &redirects
+	}
+	c.Image.MinQuality = c.Image.GetMinQuality()
+	c.Image.MaxQuality = c.Image.GetMaxQuality()
+	c.Image.NotSmallerPolicy = c.Image.GetNotSmallerPolicy()
+	c.Image.OutputFormat = c.Image.GetOutputFormat()
+	c.Image.BackgroundColor = c.Image.GetBackgroundColor()
+	c.Image.ThumbSize = c.Image.GetThumbSize()
+	c.Image.MediumSize = c.Image.GetMediumSize()
+	c.Image.ThumbnailSizes = c.Image.GetThumbnailSizes()
+	c.Image.ThumbnailCacheEntries = c.Image.GetThumbnailCacheEntries()
+	c.Image.MaxImagePixels = c.Image.GetMaxPixels()
+	c.Image.MaxWidth = c.Image.GetMaxWidth()
+	c.Image.MaxHeight = c.Image.GetMaxHeight()
+	c.Image.JobWorkers = c.Image.GetJobWorkers()
+	c.Image.JobRetentionMinutes = int(c.Image.GetJobRetention().Minutes())
+	c.Image.ReoptimizeWorkers = c.Image.GetReoptimizeWorkers()
+	c.Image.StatsRefreshMinutes = int(c.Image.GetStatsRefreshInterval().Minutes())
+
+	c.API.RequestTimeoutSeconds = int(c.API.GetRequestTimeout().Seconds())
+	c.API.IdempotencyTTLMinutes = int(c.API.GetIdempotencyTTL().Minutes())
+	if c.API.Keys == nil {
+		c.API.Keys = []string{}
+	}
+	if c.API.WriteKeys == nil {
+		c.API.WriteKeys = []string{}
+	}
+	c.API.MaxBatchSize = c.API.GetMaxBatchSize()
+	c.API.RateLimit.Burst = c.API.RateLimit.GetBurst()
+	if c.API.CORS.AllowedOrigins == nil {
+		c.API.CORS.AllowedOrigins = []string{}
+	}
+	c.API.CORS.AllowedMethods = slices.Clone(c.API.CORS.GetAllowedMethods())
+	if c.API.AdminKeys == nil {
+		c.API.AdminKeys = []string{}
+	}
+
+	c.Maintenance.BloatThreshold = c.Maintenance.GetBloatThreshold()
+	c.Maintenance.DeadTupleThreshold = c.Maintenance.GetDeadTupleThreshold()
+	c.Maintenance.VacuumStalenessDays = c.Maintenance.GetVacuumStalenessDays()
+	c.Maintenance.MinRowsForRecommendation = c.Maintenance.GetMinRowsForRecommendation()
+	c.Maintenance.ToastSizeWarningBytes = c.Maintenance.GetToastSizeWarningBytes()
+	c.Maintenance.StaleStatsThresholdPct = c.Maintenance.GetStaleStatsThreshold()
+	c.Maintenance.SeqScanRatioThreshold = c.Maintenance.GetSeqScanRatioThreshold()
+	c.Maintenance.PoolServerSharePct = c.Maintenance.GetPoolServerSharePct()
+	c.Maintenance.PoolWaitCountThreshold = c.Maintenance.GetPoolWaitCountThreshold()
+	c.Maintenance.TimeoutMinutes = int(c.Maintenance.GetTimeout().Minutes())
+	c.Maintenance.LockTimeoutSeconds = int(c.Maintenance.GetLockTimeout().Seconds())
+
+	c.Backup.Path = c.Backup.GetPath()
+	c.Backup.SubdirLayout = c.Backup.GetSubdirLayout()
+	c.Backup.RetentionDays = c.Backup.GetRetentionDays()
+	c.Backup.MaxBackups = c.Backup.GetMaxBackups()
+	c.Backup.MinBackups = c.Backup.GetMinBackups()
+	c.Backup.DefaultCompression = c.Backup.GetDefaultCompression()
+	c.Backup.TimeoutMinutes = int(c.Backup.GetTimeout().Minutes())
+	c.Backup.DownloadTimeoutMinutes = int(c.Backup.GetDownloadTimeout().Minutes())
+	if c.Backup.Validate == nil {
+		validate := true
+		c.Backup.Validate = &validate
+	}
+	c.Backup.ValidateTimeoutSeconds = int(c.Backup.GetValidateTimeout().Seconds())
+	c.Backup.PostCommandTimeoutSeconds = int(c.Backup.GetPostCommandTimeout().Seconds())
+	c.Backup.OverlapPolicy = c.Backup.GetOverlapPolicy()
+	c.Backup.OverlapGraceMinutes = int(c.Backup.GetOverlapGrace().Minutes())
+	c.Backup.StorageBackend = c.Backup.GetStorageBackend()
+	c.Backup.S3.MaxConcurrentUploads = c.Backup.S3.GetMaxConcurrentUploads()
+	if c.Backup.ExcludeTables == nil {
+		c.Backup.ExcludeTables = []string{}
+	}
+
+	c.Log.Level = strings.ToLower(c.Log.GetLevel().String())
+	c.Log.Format = c.Log.GetFormat()
+}
@@ -4,6 +4,8 @@ package database
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
 
@@ -20,9 +22,9 @@ type DB interface {
 
 // Artist is a basic artist entity with ID, name, and image status.
 type Artist struct {
-	ID         string `db:"artistid"`
-	ArtistName string `db:"artist"`
-	HasImage   bool   `db:"has_image"`
+	ID         string `db:"artistid" json:"artistid"`
+	ArtistName string `db:"artist" json:"artist"`
+	HasImage   bool   `db:"has_image" json:"has_image"`
 }
 
 // ArtistDetails contains complete artist information including social media and metadata.
@@ -39,12 +41,28 @@ type ArtistDetails struct {
 
 // Track is a basic track entity with ID, title, artist, and image status.
 type Track struct {
-	ID         string `db:"titleid"`
-	TrackTitle string `db:"tracktitle"`
-	Artist     string `db:"artist"`
-	HasImage   bool   `db:"has_image"`
+	ID         string `db:"titleid" json:"titleid"`
+	TrackTitle string `db:"tracktitle" json:"tracktitle"`
+	Artist     string `db:"artist" json:"artist"`
+	HasImage   bool   `db:"has_image" json:"has_image"`
 }
 
+// RecentTrack is a track together with the time it was added to Aeron.
+type RecentTrack struct {
+	Track
+	AddedAt string `db:"added_at" json:"added_at"` // YYYY-MM-DDTHH:MM:SS, without time zone like all Aeron timestamps
+}
+
+// ImageSizeBucket reports how many stored images fall within a size range.
+type ImageSizeBucket struct {
+	Label      string `db:"bucket" json:"bucket"`
+	Count      int    `db:"count" json:"count"`
+	TotalBytes int64  `db:"total_bytes" json:"total_bytes"`
+}
+
+// ExportTypeExcluded is the track export type for tracks that are excluded from operations.
+const ExportTypeExcluded = 2
+
 // TrackDetails contains complete track information including timing and audio properties.
 type TrackDetails struct {
 	ID            string `db:"titleid" json:"titleid"`
@@ -82,8 +100,8 @@ const artistDetailsQuery = `
 	FROM %s.artist
 	WHERE artistid = $1`
 
-const trackDetailsQuery = `
-	SELECT
+// trackDetailsColumns lists the track columns that exist in every supported Aeron schema.
+const trackDetailsColumns = `
 		titleid,
 		COALESCE(tracktitle, '') as tracktitle,
 		COALESCE(artist, '') as artist,
@@ -93,19 +111,61 @@ const trackDetailsQuery = `
 		COALESCE(introtime, 0) as introtime,
 		COALESCE(outrotime, 0) as outrotime,
 		COALESCE(tempo, 0) as tempo,
-		COALESCE(bpm, 0) as bpm,
 		COALESCE(gender, 0) as gender,
 		COALESCE("Language", 0) as language,
 		COALESCE(mood, 0) as mood,
 		COALESCE(exporttype, 0) as exporttype,
 		COALESCE(repeatvalue, 0) as repeat_value,
-		COALESCE(rating, 0) as rating,
-		CASE WHEN picture IS NOT NULL THEN true ELSE false END as has_image,
-		COALESCE(website, '') as website,
-		COALESCE(conductor, '') as conductor,
-		COALESCE(orchestra, '') as orchestra
-	FROM %s.track
-	WHERE titleid = $1`
+		CASE WHEN picture IS NOT NULL THEN true ELSE false END as has_image`
+
+// optionalTrackColumn describes a track column that is absent in older Aeron schemas.
+type optionalTrackColumn struct {
+	column   string
+	fallback string
+}
+
+// optionalTrackColumns lists track columns that are replaced by their fallback value when missing.
+var optionalTrackColumns = []optionalTrackColumn{
+	{"bpm", "0"},
+	{"rating", "0"},
+	{"website", "''"},
+	{"conductor", "''"},
+	{"orchestra", "''"},
+}
+
+// trackAddedColumns lists track columns that may record when a track was added or last changed, in order of preference.
+// Aeron schemas do not consistently have one; the first that exists with a timestamp type is used.
+var trackAddedColumns = []string{"createdate", "created", "dateadded", "datecreated", "modifydate", "modified", "lastmodified"}
+
+// IsOptionalColumn reports whether a column may be absent without breaking queries.
+func IsOptionalColumn(table, column string) bool {
+	if table != string(types.TableTrack) {
+		return false
+	}
+	for _, col := range optionalTrackColumns {
+		if col.column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// buildTrackDetailsQuery returns the track details SELECT for a schema, without a WHERE clause.
+// Optional columns for which available reports false are selected as their fallback value.
+func buildTrackDetailsQuery(schema string, available func(column string) bool) string {
+	var b strings.Builder
+	b.WriteString("\n\tSELECT")
+	b.WriteString(trackDetailsColumns)
+	for _, col := range optionalTrackColumns {
+		if available(col.column) {
+			fmt.Fprintf(&b, ",\n\t\tCOALESCE(%s, %s) as %s", col.column, col.fallback, col.column)
+		} else {
+			fmt.Fprintf(&b, ",\n\t\t%s as %s", col.fallback, col.column)
+		}
+	}
+	fmt.Fprintf(&b, "\n\tFROM %s.track", schema)
+	return b.String()
+}
 
 func getEntityByID[T any](ctx context.Context, db DB, query, id, label, operation string) (*T, error) {
 	var entity T
@@ -5,15 +5,17 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
 )
 
 // playlistItemColumns defines the fields returned for each playlist item.
 const playlistItemColumns = `
+	pi.id as itemid,
 	pi.titleid as trackid,
 	COALESCE(t.tracktitle, '') as tracktitle,
-	COALESCE(t.artistid, '00000000-0000-0000-0000-000000000000') as artistid,
+	COALESCE(t.artistid, '%[2]s') as artistid,
 	COALESCE(t.artist, '') as artistname,
 	TO_CHAR(pi.startdatetime, 'HH24:MI:SS') as start_time,
 	TO_CHAR(pi.startdatetime + INTERVAL '1 millisecond' * COALESCE(t.knownlength, 0), 'HH24:MI:SS') as end_time,
@@ -22,7 +24,7 @@ const playlistItemColumns = `
 	CASE WHEN a.picture IS NOT NULL THEN true ELSE false END as has_artist_image,
 	COALESCE(t.exporttype, 0) as exporttype,
 	COALESCE(pi.mode, 0) as mode,
-	CASE WHEN t.userid = '%s' THEN true ELSE false END as is_voicetrack,
+	CASE WHEN t.userid = '%[1]s' THEN true ELSE false END as is_voicetrack,
 	CASE WHEN COALESCE(pi.commblock, 0) > 0 THEN true ELSE false END as is_commblock`
 
 // playlistItemJoins defines the table relationships for playlist item queries.
@@ -42,6 +44,7 @@ type PlaylistBlock struct {
 
 // PlaylistItem represents a single scheduled track or media item.
 type PlaylistItem struct {
+	ItemID         int64  `db:"itemid" json:"itemid"`
 	TrackID        string `db:"trackid" json:"trackid"`
 	TrackTitle     string `db:"tracktitle" json:"tracktitle"`
 	ArtistID       string `db:"artistid" json:"artistid"`
@@ -53,23 +56,75 @@ type PlaylistItem struct {
 	HasArtistImage bool   `db:"has_artist_image" json:"has_artist_image"`
 	ExportType     int    `db:"exporttype" json:"exporttype"`
 	Mode           int    `db:"mode" json:"mode"`
+	ModeLabel      string `db:"-" json:"mode_label,omitempty"`
 	IsVoicetrack   bool   `db:"is_voicetrack" json:"is_voicetrack"`
 	IsCommblock    bool   `db:"is_commblock" json:"is_commblock"`
 }
 
+// PlaylistItemSchedule is the scheduled start of a playlist item together with the bounds of its block.
+type PlaylistItemSchedule struct {
+	ItemID     int64     `db:"itemid"`
+	BlockID    string    `db:"blockid"`
+	Start      time.Time `db:"startdatetime"`
+	BlockStart time.Time `db:"block_start"`
+	BlockEnd   time.Time `db:"block_end"`
+}
+
+// PlaylistStats contains aggregate numbers for the playlist items of one day.
+// Music items are items with a track that are neither voice tracks nor part of a commercial block.
+type PlaylistStats struct {
+	TotalItems       int   `db:"total_items" json:"total_items"`
+	MusicItems       int   `db:"music_items" json:"music_items"`
+	MusicDurationMs  int64 `db:"music_duration" json:"music_duration"`
+	Voicetracks      int   `db:"voicetracks" json:"voicetracks"`
+	Commercials      int   `db:"commercials" json:"commercials"`
+	MusicTrackImage  int   `db:"music_track_image" json:"music_with_track_image"`
+	MusicArtistImage int   `db:"music_artist_image" json:"music_with_artist_image"`
+}
+
+// playlistStatsColumns defines the aggregates returned by playlist statistics queries.
+const playlistStatsColumns = `
+	COUNT(*) as total_items,
+	COUNT(*) FILTER (WHERE %[1]s) as music_items,
+	COALESCE(SUM(COALESCE(t.knownlength, 0)) FILTER (WHERE %[1]s), 0) as music_duration,
+	COUNT(*) FILTER (WHERE t.userid = '%[2]s') as voicetracks,
+	COUNT(*) FILTER (WHERE COALESCE(pi.commblock, 0) > 0) as commercials,
+	COUNT(*) FILTER (WHERE %[1]s AND t.picture IS NOT NULL) as music_track_image,
+	COUNT(*) FILTER (WHERE %[1]s AND a.picture IS NOT NULL) as music_artist_image`
+
+// playlistMusicCondition matches playlist items that are regular music.
+const playlistMusicCondition = `t.titleid IS NOT NULL AND t.userid IS DISTINCT FROM '%s' AND COALESCE(pi.commblock, 0) = 0`
+
 // PlaylistOptions contains filter, sort, and pagination parameters for playlist queries.
 type PlaylistOptions struct {
 	BlockID     string
 	Date        string
 	ExportTypes []int
+	Modes       []int
+	HasArtist   *bool
 	Limit       int
 	Offset      int
 	SortBy      string
 	SortDesc    bool
+	Sort        []PlaylistSort // takes precedence over SortBy and SortDesc when not empty
 	TrackImage  *bool
 	ArtistImage *bool
 }
 
+// PlaylistSort is one column of a multi-column playlist sort.
+type PlaylistSort struct {
+	Field string
+	Desc  bool
+}
+
+// playlistSortColumns maps the sortable fields to their SQL expressions.
+var playlistSortColumns = map[string]string{
+	"artist":     "t.artist",
+	"track":      "t.tracktitle",
+	"start_time": "pi.startdatetime",
+	"duration":   "COALESCE(t.knownlength, 0)",
+}
+
 // BuildPlaylistQuery generates a parameterized SQL query from playlist filter options.
 func BuildPlaylistQuery(schema string, opts *PlaylistOptions) (query string, params []any, err error) {
 	var conditions []string
@@ -87,13 +142,29 @@ func BuildPlaylistQuery(schema string, opts *PlaylistOptions) (query string, par
 		return "", []any{}, nil
 	}
 
+	// Block IDs can repeat across days, so a date narrows the block to that day's items.
+	if opts.Date != "" {
+		param := nextParam()
+		conditions = append(conditions, fmt.Sprintf("pi.startdatetime >= %[1]s::date AND pi.startdatetime < %[1]s::date + INTERVAL '1 day'", param))
+		params = append(params, opts.Date)
+	}
+
 	if len(opts.ExportTypes) > 0 {
-		placeholders := make([]string, len(opts.ExportTypes))
-		for i, t := range opts.ExportTypes {
-			placeholders[i] = nextParam()
+		conditions = append(conditions, exportTypeCondition(opts.ExportTypes, nextParam))
+		for _, t := range opts.ExportTypes {
 			params = append(params, t)
 		}
-		conditions = append(conditions, fmt.Sprintf("COALESCE(t.exporttype, 1) NOT IN (%s)", strings.Join(placeholders, ",")))
+	}
+
+	if len(opts.Modes) > 0 {
+		conditions = append(conditions, modeCondition(opts.Modes, nextParam))
+		for _, mode := range opts.Modes {
+			params = append(params, mode)
+		}
+	}
+
+	if opts.HasArtist != nil {
+		conditions = append(conditions, artistCondition(*opts.HasArtist))
 	}
 
 	if opts.TrackImage != nil {
@@ -114,24 +185,16 @@ func BuildPlaylistQuery(schema string, opts *PlaylistOptions) (query string, par
 
 	whereClause := strings.Join(conditions, " AND ")
 
-	orderBy := "pi.startdatetime"
-	switch opts.SortBy {
-	case "artist":
-		orderBy = "t.artist"
-	case "track":
-		orderBy = "t.tracktitle"
-	case "start_time":
-		orderBy = "pi.startdatetime"
-	}
-	if opts.SortDesc {
-		orderBy += " DESC"
+	orderBy, err := buildPlaylistOrderBy(opts)
+	if err != nil {
+		return "", nil, err
 	}
 
 	if !types.IsValidIdentifier(schema) {
 		return "", nil, types.NewValidationError("schema", fmt.Sprintf("invalid schema name: %s", schema))
 	}
 
-	columns := fmt.Sprintf(playlistItemColumns, types.VoicetrackUserID)
+	columns := fmt.Sprintf(playlistItemColumns, types.VoicetrackUserID, types.NoArtistID)
 	joins := fmt.Sprintf(playlistItemJoins, schema, schema, schema)
 	query = fmt.Sprintf("SELECT %s %s WHERE %s ORDER BY %s", columns, joins, whereClause, orderBy)
 
@@ -147,6 +210,63 @@ func BuildPlaylistQuery(schema string, opts *PlaylistOptions) (query string, par
 	return query, params, nil
 }
 
+// buildPlaylistOrderBy returns the ORDER BY expression for the sort options.
+// Unknown fields in Sort are rejected; an unknown SortBy falls back to the start time.
+func buildPlaylistOrderBy(opts *PlaylistOptions) (string, error) {
+	if len(opts.Sort) == 0 {
+		orderBy, ok := playlistSortColumns[opts.SortBy]
+		if !ok {
+			orderBy = "pi.startdatetime"
+		}
+		if opts.SortDesc {
+			orderBy += " DESC"
+		}
+		return orderBy, nil
+	}
+
+	columns := make([]string, len(opts.Sort))
+	for i, sort := range opts.Sort {
+		column, ok := playlistSortColumns[sort.Field]
+		if !ok {
+			return "", types.NewValidationError("sort", fmt.Sprintf("invalid sort field: %s", sort.Field))
+		}
+		if sort.Desc {
+			column += " DESC"
+		}
+		columns[i] = column
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+// exportTypeCondition returns a condition that excludes playlist items with the given track export types.
+// The caller must append the export types to the query parameters in the same order.
+func exportTypeCondition(exportTypes []int, nextParam func() string) string {
+	placeholders := make([]string, len(exportTypes))
+	for i := range exportTypes {
+		placeholders[i] = nextParam()
+	}
+	return fmt.Sprintf("COALESCE(t.exporttype, 1) NOT IN (%s)", strings.Join(placeholders, ","))
+}
+
+// modeCondition returns a condition that limits playlist items to the given modes.
+// The caller must append the modes to the query parameters in the same order.
+func modeCondition(modes []int, nextParam func() string) string {
+	placeholders := make([]string, len(modes))
+	for i := range modes {
+		placeholders[i] = nextParam()
+	}
+	return fmt.Sprintf("COALESCE(pi.mode, 0) IN (%s)", strings.Join(placeholders, ","))
+}
+
+// artistCondition returns a condition that matches playlist items with or without a real artist.
+// Tracks without an artist have no artistid or the all-zero placeholder.
+func artistCondition(hasArtist bool) string {
+	if hasArtist {
+		return fmt.Sprintf("COALESCE(t.artistid, '%[1]s') <> '%[1]s'", types.NoArtistID)
+	}
+	return fmt.Sprintf("COALESCE(t.artistid, '%[1]s') = '%[1]s'", types.NoArtistID)
+}
+
 // ExecutePlaylistQuery executes a playlist query and maps results to PlaylistItem structs.
 func ExecutePlaylistQuery(ctx context.Context, db DB, query string, params []any) ([]PlaylistItem, error) {
 	var items []PlaylistItem
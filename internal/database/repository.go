@@ -2,25 +2,103 @@
 package database
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
 )
 
 // Repository provides data access methods for the Aeron database.
 type Repository struct {
-	db     *sqlx.DB
-	schema string
+	db       *sqlx.DB
+	healthDB *sqlx.DB // dedicated pool for health checks
+	schema   string
+
+	trackDetailsQuery   string
+	missingTrackColumns map[string]bool // optional track columns absent in this schema
+	trackAddedColumn    string          // empty when the track table has no usable timestamp column
 }
 
 // NewRepository returns a Repository for accessing the specified schema.
-func NewRepository(db *sqlx.DB, schema string) *Repository {
-	return &Repository{db: db, schema: schema}
+// Health checks use healthDB so they do not queue behind application queries; if nil, db is used.
+// All optional columns are assumed to exist until DetectOptionalColumns is called.
+func NewRepository(db, healthDB *sqlx.DB, schema string) *Repository {
+	return &Repository{
+		db:       db,
+		healthDB: cmp.Or(healthDB, db),
+		schema:   schema,
+		trackDetailsQuery: buildTrackDetailsQuery(schema, func(string) bool {
+			return true
+		}),
+	}
+}
+
+// DetectOptionalColumns checks which optional track columns exist and adapts queries accordingly.
+// If no columns are visible at all, for example without privileges on the schema, the columns are
+// treated as unknown and the queries keep using all of them.
+// It must be called before the repository is used concurrently.
+func (r *Repository) DetectOptionalColumns(ctx context.Context) error {
+	columns, err := r.GetColumns(ctx, []string{string(types.TableTrack)})
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		slog.Warn("No columns found for the track table, assuming all optional columns exist", "schema", r.schema)
+		return nil
+	}
+
+	existing := make(map[string]bool, len(columns))
+	timestamps := make(map[string]bool)
+	for _, col := range columns {
+		existing[col.Column] = true
+		if ColumnTypeTimestamp.Matches(col.DataType) {
+			timestamps[col.Column] = true
+		}
+	}
+
+	missing := make(map[string]bool)
+	for _, col := range optionalTrackColumns {
+		if !existing[col.column] {
+			missing[col.column] = true
+			slog.Warn("Optional track column missing, using default value", "column", col.column, "default", col.fallback)
+		}
+	}
+	r.missingTrackColumns = missing
+
+	r.trackDetailsQuery = buildTrackDetailsQuery(r.schema, func(column string) bool {
+		return existing[column]
+	})
+
+	r.trackAddedColumn = ""
+	for _, column := range trackAddedColumns {
+		if timestamps[column] {
+			r.trackAddedColumn = column
+			break
+		}
+	}
+	if r.trackAddedColumn == "" {
+		slog.Info("Track table has no timestamp column, listing recently added tracks is unavailable")
+	} else {
+		slog.Debug("Using track timestamp column for recently added tracks", "column", r.trackAddedColumn)
+	}
+	return nil
+}
+
+// TrackAddedColumn returns the track column that records when a track was added, or an empty string if there is none.
+func (r *Repository) TrackAddedColumn() string {
+	return r.trackAddedColumn
 }
 
 // DB returns the underlying database connection.
@@ -33,9 +111,56 @@ func (r *Repository) Schema() string {
 	return r.schema
 }
 
-// Ping verifies the database connection is alive.
+// PoolStats returns the statistics of the application's connection pool.
+func (r *Repository) PoolStats() sql.DBStats {
+	return r.db.Stats()
+}
+
+// GetMaxConnections returns the server's max_connections setting.
+func (r *Repository) GetMaxConnections(ctx context.Context) (int, error) {
+	var value string
+	if err := r.db.GetContext(ctx, &value, "SHOW max_connections"); err != nil {
+		return 0, types.NewOperationError("get max_connections", err)
+	}
+	maxConns, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, types.NewOperationError("parse max_connections", err)
+	}
+	return maxConns, nil
+}
+
+// Ping verifies the database connection is alive using the health check pool.
 func (r *Repository) Ping(ctx context.Context) error {
-	return r.db.PingContext(ctx)
+	return r.healthDB.PingContext(ctx)
+}
+
+// ExecWithLockTimeout runs a statement on a dedicated connection with lock_timeout set, so it fails with
+// a lock timeout error instead of waiting for locks held by other sessions. Unlike SET LOCAL this also
+// works for statements that cannot run in a transaction, such as VACUUM.
+func (r *Repository) ExecWithLockTimeout(ctx context.Context, query string, timeout time.Duration) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = %d", timeout.Milliseconds())); err != nil {
+		return err
+	}
+	_, execErr := conn.ExecContext(ctx, query)
+
+	// The connection returns to the pool afterwards; discard it if the setting cannot be reset.
+	if _, err := conn.ExecContext(context.WithoutCancel(ctx), "RESET lock_timeout"); err != nil {
+		slog.Warn("Failed to reset lock_timeout, discarding connection", "error", err)
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	return execErr
+}
+
+// IsLockTimeout reports whether err is PostgreSQL's lock_not_available error, raised when lock_timeout expires.
+func IsLockTimeout(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "55P03"
 }
 
 // --- Artist operations ---
@@ -47,15 +172,261 @@ func (r *Repository) GetArtist(ctx context.Context, id string) (*ArtistDetails,
 	return getEntityByID[ArtistDetails](ctx, r.db, query, id, "artist", "fetch artist")
 }
 
+// UpdateEntityColumns sets the given columns of an artist or track. Column names must come from
+// code, never from user input; they are quoted, so case-sensitive columns such as "Year" are
+// written as named. Values are passed as query parameters. It reports whether the entity exists.
+func (r *Repository) UpdateEntityColumns(ctx context.Context, table types.Table, id string, values map[string]any) (bool, error) {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, table)
+	if err != nil {
+		return false, types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+	label := string(table)
+
+	columns := slices.Sorted(maps.Keys(values))
+	assignments := make([]string, len(columns))
+	params := make([]any, 0, len(columns)+1)
+	for i, column := range columns {
+		if table == types.TableTrack && r.missingTrackColumns[column] {
+			return false, types.NewValidationError(column, fmt.Sprintf("column %s does not exist in this Aeron database", column))
+		}
+		params = append(params, values[column])
+		assignments[i] = fmt.Sprintf("%s = $%d", pq.QuoteIdentifier(column), len(params))
+	}
+	params = append(params, id)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = $%d",
+		qualifiedTableName, strings.Join(assignments, ", "), pq.QuoteIdentifier(types.IDColumnForTable(table)), len(params))
+
+	result, err := r.db.ExecContext(ctx, query, params...)
+	if err != nil {
+		return false, types.NewOperationError(fmt.Sprintf("update %s", label), err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, types.NewOperationError(fmt.Sprintf("update %s", label), err)
+	}
+	return rowsAffected > 0, nil
+}
+
 // --- Track operations ---
 
 // GetTrack retrieves complete track details by UUID.
 func (r *Repository) GetTrack(ctx context.Context, id string) (*TrackDetails, error) {
 	slog.Debug("Entity lookup", "type", "track", "id", id)
-	query := fmt.Sprintf(trackDetailsQuery, r.schema)
+	query := r.trackDetailsQuery + "\n\tWHERE titleid = $1"
 	return getEntityByID[TrackDetails](ctx, r.db, query, id, "track", "fetch track")
 }
 
+// GetTracks retrieves track details for multiple UUIDs in a single query.
+// IDs that do not exist are omitted from the result.
+func (r *Repository) GetTracks(ctx context.Context, ids []string) ([]TrackDetails, error) {
+	slog.Debug("Entity batch lookup", "type", "track", "count", len(ids))
+	query := r.trackDetailsQuery + "\n\tWHERE titleid = ANY($1::uuid[])"
+
+	var tracks []TrackDetails
+	if err := r.db.SelectContext(ctx, &tracks, query, pq.Array(ids)); err != nil {
+		return nil, types.NewOperationError("fetch tracks", err)
+	}
+	return tracks, nil
+}
+
+// GetTracksByArtist retrieves a page of tracks linked to an artist, ordered by title,
+// together with the total number of tracks for that artist.
+func (r *Repository) GetTracksByArtist(ctx context.Context, artistID string, limit, offset int) ([]Track, int, error) {
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s.track WHERE artistid = $1", r.schema)
+	if err := r.db.GetContext(ctx, &total, countQuery, artistID); err != nil {
+		return nil, 0, types.NewOperationError("count artist tracks", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			titleid,
+			COALESCE(tracktitle, '') as tracktitle,
+			COALESCE(artist, '') as artist,
+			CASE WHEN picture IS NOT NULL THEN true ELSE false END as has_image
+		FROM %s.track
+		WHERE artistid = $1
+		ORDER BY tracktitle, titleid
+		LIMIT $2 OFFSET $3`, r.schema)
+
+	tracks := []Track{}
+	if err := r.db.SelectContext(ctx, &tracks, query, artistID, limit, offset); err != nil {
+		return nil, 0, types.NewOperationError("fetch artist tracks", err)
+	}
+	return tracks, total, nil
+}
+
+// GetTracksAddedSince retrieves a page of tracks added at or after since, newest first,
+// together with the total number of such tracks. TrackAddedColumn must not be empty.
+func (r *Repository) GetTracksAddedSince(ctx context.Context, since time.Time, limit, offset int) ([]RecentTrack, int, error) {
+	column := pq.QuoteIdentifier(r.trackAddedColumn)
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s.track WHERE %s >= $1", r.schema, column)
+	if err := r.db.GetContext(ctx, &total, countQuery, since); err != nil {
+		return nil, 0, types.NewOperationError("count recent tracks", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			titleid,
+			COALESCE(tracktitle, '') as tracktitle,
+			COALESCE(artist, '') as artist,
+			CASE WHEN picture IS NOT NULL THEN true ELSE false END as has_image,
+			TO_CHAR(%[2]s, 'YYYY-MM-DD"T"HH24:MI:SS') as added_at
+		FROM %[1]s.track
+		WHERE %[2]s >= $1
+		ORDER BY %[2]s DESC, titleid
+		LIMIT $2 OFFSET $3`, r.schema, column)
+
+	tracks := []RecentTrack{}
+	if err := r.db.SelectContext(ctx, &tracks, query, since, limit, offset); err != nil {
+		return nil, 0, types.NewOperationError("fetch recent tracks", err)
+	}
+	return tracks, total, nil
+}
+
+// ListArtists retrieves a page of artists ordered by name, together with the total number of
+// artists matching the filter. A non-nil hasImage only returns artists with or without an image.
+func (r *Repository) ListArtists(ctx context.Context, hasImage *bool, desc bool, limit, offset int) ([]Artist, int, error) {
+	where := ""
+	if hasImage != nil {
+		where = "WHERE picture IS NULL"
+		if *hasImage {
+			where = "WHERE picture IS NOT NULL"
+		}
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s.artist %s", r.schema, where)
+	if err := r.db.GetContext(ctx, &total, countQuery); err != nil {
+		return nil, 0, types.NewOperationError("count artists", err)
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	query := fmt.Sprintf(`
+		SELECT
+			artistid,
+			COALESCE(artist, '') as artist,
+			CASE WHEN picture IS NOT NULL THEN true ELSE false END as has_image
+		FROM %s.artist
+		%s
+		ORDER BY artist %s, artistid
+		LIMIT $1 OFFSET $2`, r.schema, where, direction)
+
+	artists := []Artist{}
+	if err := r.db.SelectContext(ctx, &artists, query, limit, offset); err != nil {
+		return nil, 0, types.NewOperationError("fetch artists", err)
+	}
+	return artists, total, nil
+}
+
+// ListTracks retrieves a page of tracks ordered by title, together with the total number of
+// matching tracks. A non-empty search matches title and artist case-insensitively, and a
+// non-nil hasImage only returns tracks with or without an image.
+func (r *Repository) ListTracks(ctx context.Context, search string, hasImage *bool, limit, offset int) ([]Track, int, error) {
+	if !types.IsValidIdentifier(r.schema) {
+		return nil, 0, types.NewValidationError("schema", fmt.Sprintf("invalid schema name: %s", r.schema))
+	}
+
+	conditions := []string{"TRUE"}
+	var params []any
+	if search != "" {
+		params = append(params, "%"+escapeLikePattern(search)+"%")
+		conditions = append(conditions, fmt.Sprintf("(tracktitle ILIKE $%d OR artist ILIKE $%[1]d)", len(params)))
+	}
+	if hasImage != nil {
+		if *hasImage {
+			conditions = append(conditions, "picture IS NOT NULL")
+		} else {
+			conditions = append(conditions, "picture IS NULL")
+		}
+	}
+	whereClause := strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s.track WHERE %s", r.schema, whereClause)
+	if err := r.db.GetContext(ctx, &total, countQuery, params...); err != nil {
+		return nil, 0, types.NewOperationError("count tracks", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			titleid,
+			COALESCE(tracktitle, '') as tracktitle,
+			COALESCE(artist, '') as artist,
+			CASE WHEN picture IS NOT NULL THEN true ELSE false END as has_image
+		FROM %s.track
+		WHERE %s
+		ORDER BY tracktitle, titleid
+		LIMIT $%d OFFSET $%d`, r.schema, whereClause, len(params)+1, len(params)+2)
+
+	tracks := []Track{}
+	if err := r.db.SelectContext(ctx, &tracks, query, append(params, limit, offset)...); err != nil {
+		return nil, 0, types.NewOperationError("fetch tracks", err)
+	}
+	return tracks, total, nil
+}
+
+// SearchArtists retrieves up to limit artists whose name contains search, case-insensitively.
+func (r *Repository) SearchArtists(ctx context.Context, search string, limit int) ([]Artist, error) {
+	if !types.IsValidIdentifier(r.schema) {
+		return nil, types.NewValidationError("schema", fmt.Sprintf("invalid schema name: %s", r.schema))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			artistid,
+			COALESCE(artist, '') as artist,
+			CASE WHEN picture IS NOT NULL THEN true ELSE false END as has_image
+		FROM %s.artist
+		WHERE artist ILIKE $1
+		ORDER BY artist, artistid
+		LIMIT $2`, r.schema)
+
+	artists := []Artist{}
+	if err := r.db.SelectContext(ctx, &artists, query, "%"+escapeLikePattern(search)+"%", limit); err != nil {
+		return nil, types.NewOperationError("search artists", err)
+	}
+	return artists, nil
+}
+
+// SearchTracks retrieves up to limit tracks whose title contains search, case-insensitively.
+func (r *Repository) SearchTracks(ctx context.Context, search string, limit int) ([]Track, error) {
+	if !types.IsValidIdentifier(r.schema) {
+		return nil, types.NewValidationError("schema", fmt.Sprintf("invalid schema name: %s", r.schema))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			titleid,
+			COALESCE(tracktitle, '') as tracktitle,
+			COALESCE(artist, '') as artist,
+			CASE WHEN picture IS NOT NULL THEN true ELSE false END as has_image
+		FROM %s.track
+		WHERE tracktitle ILIKE $1
+		ORDER BY tracktitle, titleid
+		LIMIT $2`, r.schema)
+
+	tracks := []Track{}
+	if err := r.db.SelectContext(ctx, &tracks, query, "%"+escapeLikePattern(search)+"%", limit); err != nil {
+		return nil, types.NewOperationError("search tracks", err)
+	}
+	return tracks, nil
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLikePattern returns value with LIKE wildcards escaped, so it only matches literally.
+func escapeLikePattern(value string) string {
+	return likeEscaper.Replace(value)
+}
+
 // --- Image operations ---
 
 // GetImage retrieves the image for an entity.
@@ -85,6 +456,102 @@ func (r *Repository) GetImage(ctx context.Context, table types.Table, id string)
 	return imageData, nil
 }
 
+// GetImageHash returns the hex-encoded MD5 hash of an entity's stored image, or an empty string if it has none.
+// The hash is computed by PostgreSQL so the image itself is not transferred.
+func (r *Repository) GetImageHash(ctx context.Context, table types.Table, id string) (string, error) {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, table)
+	if err != nil {
+		return "", types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+	label := string(table)
+	idCol := types.IDColumnForTable(table)
+
+	query := fmt.Sprintf("SELECT COALESCE(md5(picture), '') FROM %s WHERE %s = $1", qualifiedTableName, idCol)
+
+	var hash string
+	err = r.db.GetContext(ctx, &hash, query, id)
+	if err == sql.ErrNoRows {
+		return "", types.NewNotFoundError(label, id)
+	}
+	if err != nil {
+		return "", types.NewOperationError(fmt.Sprintf("fetch %s image hash", label), err)
+	}
+	return hash, nil
+}
+
+// StoredImage is a stored image together with the ID and display name of its entity.
+type StoredImage struct {
+	ID      string `db:"id"`
+	Name    string `db:"name"`
+	Picture []byte `db:"picture"`
+}
+
+// EachImage calls fn for every stored image in a table, ordered by ID.
+// Rows are read one at a time, so the images are never held in memory together.
+func (r *Repository) EachImage(ctx context.Context, table types.Table, fn func(StoredImage) error) error {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, table)
+	if err != nil {
+		return types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+	idCol := types.IDColumnForTable(table)
+	nameCol := "artist"
+	if table == types.TableTrack {
+		nameCol = "tracktitle"
+	}
+
+	query := fmt.Sprintf("SELECT %s as id, COALESCE(%s, '') as name, picture FROM %s WHERE picture IS NOT NULL ORDER BY %s",
+		idCol, nameCol, qualifiedTableName, idCol)
+
+	rows, err := r.db.QueryxContext(ctx, query)
+	if err != nil {
+		return types.NewOperationError(fmt.Sprintf("fetch %s images", table), err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Debug("Failed to close image rows", "table", table, "error", err)
+		}
+	}()
+
+	for rows.Next() {
+		var stored StoredImage
+		if err := rows.StructScan(&stored); err != nil {
+			return types.NewOperationError(fmt.Sprintf("read %s image", table), err)
+		}
+		if err := fn(stored); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return types.NewOperationError(fmt.Sprintf("fetch %s images", table), err)
+	}
+	return nil
+}
+
+// ImageRef identifies a stored image by the ID of its entity and its size in bytes.
+type ImageRef struct {
+	ID   string `db:"id"`
+	Size int64  `db:"size"`
+}
+
+// ListImageRefs returns the ID and image size of every entity in a table that has an image, ordered by ID.
+// Only the sizes are transferred, so the list is cheap to build even for a large catalog.
+func (r *Repository) ListImageRefs(ctx context.Context, table types.Table) ([]ImageRef, error) {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, table)
+	if err != nil {
+		return nil, types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+	idCol := types.IDColumnForTable(table)
+
+	query := fmt.Sprintf("SELECT %s as id, octet_length(picture) as size FROM %s WHERE picture IS NOT NULL ORDER BY %s",
+		idCol, qualifiedTableName, idCol)
+
+	refs := []ImageRef{}
+	if err := r.db.SelectContext(ctx, &refs, query); err != nil {
+		return nil, types.NewOperationError(fmt.Sprintf("list %s images", table), err)
+	}
+	return refs, nil
+}
+
 // UpdateImage stores new image data for the specified entity.
 func (r *Repository) UpdateImage(ctx context.Context, table types.Table, id string, imageData []byte) error {
 	qualifiedTableName, err := types.QualifiedTable(r.schema, table)
@@ -134,16 +601,18 @@ func (r *Repository) DeleteImage(ctx context.Context, table types.Table, id stri
 // --- Count operations ---
 
 // CountWithImages counts entities that have images.
-func (r *Repository) CountWithImages(ctx context.Context, table types.Table) (int, error) {
-	return r.countItems(ctx, table, true)
+// With activeOnly, tracks excluded from operations are not counted; it has no effect on other tables.
+func (r *Repository) CountWithImages(ctx context.Context, table types.Table, activeOnly bool) (int, error) {
+	return r.countItems(ctx, table, true, activeOnly)
 }
 
 // CountWithoutImages counts entities that don't have images.
-func (r *Repository) CountWithoutImages(ctx context.Context, table types.Table) (int, error) {
-	return r.countItems(ctx, table, false)
+// With activeOnly, tracks excluded from operations are not counted; it has no effect on other tables.
+func (r *Repository) CountWithoutImages(ctx context.Context, table types.Table, activeOnly bool) (int, error) {
+	return r.countItems(ctx, table, false, activeOnly)
 }
 
-func (r *Repository) countItems(ctx context.Context, table types.Table, hasImage bool) (int, error) {
+func (r *Repository) countItems(ctx context.Context, table types.Table, hasImage, activeOnly bool) (int, error) {
 	condition := "IS NULL"
 	if hasImage {
 		condition = "IS NOT NULL"
@@ -155,8 +624,14 @@ func (r *Repository) countItems(ctx context.Context, table types.Table, hasImage
 	}
 	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE picture %s", qualifiedTableName, condition)
 
+	var params []any
+	if activeOnly && table == types.TableTrack {
+		query += " AND COALESCE(exporttype, 0) <> $1"
+		params = append(params, ExportTypeExcluded)
+	}
+
 	var count int
-	err = r.db.GetContext(ctx, &count, query)
+	err = r.db.GetContext(ctx, &count, query, params...)
 	if err != nil {
 		return 0, types.NewOperationError(fmt.Sprintf("count %s", table), err)
 	}
@@ -164,6 +639,64 @@ func (r *Repository) countItems(ctx context.Context, table types.Table, hasImage
 	return count, nil
 }
 
+// imageSizeBuckets defines the histogram ranges in ascending order; a zero upper bound means unbounded.
+var imageSizeBuckets = []struct {
+	label string
+	upper int64
+}{
+	{"<50KB", 50 * 1024},
+	{"50-200KB", 200 * 1024},
+	{"200KB-1MB", 1024 * 1024},
+	{">1MB", 0},
+}
+
+// GetImageSizeBuckets groups the stored images of a table by size.
+// All buckets are returned in ascending order, including empty ones.
+func (r *Repository) GetImageSizeBuckets(ctx context.Context, table types.Table) ([]ImageSizeBucket, error) {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, table)
+	if err != nil {
+		return nil, types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+
+	var caseExpr strings.Builder
+	caseExpr.WriteString("CASE")
+	for _, bucket := range imageSizeBuckets {
+		if bucket.upper == 0 {
+			fmt.Fprintf(&caseExpr, " ELSE '%s'", bucket.label)
+			continue
+		}
+		fmt.Fprintf(&caseExpr, " WHEN octet_length(picture) < %d THEN '%s'", bucket.upper, bucket.label)
+	}
+	caseExpr.WriteString(" END")
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s as bucket,
+			COUNT(*) as count,
+			COALESCE(SUM(octet_length(picture)), 0) as total_bytes
+		FROM %s
+		WHERE picture IS NOT NULL
+		GROUP BY bucket`, caseExpr.String(), qualifiedTableName)
+
+	var rows []ImageSizeBucket
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, types.NewOperationError(fmt.Sprintf("fetch %s image sizes", table), err)
+	}
+
+	counts := make(map[string]ImageSizeBucket, len(rows))
+	for _, row := range rows {
+		counts[row.Label] = row
+	}
+
+	buckets := make([]ImageSizeBucket, 0, len(imageSizeBuckets))
+	for _, bucket := range imageSizeBuckets {
+		row := counts[bucket.label]
+		row.Label = bucket.label
+		buckets = append(buckets, row)
+	}
+	return buckets, nil
+}
+
 // DeleteAllImages removes all images for entities in the specified table.
 func (r *Repository) DeleteAllImages(ctx context.Context, table types.Table) (int64, error) {
 	qualifiedTableName, err := types.QualifiedTable(r.schema, table)
@@ -226,8 +759,85 @@ func (r *Repository) GetPlaylistBlocks(ctx context.Context, date string) ([]Play
 	return blocks, nil
 }
 
-// GetPlaylistWithTracks retrieves all blocks with their associated tracks for a date.
-func (r *Repository) GetPlaylistWithTracks(ctx context.Context, date string) ([]PlaylistBlock, map[string][]PlaylistItem, error) {
+// GetPlaylistStats aggregates the playlist items in the blocks of a date; an empty date means today.
+func (r *Repository) GetPlaylistStats(ctx context.Context, date string) (*PlaylistStats, error) {
+	var blockFilter, itemFilter string
+	params := []any{}
+
+	if date != "" {
+		blockFilter = "pb.startdatetime >= $1::date AND pb.startdatetime < $1::date + INTERVAL '1 day'"
+		itemFilter = "pi.startdatetime >= $1::date AND pi.startdatetime < $1::date + INTERVAL '1 day'"
+		params = append(params, date)
+	} else {
+		blockFilter = "pb.startdatetime >= CURRENT_DATE AND pb.startdatetime < CURRENT_DATE + INTERVAL '1 day'"
+		itemFilter = "pi.startdatetime >= CURRENT_DATE AND pi.startdatetime < CURRENT_DATE + INTERVAL '1 day'"
+	}
+
+	musicCondition := fmt.Sprintf(playlistMusicCondition, types.VoicetrackUserID)
+	columns := fmt.Sprintf(playlistStatsColumns, musicCondition, types.VoicetrackUserID)
+	joins := fmt.Sprintf(playlistItemJoins, r.schema, r.schema, r.schema)
+	query := fmt.Sprintf("SELECT %s %s WHERE %s AND pi.blockid IN (SELECT pb.blockid FROM %s.playlistblock pb WHERE %s)",
+		columns, joins, itemFilter, r.schema, blockFilter)
+
+	var stats PlaylistStats
+	if err := r.db.GetContext(ctx, &stats, query, params...); err != nil {
+		return nil, types.NewOperationError("fetch playlist statistics", err)
+	}
+	return &stats, nil
+}
+
+// GetPlaylistItemSchedule returns the scheduled start of a playlist item and the bounds of its block.
+func (r *Repository) GetPlaylistItemSchedule(ctx context.Context, id int64) (*PlaylistItemSchedule, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			pi.id as itemid,
+			pi.blockid::text as blockid,
+			pi.startdatetime,
+			pb.startdatetime as block_start,
+			pb.enddatetime as block_end
+		FROM %s.playlistitem pi
+		JOIN %s.playlistblock pb ON pi.blockid = pb.blockid
+		WHERE pi.id = $1`, r.schema, r.schema)
+
+	var schedule PlaylistItemSchedule
+	err := r.db.GetContext(ctx, &schedule, query, id)
+	if err == sql.ErrNoRows {
+		return nil, types.NewNotFoundError("playlist item", strconv.FormatInt(id, 10))
+	}
+	if err != nil {
+		return nil, types.NewOperationError("fetch playlist item", err)
+	}
+	return &schedule, nil
+}
+
+// UpdatePlaylistItemStart sets the scheduled start of a playlist item.
+// The update only applies while start lies within the item's block, so a concurrent block change cannot
+// move the item outside of it; in that case false is returned.
+func (r *Repository) UpdatePlaylistItemStart(ctx context.Context, id int64, start time.Time) (bool, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s.playlistitem pi
+		SET startdatetime = $1
+		FROM %s.playlistblock pb
+		WHERE pi.id = $2
+			AND pb.blockid = pi.blockid
+			AND $1 >= pb.startdatetime
+			AND $1 < pb.enddatetime`, r.schema, r.schema)
+
+	result, err := r.db.ExecContext(ctx, query, start, id)
+	if err != nil {
+		return false, types.NewOperationError("update playlist item", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, types.NewOperationError("update playlist item", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetPlaylistWithTracks retrieves all blocks with their associated tracks for opts.Date.
+// Items are filtered by opts.ExportTypes, opts.Modes, and opts.HasArtist; other options are ignored.
+func (r *Repository) GetPlaylistWithTracks(ctx context.Context, opts *PlaylistOptions) ([]PlaylistBlock, map[string][]PlaylistItem, error) {
+	date, modes := opts.Date, opts.Modes
 	blocks, err := r.GetPlaylistBlocks(ctx, date)
 	if err != nil {
 		return nil, nil, err
@@ -261,15 +871,38 @@ func (r *Repository) GetPlaylistWithTracks(ctx context.Context, date string) ([]
 		params = append(params, id)
 	}
 
+	var itemFilter string
+	if len(modes) > 0 {
+		itemFilter = " AND " + modeCondition(modes, func() string {
+			paramCount++
+			return fmt.Sprintf("$%d", paramCount)
+		})
+		for _, mode := range modes {
+			params = append(params, mode)
+		}
+	}
+	if len(opts.ExportTypes) > 0 {
+		itemFilter += " AND " + exportTypeCondition(opts.ExportTypes, func() string {
+			paramCount++
+			return fmt.Sprintf("$%d", paramCount)
+		})
+		for _, exportType := range opts.ExportTypes {
+			params = append(params, exportType)
+		}
+	}
+	if opts.HasArtist != nil {
+		itemFilter += " AND " + artistCondition(*opts.HasArtist)
+	}
+
 	type playlistItemWithBlockID struct {
 		PlaylistItem
 		TempBlockID string `db:"blockid"`
 	}
 
-	columns := fmt.Sprintf(playlistItemColumns, types.VoicetrackUserID)
+	columns := fmt.Sprintf(playlistItemColumns, types.VoicetrackUserID, types.NoArtistID)
 	joins := fmt.Sprintf(playlistItemJoins, r.schema, r.schema, r.schema)
-	query := fmt.Sprintf("SELECT %s, COALESCE(pi.blockid::text, '') as blockid %s WHERE %s AND pi.blockid IN (%s) ORDER BY pi.blockid, pi.startdatetime",
-		columns, joins, dateFilter, strings.Join(placeholders, ","))
+	query := fmt.Sprintf("SELECT %s, COALESCE(pi.blockid::text, '') as blockid %s WHERE %s AND pi.blockid IN (%s)%s ORDER BY pi.blockid, pi.startdatetime",
+		columns, joins, dateFilter, strings.Join(placeholders, ","), itemFilter)
 
 	var tempItems []playlistItemWithBlockID
 	err = r.db.SelectContext(ctx, &tempItems, query, params...)
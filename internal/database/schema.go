@@ -0,0 +1,130 @@
+// Package database provides PostgreSQL data access for the Aeron database.
+package database
+
+import (
+	"context"
+	"slices"
+
+	"github.com/lib/pq"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// ColumnType groups PostgreSQL data types that are interchangeable for the application's queries.
+type ColumnType string
+
+const (
+	// ColumnTypeUUID matches uuid columns.
+	ColumnTypeUUID ColumnType = "uuid"
+	// ColumnTypeText matches text and character columns.
+	ColumnTypeText ColumnType = "text"
+	// ColumnTypeInteger matches integer columns of any width.
+	ColumnTypeInteger ColumnType = "integer"
+	// ColumnTypeBytea matches binary columns.
+	ColumnTypeBytea ColumnType = "bytea"
+	// ColumnTypeTimestamp matches timestamp columns with or without time zone.
+	ColumnTypeTimestamp ColumnType = "timestamp"
+)
+
+// columnTypeDataTypes maps each ColumnType to the information_schema data_type values it accepts.
+var columnTypeDataTypes = map[ColumnType][]string{
+	ColumnTypeUUID:      {"uuid"},
+	ColumnTypeText:      {"text", "character varying", "character"},
+	ColumnTypeInteger:   {"smallint", "integer", "bigint", "numeric"},
+	ColumnTypeBytea:     {"bytea"},
+	ColumnTypeTimestamp: {"timestamp without time zone", "timestamp with time zone"},
+}
+
+// Matches reports whether a PostgreSQL data_type value is compatible with this column type.
+func (t ColumnType) Matches(dataType string) bool {
+	return slices.Contains(columnTypeDataTypes[t], dataType)
+}
+
+// ExpectedColumn describes a column that the application's queries depend on.
+type ExpectedColumn struct {
+	Table  string
+	Column string
+	Type   ColumnType
+}
+
+// ExpectedColumns lists every column referenced by the artist, track, and playlist queries.
+var ExpectedColumns = []ExpectedColumn{
+	{"artist", "artistid", ColumnTypeUUID},
+	{"artist", "artist", ColumnTypeText},
+	{"artist", "info", ColumnTypeText},
+	{"artist", "website", ColumnTypeText},
+	{"artist", "twitter", ColumnTypeText},
+	{"artist", "instagram", ColumnTypeText},
+	{"artist", "repeatvalue", ColumnTypeInteger},
+	{"artist", "picture", ColumnTypeBytea},
+
+	{"track", "titleid", ColumnTypeUUID},
+	{"track", "tracktitle", ColumnTypeText},
+	{"track", "artist", ColumnTypeText},
+	{"track", "artistid", ColumnTypeUUID},
+	{"track", "userid", ColumnTypeUUID},
+	{"track", "Year", ColumnTypeInteger},
+	{"track", "knownlength", ColumnTypeInteger},
+	{"track", "introtime", ColumnTypeInteger},
+	{"track", "outrotime", ColumnTypeInteger},
+	{"track", "tempo", ColumnTypeInteger},
+	{"track", "bpm", ColumnTypeInteger},
+	{"track", "gender", ColumnTypeInteger},
+	{"track", "Language", ColumnTypeInteger},
+	{"track", "mood", ColumnTypeInteger},
+	{"track", "exporttype", ColumnTypeInteger},
+	{"track", "repeatvalue", ColumnTypeInteger},
+	{"track", "rating", ColumnTypeInteger},
+	{"track", "website", ColumnTypeText},
+	{"track", "conductor", ColumnTypeText},
+	{"track", "orchestra", ColumnTypeText},
+	{"track", "picture", ColumnTypeBytea},
+
+	{"playlistitem", "id", ColumnTypeInteger},
+	{"playlistitem", "titleid", ColumnTypeUUID},
+	{"playlistitem", "blockid", ColumnTypeUUID},
+	{"playlistitem", "startdatetime", ColumnTypeTimestamp},
+	{"playlistitem", "mode", ColumnTypeInteger},
+	{"playlistitem", "commblock", ColumnTypeInteger},
+
+	{"playlistblock", "blockid", ColumnTypeUUID},
+	{"playlistblock", "name", ColumnTypeText},
+	{"playlistblock", "startdatetime", ColumnTypeTimestamp},
+	{"playlistblock", "enddatetime", ColumnTypeTimestamp},
+}
+
+// ColumnInfo describes an existing column as reported by information_schema.
+type ColumnInfo struct {
+	Table    string `db:"table_name"`
+	Column   string `db:"column_name"`
+	DataType string `db:"data_type"`
+}
+
+// GetTables returns the names of the base tables in the repository schema.
+func (r *Repository) GetTables(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = $1 AND table_type = 'BASE TABLE'
+		ORDER BY table_name`
+
+	var tables []string
+	if err := r.db.SelectContext(ctx, &tables, query, r.schema); err != nil {
+		return nil, types.NewOperationError("fetch schema tables", err)
+	}
+	return tables, nil
+}
+
+// GetColumns returns the columns of the given tables in the repository schema.
+func (r *Repository) GetColumns(ctx context.Context, tables []string) ([]ColumnInfo, error) {
+	query := `
+		SELECT table_name, column_name, data_type
+		FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = ANY($2)
+		ORDER BY table_name, ordinal_position`
+
+	var columns []ColumnInfo
+	if err := r.db.SelectContext(ctx, &columns, query, r.schema, pq.Array(tables)); err != nil {
+		return nil, types.NewOperationError("fetch schema columns", err)
+	}
+	return columns, nil
+}
@@ -0,0 +1,134 @@
+// Package database provides PostgreSQL data access for the Aeron database.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// imageVariantTable holds resized variants of entity images. Unlike the other tables it is
+// owned by the toolbox rather than Aeron, and only exists when image variants are enabled.
+const imageVariantTable types.Table = "toolbox_image_variant"
+
+// ImageVariantTableExists reports whether the image variant table exists in the schema.
+func (r *Repository) ImageVariantTableExists(ctx context.Context) (bool, error) {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, imageVariantTable)
+	if err != nil {
+		return false, types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, "SELECT to_regclass($1) IS NOT NULL", qualifiedTableName); err != nil {
+		return false, types.NewOperationError("check image variant table", err)
+	}
+	return exists, nil
+}
+
+// CreateImageVariantTable creates the image variant table if it does not exist yet.
+func (r *Repository) CreateImageVariantTable(ctx context.Context) error {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, imageVariantTable)
+	if err != nil {
+		return types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			entity_type text NOT NULL,
+			entity_id text NOT NULL,
+			variant text NOT NULL,
+			picture bytea NOT NULL,
+			PRIMARY KEY (entity_type, entity_id, variant)
+		)`, qualifiedTableName)
+
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return types.NewOperationError("create image variant table", err)
+	}
+	return nil
+}
+
+// SaveImageVariants stores the variants of an entity image, replacing existing ones with the same name.
+// All variants are written in a single transaction.
+func (r *Repository) SaveImageVariants(ctx context.Context, table types.Table, id string, variants map[string][]byte) error {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, imageVariantTable)
+	if err != nil {
+		return types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (entity_type, entity_id, variant, picture)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (entity_type, entity_id, variant) DO UPDATE SET picture = EXCLUDED.picture`, qualifiedTableName)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return types.NewOperationError(fmt.Sprintf("save %s image variants", table), err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Debug("Failed to roll back image variant transaction", "table", table, "error", err)
+		}
+	}()
+
+	for variant, imageData := range variants {
+		if _, err := tx.ExecContext(ctx, query, string(table), id, variant, imageData); err != nil {
+			return types.NewOperationError(fmt.Sprintf("save %s %s image", table, variant), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.NewOperationError(fmt.Sprintf("save %s image variants", table), err)
+	}
+	return nil
+}
+
+// GetImageVariant retrieves a stored image variant for an entity.
+func (r *Repository) GetImageVariant(ctx context.Context, table types.Table, id, variant string) ([]byte, error) {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, imageVariantTable)
+	if err != nil {
+		return nil, types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+
+	query := fmt.Sprintf("SELECT picture FROM %s WHERE entity_type = $1 AND entity_id = $2 AND variant = $3", qualifiedTableName)
+
+	var imageData []byte
+	err = r.db.GetContext(ctx, &imageData, query, string(table), id, variant)
+	if err == sql.ErrNoRows {
+		return nil, types.NewNoImageError(fmt.Sprintf("%s %s", table, variant), id)
+	}
+	if err != nil {
+		return nil, types.NewOperationError(fmt.Sprintf("fetch %s %s image", table, variant), err)
+	}
+	return imageData, nil
+}
+
+// DeleteImageVariants removes all stored variants of an entity image.
+func (r *Repository) DeleteImageVariants(ctx context.Context, table types.Table, id string) error {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, imageVariantTable)
+	if err != nil {
+		return types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE entity_type = $1 AND entity_id = $2", qualifiedTableName)
+	if _, err := r.db.ExecContext(ctx, query, string(table), id); err != nil {
+		return types.NewOperationError(fmt.Sprintf("delete %s image variants", table), err)
+	}
+	return nil
+}
+
+// DeleteAllImageVariants removes the stored image variants of every entity in a table.
+func (r *Repository) DeleteAllImageVariants(ctx context.Context, table types.Table) error {
+	qualifiedTableName, err := types.QualifiedTable(r.schema, imageVariantTable)
+	if err != nil {
+		return types.NewValidationError("table", fmt.Sprintf("invalid table configuration: %v", err))
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE entity_type = $1", qualifiedTableName)
+	if _, err := r.db.ExecContext(ctx, query, string(table)); err != nil {
+		return types.NewOperationError(fmt.Sprintf("delete %s image variants", table), err)
+	}
+	return nil
+}
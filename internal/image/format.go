@@ -0,0 +1,160 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"mime"
+	"path"
+	"strings"
+)
+
+// NormalizeFormat returns the canonical name of an image format, so "jpg", "JPEG" and "pjpeg" all become "jpeg",
+// and "heif" becomes "heic".
+func NormalizeFormat(format string) string {
+	switch format = strings.ToLower(strings.TrimSpace(format)); format {
+	case "jpg", "pjpeg":
+		return "jpeg"
+	case "heif":
+		return "heic"
+	default:
+		return format
+	}
+}
+
+// FormatFromMediaType returns the image format declared by a Content-Type, or an empty string
+// if it declares no image type.
+func FormatFromMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	subtype, ok := strings.CutPrefix(mediaType, "image/")
+	if !ok {
+		return ""
+	}
+	return NormalizeFormat(subtype)
+}
+
+// FormatFromFilename returns the image format suggested by a file extension, or an empty string without one.
+func FormatFromFilename(name string) string {
+	return NormalizeFormat(strings.TrimPrefix(path.Ext(name), "."))
+}
+
+// FormatFromDataURL returns the image format declared by a data URL such as "data:image/png;base64,...",
+// or an empty string for plain base64 data.
+func FormatFromDataURL(data string) string {
+	header, _, found := strings.Cut(data, ",")
+	if !found {
+		return ""
+	}
+	mediaType, ok := strings.CutPrefix(header, "data:")
+	if !ok {
+		return ""
+	}
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	return FormatFromMediaType(mediaType)
+}
+
+// hasTrailingData reports whether data continues after the end of the image, as in polyglot files
+// that hide an archive or script behind a valid image. Unparseable data is reported as trailing,
+// so such files are never stored as is.
+func hasTrailingData(data []byte, format string) bool {
+	var end int
+	switch NormalizeFormat(format) {
+	case "jpeg":
+		end = jpegEnd(data)
+	case "png":
+		end = pngEnd(data)
+	case "webp":
+		end = webpEnd(data)
+	default:
+		return false
+	}
+	return end < 0 || end < len(data)
+}
+
+// jpegEnd returns the offset just past the EOI marker of a JPEG stream, or -1 if none is found.
+// Segments are followed by their length, and entropy-coded scan data is skipped up to the next marker,
+// so image data that happens to contain an EOI byte pair is not mistaken for the end.
+func jpegEnd(data []byte) int {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return -1
+	}
+	pos := 2
+	for pos+1 < len(data) {
+		if data[pos] != 0xFF {
+			return -1
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF: // fill byte
+			pos++
+			continue
+		case marker == 0xD9: // EOI
+			return pos + 2
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // markers without a length
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(data) {
+			return -1
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if marker == 0xDA { // SOS: skip the scan data up to the next marker
+			pos = skipJPEGScan(data, pos)
+		}
+	}
+	return -1
+}
+
+// skipJPEGScan returns the offset of the first marker after entropy-coded scan data starting at pos.
+// Stuffed zero bytes and restart markers belong to the scan.
+func skipJPEGScan(data []byte, pos int) int {
+	for pos+1 < len(data) {
+		if data[pos] == 0xFF {
+			next := data[pos+1]
+			if next != 0x00 && (next < 0xD0 || next > 0xD7) {
+				return pos
+			}
+		}
+		pos++
+	}
+	return len(data)
+}
+
+// pngEnd returns the offset just past the IEND chunk of a PNG stream, or -1 if none is found.
+func pngEnd(data []byte) int {
+	const signatureLength = 8
+	if len(data) < signatureLength || !bytes.Equal(data[:signatureLength], []byte("\x89PNG\r\n\x1a\n")) {
+		return -1
+	}
+	pos := signatureLength
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		pos += 12 + length // length, type, data and CRC
+		if length < 0 || pos > len(data) {
+			return -1
+		}
+		if chunkType == "IEND" {
+			return pos
+		}
+	}
+	return -1
+}
+
+// webpEnd returns the offset just past the RIFF container of a WebP stream, or -1 if the header is invalid.
+func webpEnd(data []byte) int {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return -1
+	}
+	end := 8 + int(binary.LittleEndian.Uint32(data[4:]))
+	if end > len(data) {
+		return -1
+	}
+	if end%2 == 1 && end < len(data) {
+		end++ // chunks are padded to an even size
+	}
+	return end
+}
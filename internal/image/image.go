@@ -3,22 +3,76 @@ package image
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
+	"image/color"
 	"image/jpeg"
 	"image/png"
+	"log/slog"
+	"math"
 
+	"github.com/gen2brain/avif"
+	"github.com/gen2brain/webp"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/util"
 	"golang.org/x/image/draw"
 )
 
+// Policies for images whose optimized version is not smaller than the original.
+const (
+	NotSmallerKeep     = "keep"     // store the original as-is
+	NotSmallerReencode = "reencode" // always store the re-encoded image, even if larger
+	NotSmallerReject   = "reject"   // refuse the upload
+)
+
+// Output formats for optimized images.
+const (
+	OutputJPEG = "jpeg"
+	OutputWebP = "webp"
+	OutputAuto = "auto" // encode both and keep the smaller one
+	OutputAVIF = "avif" // only chosen when Config.EnableAVIF is set
+)
+
+// avifMinSavings is how much smaller than the JPEG or WebP result an AVIF encoding must be to be chosen.
+// AVIF decodes slower and is less widely supported, so a marginal gain is not worth switching for.
+const avifMinSavings = 0.15
+
 // Config contains image processing settings.
 type Config struct {
-	TargetWidth   int
-	TargetHeight  int
-	Quality       int
-	RejectSmaller bool
+	TargetWidth      int
+	TargetHeight     int
+	Quality          int
+	AdaptiveQuality  bool // choose the quality between MinQuality and MaxQuality based on the source size
+	MinQuality       int
+	MaxQuality       int
+	RejectSmaller    bool
+	MinSourceBytes   int64       // minimum source file size in bytes, 0 for no minimum
+	SkipBelowBytes   int64       // store sources smaller than this within the target dimensions as is, 0 to always optimize
+	NotSmallerPolicy string      // one of the NotSmaller* policies, empty behaves as NotSmallerKeep
+	OutputFormat     string      // one of the Output* formats, empty behaves as OutputJPEG
+	EnableAVIF       bool        // also encode AVIF and keep it when it is at least avifMinSavings smaller
+	EnableHEIC       bool        // accept HEIC sources, which are always converted to the output format
+	PerceptualHash   bool        // compute a perceptual hash of the resulting image
+	StripMetadata    bool        // remove EXIF, XMP, IPTC, and ICC metadata, also from images stored as-is
+	Background       color.Color // fills transparent areas when encoding to JPEG, nil for white
+	MaxPixels        int64       // maximum width*height accepted before decoding, 0 for no limit
+	MaxWidth         int         // absolute maximum output width, clamps TargetWidth; 0 for no clamp
+	MaxHeight        int         // absolute maximum output height, clamps TargetHeight; 0 for no clamp
+}
+
+// resizeBounds returns the dimensions images are scaled down to fit: the target dimensions,
+// clamped to MaxWidth and MaxHeight. The clamp bounds the buffer the resize allocates,
+// however large the target dimensions are configured.
+func (c Config) resizeBounds() (width, height int) {
+	width, height = c.TargetWidth, c.TargetHeight
+	if c.MaxWidth > 0 {
+		width = min(width, c.MaxWidth)
+	}
+	if c.MaxHeight > 0 {
+		height = min(height, c.MaxHeight)
+	}
+	return width, height
 }
 
 // ProcessingResult contains the results of image processing operations.
@@ -29,6 +83,9 @@ type ProcessingResult struct {
 	Original  Info
 	Optimized Info
 	Savings   float64
+	Quality   int    // quality used for encoding, 0 if the original was kept
+	Hash      uint64 // perceptual hash of Data, only set when Config.PerceptualHash is enabled
+	Sanitized bool   // the original had data after the end of the image, so it was re-encoded rather than kept
 }
 
 // Info contains image metadata.
@@ -50,13 +107,18 @@ func NewOptimizer(config Config) *Optimizer {
 }
 
 // DownloadImage downloads an image from a URL with SSRF protection.
-func DownloadImage(urlString string, maxSize int64) ([]byte, error) {
-	return util.ValidateAndDownloadImage(urlString, maxSize)
+// It also returns the image format declared by the server's Content-Type, if any.
+func DownloadImage(urlString string, maxSize int64, maxRedirects int, maxPixels int64) (data []byte, declaredFormat string, err error) {
+	data, contentType, err := util.ValidateAndDownloadImage(urlString, maxSize, maxRedirects, maxPixels)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, FormatFromMediaType(contentType), nil
 }
 
 // getImageInfo extracts format, width, and height metadata from image data.
-func getImageInfo(data []byte) (format string, width, height int, err error) {
-	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+func getImageInfo(data []byte, maxPixels int64) (format string, width, height int, err error) {
+	config, format, err := util.DecodeImageConfig(data, maxPixels)
 	if err != nil {
 		return "", 0, 0, err
 	}
@@ -65,7 +127,7 @@ func getImageInfo(data []byte) (format string, width, height int, err error) {
 
 // OptimizeImage processes and optimizes image data according to the configured settings.
 func (o *Optimizer) OptimizeImage(data []byte) (optimized []byte, format, encoder string, err error) {
-	_, format, err = image.DecodeConfig(bytes.NewReader(data))
+	_, format, err = util.DecodeImageConfig(data, o.Config.MaxPixels)
 	if err != nil {
 		return nil, "", "", err
 	}
@@ -74,7 +136,11 @@ func (o *Optimizer) OptimizeImage(data []byte) (optimized []byte, format, encode
 	case "jpeg", "jpg":
 		return o.optimizeJPEG(data)
 	case "png":
-		return o.convertPNGToJPEG(data)
+		return o.convertPNG(data)
+	case "webp":
+		return o.optimizeWebP(data)
+	case "heic":
+		return o.convertHEIC(data)
 	default:
 		return data, format, "original", nil
 	}
@@ -91,37 +157,175 @@ func (o *Optimizer) optimizeJPEG(data []byte) (optimized []byte, format, encoder
 	return o.processImage(sourceImage, data, "jpeg")
 }
 
-// convertPNGToJPEG converts PNG image data to optimized JPEG format.
-func (o *Optimizer) convertPNGToJPEG(data []byte) (optimized []byte, format, encoder string, err error) {
+// convertPNG converts PNG image data to the optimized output format.
+func (o *Optimizer) convertPNG(data []byte) (optimized []byte, format, encoder string, err error) {
 	var sourceImage image.Image
 	sourceImage, err = png.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, "", "", types.NewValidationError("image", fmt.Sprintf("failed to decode PNG: %v", err))
 	}
 
-	return o.processImage(sourceImage, data, "jpeg")
+	return o.processImage(sourceImage, data, "png")
+}
+
+// optimizeWebP processes WebP image data to optimize size and dimensions.
+func (o *Optimizer) optimizeWebP(data []byte) (optimized []byte, format, encoder string, err error) {
+	var sourceImage image.Image
+	sourceImage, err = webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", "", types.NewValidationError("image", fmt.Sprintf("failed to decode WebP: %v", err))
+	}
+
+	return o.processImage(sourceImage, data, "webp")
+}
+
+// convertHEIC converts HEIC image data to the optimized output format.
+// The decoder is registered with the image package, in builds that include it.
+func (o *Optimizer) convertHEIC(data []byte) (optimized []byte, format, encoder string, err error) {
+	var sourceImage image.Image
+	sourceImage, _, err = image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", "", types.NewValidationError("image", fmt.Sprintf("failed to decode HEIC: %v", err))
+	}
+
+	return o.processImage(sourceImage, data, "heic")
 }
 
 // processImage resizes and encodes an image, returning optimized data if smaller.
-func (o *Optimizer) processImage(sourceImage image.Image, originalData []byte, outputFormat string) (optimized []byte, format, encoder string, err error) {
+// The original data and its format are returned when encoding does not reduce the size.
+func (o *Optimizer) processImage(sourceImage image.Image, originalData []byte, originalFormat string) (optimized []byte, format, encoder string, err error) {
 	bounds := sourceImage.Bounds()
 	width, height := bounds.Dx(), bounds.Dy()
 
-	if width > o.Config.TargetWidth || height > o.Config.TargetHeight {
-		sourceImage = o.resizeImage(sourceImage, o.Config.TargetWidth, o.Config.TargetHeight)
+	maxWidth, maxHeight := o.Config.resizeBounds()
+	if width > maxWidth || height > maxHeight {
+		sourceImage = o.resizeImage(sourceImage, maxWidth, maxHeight)
+	}
+
+	optimizedData, outputFormat, encoder, err := o.encode(sourceImage)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if len(optimizedData) < len(originalData) || o.Config.NotSmallerPolicy == NotSmallerReencode {
+		return optimizedData, outputFormat, encoder, nil
 	}
 
-	var jpegBuffer bytes.Buffer
-	if err := jpeg.Encode(&jpegBuffer, sourceImage, &jpeg.Options{Quality: o.Config.Quality}); err != nil {
-		return nil, "", "", types.NewValidationError("image", fmt.Sprintf("JPEG encoding failed: %v", err))
+	return originalData, originalFormat, fmt.Sprintf("original (not larger than optimized version, %s)", encoder), nil
+}
+
+// encode encodes an image in the configured output format. With EnableAVIF, the image is
+// also encoded as AVIF at the same time, and the AVIF result is returned when it is at least
+// avifMinSavings smaller. A failed AVIF encoding falls back to the configured format.
+// The encoder description lists the chosen format and size first, followed by the alternatives.
+func (o *Optimizer) encode(img image.Image) (data []byte, format, encoder string, err error) {
+	if !o.Config.EnableAVIF {
+		return o.encodeConfigured(img)
 	}
-	optimizedData := jpegBuffer.Bytes()
 
-	if len(optimizedData) < len(originalData) {
-		return optimizedData, outputFormat, "optimized", nil
+	type avifResult struct {
+		data []byte
+		err  error
+	}
+	avifDone := make(chan avifResult, 1)
+	go func() {
+		data, err := o.encodeAVIF(img)
+		avifDone <- avifResult{data, err}
+	}()
+
+	data, format, encoder, err = o.encodeConfigured(img)
+	avifEncoded := <-avifDone
+	if err != nil {
+		return nil, "", "", err
 	}
 
-	return originalData, outputFormat, "original", nil
+	if avifEncoded.err != nil {
+		slog.Debug("AVIF encoding failed, keeping "+format, "error", avifEncoded.err)
+		return data, format, encoder, nil
+	}
+	avifEncoder := describeEncoding(OutputAVIF, avifEncoded.data)
+	if float64(len(avifEncoded.data)) < float64(len(data))*(1-avifMinSavings) {
+		return avifEncoded.data, OutputAVIF, avifEncoder + " versus " + encoder, nil
+	}
+	return data, format, encoder + " versus " + avifEncoder, nil
+}
+
+// encodeConfigured encodes an image in the configured output format. In auto mode the image is
+// encoded as both JPEG and WebP, and the smaller result is returned.
+func (o *Optimizer) encodeConfigured(img image.Image) (data []byte, format, encoder string, err error) {
+	switch o.Config.OutputFormat {
+	case OutputWebP:
+		data, err = o.encodeWebP(img)
+		return data, OutputWebP, describeEncoding(OutputWebP, data), err
+	case OutputAuto:
+		jpegData, err := o.encodeJPEG(img)
+		if err != nil {
+			return nil, "", "", err
+		}
+		webpData, err := o.encodeWebP(img)
+		if err != nil {
+			return nil, "", "", err
+		}
+		jpegEncoder, webpEncoder := describeEncoding(OutputJPEG, jpegData), describeEncoding(OutputWebP, webpData)
+		if len(webpData) < len(jpegData) {
+			return webpData, OutputWebP, webpEncoder + " versus " + jpegEncoder, nil
+		}
+		return jpegData, OutputJPEG, jpegEncoder + " versus " + webpEncoder, nil
+	default:
+		data, err = o.encodeJPEG(img)
+		return data, OutputJPEG, describeEncoding(OutputJPEG, data), err
+	}
+}
+
+// describeEncoding returns a short description of an encoding result, such as "webp (42.17 KB)".
+func describeEncoding(format string, data []byte) string {
+	return fmt.Sprintf("%s (%s)", format, util.FormatBytes(int64(len(data))))
+}
+
+// encodeJPEG encodes an image as JPEG at the configured quality.
+// JPEG has no alpha channel, so transparent areas are first filled with the background color.
+func (o *Optimizer) encodeJPEG(img image.Image) ([]byte, error) {
+	img = o.flatten(img)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: o.Config.Quality}); err != nil {
+		return nil, types.NewValidationError("image", fmt.Sprintf("JPEG encoding failed: %v", err))
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten composites an image with transparent areas onto the configured background color.
+// Fully opaque images are returned as is.
+func (o *Optimizer) flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	background := o.Config.Background
+	if background == nil {
+		background = color.White
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+// encodeWebP encodes an image as lossy WebP at the configured quality.
+func (o *Optimizer) encodeWebP(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img, webp.Options{Quality: o.Config.Quality, Method: webp.DefaultMethod}); err != nil {
+		return nil, types.NewValidationError("image", fmt.Sprintf("WebP encoding failed: %v", err))
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeAVIF encodes an image as lossy AVIF at the configured quality.
+func (o *Optimizer) encodeAVIF(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := avif.Encode(&buf, img, avif.Options{Quality: o.Config.Quality, Speed: avif.DefaultSpeed}); err != nil {
+		return nil, types.NewValidationError("image", fmt.Sprintf("AVIF encoding failed: %v", err))
+	}
+	return buf.Bytes(), nil
 }
 
 // resizeImage scales an image to fit within max dimensions using Catmull-Rom.
@@ -148,7 +352,7 @@ func (o *Optimizer) resizeImage(sourceImage image.Image, maxWidth, maxHeight int
 
 // Process is the main entry point for image processing.
 func Process(imageData []byte, config Config) (*ProcessingResult, error) {
-	originalInfo, err := extractImageInfo(imageData)
+	originalInfo, err := extractImageInfo(imageData, config.MaxPixels)
 	if err != nil {
 		return nil, err
 	}
@@ -157,11 +361,40 @@ func Process(imageData []byte, config Config) (*ProcessingResult, error) {
 		return nil, err
 	}
 
-	if isAlreadyTargetSize(originalInfo, config) {
-		return createSkippedResult(imageData, originalInfo), nil
+	// Only the decoded image is trusted: an original with data after the end of the image may be a
+	// polyglot file, so it is always re-encoded instead of stored as is. HEIC originals are always
+	// re-encoded too, since browsers and Aeron cannot show them.
+	sanitized := hasTrailingData(imageData, originalInfo.Format)
+	reencode := sanitized || originalInfo.Format == "heic"
+	if reencode {
+		config.NotSmallerPolicy = NotSmallerReencode
+	}
+
+	var result *ProcessingResult
+	if encoder := skippedEncoder(originalInfo, config, reencode); encoder != "" {
+		result = createSkippedResult(imageData, originalInfo, encoder)
+	} else {
+		result, err = optimizeImageData(imageData, originalInfo, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Sanitized = sanitized
+	if config.StripMetadata {
+		stripResultMetadata(result)
+	}
+
+	if config.PerceptualHash {
+		// Hash the image as stored, so the hash matches one computed later from the database.
+		hash, err := HashImageData(result.Data, 0)
+		if err != nil {
+			return nil, types.NewValidationError("image", fmt.Sprintf("failed to decode image for hashing: %v", err))
+		}
+		result.Hash = hash
 	}
 
-	return optimizeImageData(imageData, originalInfo, config)
+	return result, nil
 }
 
 // validateImage checks format support and dimension requirements.
@@ -169,13 +402,24 @@ func validateImage(info *Info, config Config) error {
 	if err := util.ValidateImageFormat(info.Format); err != nil {
 		return err
 	}
+	if info.Format == "heic" && !config.EnableHEIC {
+		return types.NewValidationError("image", "HEIC images are not accepted (enable image.enable_heic)")
+	}
+	if config.MinSourceBytes > 0 && int64(info.Size) < config.MinSourceBytes {
+		return types.NewValidationError("image",
+			fmt.Sprintf("image file is too small: %d bytes (minimum %d bytes required)", info.Size, config.MinSourceBytes))
+	}
 	return validateImageDimensions(info, config)
 }
 
 // extractImageInfo decodes image metadata into an Info struct.
-func extractImageInfo(imageData []byte) (*Info, error) {
-	format, width, height, err := getImageInfo(imageData)
+func extractImageInfo(imageData []byte, maxPixels int64) (*Info, error) {
+	format, width, height, err := getImageInfo(imageData, maxPixels)
 	if err != nil {
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, err
+		}
 		return nil, types.NewValidationError("image", fmt.Sprintf("failed to get image information: %v", err))
 	}
 
@@ -199,32 +443,94 @@ func validateImageDimensions(info *Info, config Config) error {
 	return nil
 }
 
-// isAlreadyTargetSize returns true if image matches target dimensions exactly.
+// isAlreadyTargetSize returns true if image matches target dimensions exactly, after clamping.
 func isAlreadyTargetSize(info *Info, config Config) bool {
-	return info.Width == config.TargetWidth && info.Height == config.TargetHeight
+	width, height := config.resizeBounds()
+	return info.Width == width && info.Height == height
+}
+
+// convertsToWebP reports whether images may be re-encoded as WebP.
+func convertsToWebP(config Config) bool {
+	return config.OutputFormat == OutputWebP || config.OutputFormat == OutputAuto
+}
+
+// skippedEncoder returns why an image can be stored without encoding it, or an empty string if it
+// has to be optimized. Images that must be re-encoded, such as sanitized images, are never skipped.
+func skippedEncoder(info *Info, config Config, reencode bool) string {
+	if reencode {
+		return ""
+	}
+
+	width, height := config.resizeBounds()
+	switch {
+	case int64(info.Size) < config.SkipBelowBytes && info.Width <= width && info.Height <= height:
+		return "skipped (below threshold)"
+	// Re-encoding normalizes every image to the output format, so images already at target size are processed too.
+	// The same goes for WebP and AVIF output, which are likely to be smaller than the original.
+	case isAlreadyTargetSize(info, config) && config.NotSmallerPolicy != NotSmallerReencode && !convertsToWebP(config) && !config.EnableAVIF:
+		return "original (no optimization needed)"
+	default:
+		return ""
+	}
 }
 
 // createSkippedResult creates a result for images needing no optimization.
-func createSkippedResult(imageData []byte, originalInfo *Info) *ProcessingResult {
+func createSkippedResult(imageData []byte, originalInfo *Info, encoder string) *ProcessingResult {
 	return &ProcessingResult{
 		Data:      imageData,
 		Format:    originalInfo.Format,
-		Encoder:   "original (no optimization needed)",
+		Encoder:   encoder,
 		Original:  *originalInfo,
 		Optimized: *originalInfo,
 		Savings:   0,
 	}
 }
 
+// stripResultMetadata removes metadata from the image in result and notes this in its encoder description.
+// Re-encoded images carry no metadata, so this mainly affects originals that are stored as-is.
+func stripResultMetadata(result *ProcessingResult) {
+	data, stripped := stripMetadata(result.Data, result.Format)
+	if !stripped {
+		return
+	}
+	result.Data = data
+	result.Optimized.Size = len(data)
+	result.Savings = float64(result.Original.Size-len(data)) / float64(result.Original.Size) * 100
+	result.Encoder += " (metadata stripped)"
+}
+
+// adaptiveQualityRange is the source-to-target pixel ratio at which adaptive quality reaches MinQuality.
+const adaptiveQualityRange = 16.0
+
+// selectQuality returns the quality to encode an image with. In adaptive mode, sources up to the
+// target size use MaxQuality and larger sources step down logarithmically to MinQuality, since heavy
+// downscaling hides compression artifacts while small sources tend to grow at low quality settings.
+func selectQuality(info *Info, config Config) int {
+	if !config.AdaptiveQuality {
+		return config.Quality
+	}
+
+	low, high := min(config.MinQuality, config.MaxQuality), max(config.MinQuality, config.MaxQuality)
+	width, height := config.resizeBounds()
+	ratio := float64(info.Width) * float64(info.Height) / (float64(width) * float64(height))
+	if ratio <= 1 {
+		return high
+	}
+
+	position := min(math.Log2(ratio)/math.Log2(adaptiveQualityRange), 1)
+	return high - int(math.Round(position*float64(high-low)))
+}
+
 // optimizeImageData runs the optimization pipeline and returns processing results.
 func optimizeImageData(imageData []byte, originalInfo *Info, config Config) (*ProcessingResult, error) {
+	config.Quality = selectQuality(originalInfo, config)
 	optimizer := NewOptimizer(config)
 	optimizedData, optFormat, optEncoder, err := optimizer.OptimizeImage(imageData)
 	if err != nil {
 		return nil, types.NewValidationError("image", fmt.Sprintf("optimization failed: %v", err))
 	}
 
-	optimizedInfo, err := extractImageInfo(optimizedData)
+	optimizedInfo, err := extractImageInfo(optimizedData, 0) // Already bounded by the target dimensions
 	if err != nil {
 		optimizedInfo = &Info{
 			Format: optFormat,
@@ -234,11 +540,14 @@ func optimizeImageData(imageData []byte, originalInfo *Info, config Config) (*Pr
 		}
 	}
 
-	if len(optimizedData) >= len(imageData) {
+	if len(optimizedData) >= len(imageData) && config.NotSmallerPolicy != NotSmallerReencode {
+		if config.NotSmallerPolicy == NotSmallerReject {
+			return nil, types.NewValidationError("image", "optimized image is not smaller than the original")
+		}
 		return &ProcessingResult{
 			Data:      imageData,
 			Format:    originalInfo.Format,
-			Encoder:   "original (smaller than optimized version)",
+			Encoder:   optEncoder,
 			Original:  *originalInfo,
 			Optimized: *originalInfo,
 			Savings:   0,
@@ -254,5 +563,6 @@ func optimizeImageData(imageData []byte, originalInfo *Info, config Config) (*Pr
 		Original:  *originalInfo,
 		Optimized: *optimizedInfo,
 		Savings:   savings,
+		Quality:   config.Quality,
 	}, nil
 }
@@ -0,0 +1,138 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+)
+
+// stripMetadata removes EXIF, XMP, IPTC, and ICC metadata from JPEG, PNG, and WebP data without
+// re-encoding the image. It reports whether anything was removed; data in other formats, and data
+// that cannot be parsed, is returned unchanged.
+func stripMetadata(data []byte, format string) ([]byte, bool) {
+	var stripped []byte
+	switch format {
+	case "jpeg", "jpg":
+		stripped = stripJPEGMetadata(data)
+	case "png":
+		stripped = stripPNGMetadata(data)
+	case "webp":
+		stripped = stripWebPMetadata(data)
+	}
+	if stripped == nil || len(stripped) == len(data) {
+		return data, false
+	}
+	return stripped, true
+}
+
+// JPEG segments that carry metadata: APP1 (EXIF, XMP), APP2 (ICC profile), APP13 (IPTC), and comments.
+var jpegMetadataMarkers = []byte{0xE1, 0xE2, 0xED, 0xFE}
+
+// stripJPEGMetadata returns data without its metadata segments, or nil if data is not a valid JPEG.
+// Everything from the start of scan onwards is copied as is.
+func stripJPEGMetadata(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	out := append(make([]byte, 0, len(data)), data[:2]...)
+	for pos := 2; pos < len(data); {
+		if data[pos] != 0xFF || pos+1 >= len(data) {
+			return nil
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF: // fill byte
+			pos++
+			continue
+		case marker == 0xD9 || marker == 0xDA: // end of image, start of scan
+			return append(out, data[pos:]...)
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // markers without a length
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(data) {
+			return nil
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:pos+4]))
+		if end > len(data) {
+			return nil
+		}
+		if !slices.Contains(jpegMetadataMarkers, marker) {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return nil
+}
+
+// pngSignature is the fixed header of every PNG file.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// PNG chunks that carry metadata.
+var pngMetadataChunks = []string{"eXIf", "iCCP", "tEXt", "zTXt", "iTXt", "tIME"}
+
+// stripPNGMetadata returns data without its metadata chunks, or nil if data is not a valid PNG.
+func stripPNGMetadata(data []byte) []byte {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil
+	}
+
+	out := append(make([]byte, 0, len(data)), pngSignature...)
+	for pos := len(pngSignature); pos < len(data); {
+		if pos+8 > len(data) {
+			return nil
+		}
+		end := pos + 12 + int(binary.BigEndian.Uint32(data[pos:pos+4])) // length, type, data, CRC
+		if end > len(data) || end < pos {
+			return nil
+		}
+		if !slices.Contains(pngMetadataChunks, string(data[pos+4:pos+8])) {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return out
+}
+
+// WebP chunks that carry metadata, and the VP8X flags announcing them.
+var webpMetadataChunks = []string{"EXIF", "ICCP", "XMP "}
+
+const webpMetadataFlags = 0x20 | 0x08 | 0x04 // ICC profile, EXIF, XMP
+
+// stripWebPMetadata returns data without its metadata chunks, or nil if data is not a valid WebP.
+// The VP8X flags and RIFF size are updated to match.
+func stripWebPMetadata(data []byte) []byte {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil
+	}
+
+	out := append(make([]byte, 0, len(data)), data[:12]...)
+	for pos := 12; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil
+		}
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2 // chunks are padded to an even size
+		if end > len(data) || end < pos {
+			return nil
+		}
+
+		fourCC := string(data[pos : pos+4])
+		switch {
+		case slices.Contains(webpMetadataChunks, fourCC):
+		case fourCC == "VP8X" && size > 0:
+			start := len(out)
+			out = append(out, data[pos:end]...)
+			out[start+8] &^= webpMetadataFlags
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out
+}
@@ -0,0 +1,54 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math/bits"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/util"
+	"golang.org/x/image/draw"
+)
+
+// PerceptualHash returns a 64-bit difference hash (dHash) of img. Visually similar images
+// produce hashes with a small Hamming distance, regardless of their size, format, or compression.
+func PerceptualHash(img image.Image) uint64 {
+	const width, height = 9, 8
+
+	small := image.NewGray(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := range height {
+		for x := range width - 1 {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// HashImageData decodes encoded image data and returns its perceptual hash.
+// Images larger than maxPixels are rejected before decoding; 0 disables the limit.
+func HashImageData(data []byte, maxPixels int64) (uint64, error) {
+	if _, _, err := util.DecodeImageConfig(data, maxPixels); err != nil {
+		return 0, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	return PerceptualHash(img), nil
+}
+
+// HammingDistance returns the number of differing bits between two perceptual hashes.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// FormatHash returns the hexadecimal representation of a perceptual hash.
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
@@ -0,0 +1,33 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand/v2"
+)
+
+// SampleJPEG generates a JPEG test image of the given size. Gradients with added noise
+// make it compress like a photo rather than a flat graphic. The output is deterministic.
+func SampleJPEG(width, height int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for y := range height {
+		for x := range width {
+			noise := rng.IntN(32)
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8((x*255/max(width-1, 1) + noise) % 256),
+				G: uint8((y*255/max(height-1, 1) + noise) % 256),
+				B: uint8(((x+y)*255/max(width+height-2, 1) + noise) % 256),
+				A: 255,
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,173 @@
+// Package logbuffer keeps the most recent log records in memory for remote troubleshooting.
+package logbuffer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Entry is a log record as kept in the buffer.
+type Entry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Attrs   map[string]any `json:"attrs,omitempty"` // group members use dotted keys
+	level   slog.Level
+}
+
+// Buffer is a fixed-size ring buffer of log entries. It is safe for concurrent use.
+type Buffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int // position of the next write
+	full    bool
+}
+
+// New creates a Buffer that keeps the last size entries.
+func New(size int) *Buffer {
+	return &Buffer{entries: make([]Entry, size)}
+}
+
+// Capacity returns the maximum number of entries kept.
+func (b *Buffer) Capacity() int {
+	return len(b.entries)
+}
+
+// add stores an entry, overwriting the oldest one when the buffer is full.
+func (b *Buffer) add(entry Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Recent returns up to limit of the most recent entries at or above minLevel, oldest first.
+func (b *Buffer) Recent(minLevel slog.Level, limit int) []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count := b.next
+	if b.full {
+		count = len(b.entries)
+	}
+
+	result := make([]Entry, 0, min(limit, count))
+	for i := 1; i <= count && len(result) < limit; i++ {
+		entry := b.entries[(b.next-i+len(b.entries))%len(b.entries)]
+		if entry.level >= minLevel {
+			result = append(result, entry)
+		}
+	}
+
+	// Collected newest first; reverse to chronological order.
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// Handler is an slog.Handler that copies every handled record into a Buffer
+// before passing it on to the wrapped handler.
+type Handler struct {
+	next   slog.Handler
+	buffer *Buffer
+	attrs  map[string]any // attributes added with WithAttrs
+	prefix string         // dotted group path from WithGroup
+}
+
+// NewHandler wraps next so that its records are also kept in buffer.
+func NewHandler(next slog.Handler, buffer *Buffer) *Handler {
+	return &Handler{next: next, buffer: buffer}
+}
+
+// Enabled implements slog.Handler. Only records the wrapped handler accepts are buffered.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	entry := Entry{
+		Time:    record.Time,
+		Level:   record.Level.String(),
+		Message: record.Message,
+		level:   record.Level,
+	}
+	if len(h.attrs) > 0 || record.NumAttrs() > 0 {
+		entry.Attrs = make(map[string]any, len(h.attrs)+record.NumAttrs())
+		for key, value := range h.attrs {
+			entry.Attrs[key] = value
+		}
+		record.Attrs(func(attr slog.Attr) bool {
+			addAttr(entry.Attrs, h.prefix, attr)
+			return true
+		})
+	}
+	h.buffer.add(entry)
+
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = make(map[string]any, len(h.attrs)+len(attrs))
+	for key, value := range h.attrs {
+		clone.attrs[key] = value
+	}
+	for _, attr := range attrs {
+		addAttr(clone.attrs, h.prefix, attr)
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// addAttr stores attr in attrs under its dotted key, flattening groups.
+// Values are converted to types that encode well as JSON.
+func addAttr(attrs map[string]any, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			addAttr(attrs, groupPrefix, member)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+
+	key := prefix + attr.Key
+	switch value.Kind() {
+	case slog.KindDuration:
+		attrs[key] = value.Duration().String()
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			attrs[key] = err.Error()
+			return
+		}
+		attrs[key] = value.Any()
+	default:
+		attrs[key] = value.Any()
+	}
+}
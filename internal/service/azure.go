@@ -0,0 +1,130 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// azureService manages uploads and deletions of backup files to Azure Blob Storage.
+type azureService struct {
+	client    *azblob.Client
+	container string
+	prefix    string
+}
+
+// newAzureService creates an Azure Blob Storage client for backup synchronization.
+func newAzureService(cfg *config.AzureConfig) (*azureService, error) {
+	client, err := newAzureClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Azure sync enabled",
+		"account", cfg.AccountName,
+		"container", cfg.Container,
+		"endpoint", cfg.GetEndpoint(),
+		"prefix", cfg.GetPathPrefix())
+
+	return &azureService{
+		client:    client,
+		container: cfg.Container,
+		prefix:    cfg.GetPathPrefix(),
+	}, nil
+}
+
+// newAzureClient creates an Azure Blob Storage client using the shared account key from the configuration.
+func newAzureClient(cfg *config.AzureConfig) (*azblob.Client, error) {
+	credential, err := azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+	if err != nil {
+		return nil, types.NewConfigError("backup.azure.account_key", fmt.Sprintf("invalid Azure account key: %v", err))
+	}
+	client, err := azblob.NewClientWithSharedKeyCredential(cfg.GetEndpoint(), credential, nil)
+	if err != nil {
+		return nil, types.NewConfigError("backup.azure.endpoint", fmt.Sprintf("Azure client cannot be created: %v", err))
+	}
+	return client, nil
+}
+
+// upload transfers a backup file to Azure Blob Storage as a block blob.
+func (s *azureService) upload(ctx context.Context, filename, localPath string) (err error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return types.NewOperationError("Azure upload", fmt.Errorf("open file: %w", err))
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = types.NewOperationError("Azure upload", fmt.Errorf("close file: %w", closeErr))
+		}
+	}()
+
+	key := s.prefix + filename
+	start := time.Now()
+
+	if _, err := s.client.UploadFile(ctx, s.container, key, file, nil); err != nil {
+		return types.NewOperationError("Azure upload", err)
+	}
+
+	backupLog(backupPhaseS3Sync, filename).Info("Backup uploaded to Azure",
+		"key", key,
+		logKeyBackupDuration, time.Since(start).Milliseconds())
+
+	return nil
+}
+
+// delete removes a backup file from Azure Blob Storage. Blobs that no longer exist are ignored.
+func (s *azureService) delete(ctx context.Context, filename string) error {
+	key := s.prefix + filename
+
+	if _, err := s.client.DeleteBlob(ctx, s.container, key, nil); err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return types.NewOperationError("Azure delete", err)
+	}
+
+	slog.Info("Backup deleted from Azure", "key", key)
+	return nil
+}
+
+// list returns the blobs whose name starts with prefix.
+func (s *azureService) list(ctx context.Context, prefix string) ([]blobObject, error) {
+	pager := s.client.NewListBlobsFlatPager(s.container, &azblob.ListBlobsFlatOptions{
+		Prefix: to.Ptr(s.prefix + prefix),
+	})
+
+	var objects []blobObject
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, types.NewOperationError("Azure list", err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			obj := blobObject{key: strings.TrimPrefix(*item.Name, s.prefix)}
+			if item.Properties != nil && item.Properties.LastModified != nil {
+				obj.lastModified = *item.Properties.LastModified
+			}
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// checkAzureAccess verifies that the configured account key can access the backup container.
+func checkAzureAccess(ctx context.Context, cfg *config.AzureConfig) error {
+	client, err := newAzureClient(cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := client.ServiceClient().NewContainerClient(cfg.Container).GetProperties(ctx, nil); err != nil {
+		return types.NewOperationError("Azure container access", err)
+	}
+	return nil
+}
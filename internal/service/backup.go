@@ -2,9 +2,14 @@
 package service
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -16,6 +21,7 @@ import (
 	"sync"
 	"time"
 
+	"filippo.io/age"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/async"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/database"
@@ -28,14 +34,22 @@ type BackupService struct {
 	repo       *database.Repository
 	config     *config.Config
 	backupRoot *os.Root
-	s3         *s3Service // nil if S3 is disabled
+	storage    blobStorage     // nil if remote storage is disabled
+	recipients []age.Recipient // nil if encryption is disabled
+	identities []age.Identity  // nil if no identity file is configured
 	runner     *async.Runner
+	metrics    *metrics
 
-	pgDumpPath    string
-	pgRestorePath string
+	pgDumpPath      string
+	pgRestorePath   string
+	postCommandPath string // empty when backup.post_command is not set
 
 	statusMu sync.RWMutex
 	status   *BackupStatus
+	events   backupEvents
+
+	cancelMu sync.Mutex
+	cancel   context.CancelFunc // cancels the running backup, nil when none is running
 }
 
 // BackupStatus represents the status of the last backup operation.
@@ -46,16 +60,37 @@ type BackupStatus struct {
 	Success   bool          `json:"success"`
 	Error     string        `json:"error,omitempty"`
 	Filename  string        `json:"filename,omitempty"`
+	Command   []string      `json:"command,omitempty"` // pg_dump invocation; the password is passed via the environment and never included
 	S3Sync    *S3SyncStatus `json:"s3_sync,omitempty"`
+
+	PostCommand *PostCommandStatus      `json:"post_command,omitempty"`
+	Validation  *BackupValidationStatus `json:"validation,omitempty"` // only set when validation is asynchronous or skipped
 }
 
-// S3SyncStatus represents the status of S3 synchronization.
-type S3SyncStatus struct {
-	Synced bool   `json:"synced"`
+// Backup validation states reported in BackupValidationStatus.
+const (
+	BackupValidationPending = "pending"
+	BackupValidationValid   = "valid"
+	BackupValidationInvalid = "invalid"
+	BackupValidationSkipped = "skipped"
+)
+
+// BackupValidationStatus represents the outcome of validating a backup after it was reported done,
+// or that validation was skipped.
+type BackupValidationStatus struct {
+	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
 }
 
-// newBackupService creates a BackupService with resolved tool paths and optional S3 client.
+// S3SyncStatus represents the status of synchronization to the remote storage selected by backup.storage_backend.
+// It keeps its name for compatibility, but also reports GCS and Azure uploads.
+type S3SyncStatus struct {
+	Synced     bool   `json:"synced"`
+	Error      string `json:"error,omitempty"`
+	FailedPart int32  `json:"failed_part,omitempty"` // multipart upload part that could not be uploaded
+}
+
+// newBackupService creates a BackupService with resolved tool paths and optional remote storage.
 func newBackupService(repo *database.Repository, cfg *config.Config) (*BackupService, error) {
 	svc := &BackupService{
 		repo:   repo,
@@ -77,6 +112,21 @@ func newBackupService(repo *database.Repository, cfg *config.Config) (*BackupSer
 		}
 		svc.pgRestorePath = pgRestorePath
 
+		if cfg.Backup.PostCommand != "" {
+			postCommandPath, err := resolvePostCommand(cfg.Backup.PostCommand)
+			if err != nil {
+				return nil, err
+			}
+			svc.postCommandPath = postCommandPath
+		}
+
+		recipients, identities, err := parseEncryption(&cfg.Backup)
+		if err != nil {
+			return nil, err
+		}
+		svc.recipients = recipients
+		svc.identities = identities
+
 		backupPath := cfg.Backup.GetPath()
 		if err := os.MkdirAll(backupPath, 0o750); err != nil {
 			return nil, types.NewConfigError("backup.path", fmt.Sprintf("backup directory not accessible: %v", err))
@@ -87,13 +137,14 @@ func newBackupService(repo *database.Repository, cfg *config.Config) (*BackupSer
 			return nil, types.NewConfigError("backup.path", fmt.Sprintf("backup directory cannot be opened: %v", err))
 		}
 		svc.backupRoot = root
+		svc.removeStalePartialBackups()
 
-		// Initialize S3 backend if configured
-		s3svc, err := newS3Service(&cfg.Backup.S3)
+		// Initialize remote storage if configured
+		storage, err := newBlobStorage(&cfg.Backup)
 		if err != nil {
 			return nil, err
 		}
-		svc.s3 = s3svc
+		svc.storage = storage
 	}
 
 	return svc, nil
@@ -108,15 +159,23 @@ func (s *BackupService) Close() {
 
 // BackupRequest represents the request body for backup operations.
 type BackupRequest struct {
-	Compression int `json:"compression"`
+	Compression  int    `json:"compression"`
+	Format       string `json:"format"`        // custom (default) or plain
+	PostCompress string `json:"post_compress"` // gzip or zstd, only for plain-format backups
+	SchemaOnly   bool   `json:"schema_only"`   // dump only the table definitions, without data
+	DataOnly     bool   `json:"data_only"`     // dump only the data, without table definitions
 }
 
-// BackupInfo represents metadata about an existing backup file.
+// BackupInfo represents metadata about an existing backup file or per-table backup directory.
 type BackupInfo struct {
 	Filename      string    `json:"filename"`
+	Format        string    `json:"format"`                  // custom or plain
+	PostCompress  string    `json:"post_compress,omitempty"` // gzip or zstd, only set for post-compressed plain backups
+	Encrypted     bool      `json:"encrypted,omitempty"`     // encrypted with age, .age extension
 	Size          int64     `json:"size_bytes"`
 	SizeFormatted string    `json:"size"`
 	CreatedAt     time.Time `json:"created_at"`
+	Tables        int       `json:"tables,omitempty"` // number of table dumps, only set for per-table backups
 }
 
 // BackupListResponse represents the response for listing backups.
@@ -130,6 +189,53 @@ type BackupListResponse struct {
 
 var safeBackupFilenamePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-.]+$`)
 
+// partialBackupSuffix marks backup files that are still being written or validated.
+const partialBackupSuffix = ".partial"
+
+// tableSetSuffix marks per-table backups: a directory with one dump file per table.
+const tableSetSuffix = ".tables"
+
+// IsTableSet reports whether a backup name refers to a per-table backup directory.
+func IsTableSet(filename string) bool {
+	return strings.HasSuffix(filename, tableSetSuffix)
+}
+
+// Structured log keys shared by all backup lifecycle events, so log aggregators can parse them reliably.
+const (
+	logKeyBackupFilename = "backup_filename"
+	logKeyBackupFormat   = "backup_format"
+	logKeyBackupSize     = "backup_size_bytes"
+	logKeyBackupDuration = "backup_duration_ms"
+	logKeyBackupPhase    = "backup_phase"
+)
+
+// Backup lifecycle phases reported under logKeyBackupPhase.
+const (
+	backupPhaseStart       = "start"
+	backupPhaseDump        = "dump"
+	backupPhaseValidate    = "validate"
+	backupPhaseDone        = "done"
+	backupPhaseS3Sync      = "s3_sync"
+	backupPhasePostCommand = "post_command"
+	backupPhaseDelete      = "delete"
+	backupPhaseCleanup     = "cleanup"
+	backupPhaseRestore     = "restore"
+)
+
+// backupLog returns a logger carrying the standard backup fields for a lifecycle phase.
+// The filename is omitted when empty, for events that do not concern a single backup.
+func backupLog(phase, filename string) *slog.Logger {
+	logger := slog.With(logKeyBackupPhase, phase)
+	if filename != "" {
+		format, _ := detectBackupFormat(filename)
+		logger = logger.With(logKeyBackupFilename, filename, logKeyBackupFormat, format)
+	}
+	return logger
+}
+
+// maxConcurrentValidations limits how many backups ValidateAll checks at once.
+const maxConcurrentValidations = 4
+
 // resolveToolPath returns the absolute path to an external tool, checking custom paths first.
 func resolveToolPath(customPath, toolName string) (string, error) {
 	if customPath != "" {
@@ -154,21 +260,26 @@ func (s *BackupService) checkEnabled() error {
 	return nil
 }
 
-// validateBackupFilename ensures the filename has valid characters, expected prefix and .dump extension.
+// validateBackupFilename ensures the filename has valid characters, expected prefix and a backup or .tables extension.
 func validateBackupFilename(filename string) error {
 	if !safeBackupFilenamePattern.MatchString(filename) {
 		return types.NewValidationError("filename", "invalid filename")
 	}
-	if !strings.HasPrefix(filename, "aeron-backup-") || !strings.HasSuffix(filename, ".dump") {
+	if !strings.HasPrefix(filename, "aeron-backup-") || (!hasBackupExtension(filename) && !IsTableSet(filename)) {
 		return types.NewValidationError("filename", "not a valid backup file")
 	}
 	return nil
 }
 
-// buildPgDumpArgs constructs pg_dump command-line arguments for the given settings.
-func (s *BackupService) buildPgDumpArgs(compression int) []string {
-	return []string{
-		"--format=custom",
+// buildPgDumpArgs constructs pg_dump command-line arguments for a validated request.
+// Plain-format dumps are written uncompressed; post-compression is applied to the output instead.
+func (s *BackupService) buildPgDumpArgs(req BackupRequest) []string {
+	compression := req.Compression
+	if req.Format == BackupFormatPlain {
+		compression = 0
+	}
+	args := []string{
+		"--format=" + req.Format,
 		"--compress=" + strconv.Itoa(compression),
 		"--host=" + s.config.Database.Host,
 		"--port=" + s.config.Database.Port,
@@ -177,6 +288,30 @@ func (s *BackupService) buildPgDumpArgs(compression int) []string {
 		"--schema=" + s.config.Database.Schema,
 		"--no-password",
 	}
+	// Table names are validated as identifiers when the configuration is loaded.
+	for _, table := range s.config.Backup.ExcludeTables {
+		args = append(args, "--exclude-table="+s.config.Database.Schema+"."+table)
+	}
+	if req.SchemaOnly {
+		args = append(args, "--schema-only")
+	}
+	if req.DataOnly {
+		args = append(args, "--data-only")
+	}
+	return args
+}
+
+// validateRequest checks a backup request and returns it with the default compression level applied.
+func (s *BackupService) validateRequest(req BackupRequest) (BackupRequest, error) {
+	if req.SchemaOnly && req.DataOnly {
+		return req, types.NewValidationError("schema_only", "schema_only and data_only cannot be combined")
+	}
+	compression, err := s.compressionLevel(req.Compression)
+	if err != nil {
+		return req, err
+	}
+	req.Compression = compression
+	return s.validateFormat(req)
 }
 
 // compressionLevel returns a valid compression level (0-9), applying defaults and validation.
@@ -192,8 +327,34 @@ func (s *BackupService) compressionLevel(requested int) (int, error) {
 }
 
 // validateBackupFile checks backup file integrity using pg_restore --list.
+// Plain-format dumps cannot be listed by pg_restore and are checked by reading them instead.
+// Encrypted dumps are decrypted on the fly, which requires backup.encryption.identity_file.
 func (s *BackupService) validateBackupFile(ctx context.Context, filePath string) error {
-	cmd := exec.CommandContext(ctx, s.pgRestorePath, "--list", filePath)
+	format, postCompress := detectBackupFormat(filePath)
+	if format == BackupFormatCustom && !isEncryptedBackup(filePath) {
+		return s.listBackup(ctx, filePath, nil)
+	}
+
+	r, err := s.openBackupFile(filePath)
+	if err != nil {
+		return types.NewOperationError("backup validation", err)
+	}
+	defer r.Close()
+
+	if format == BackupFormatPlain {
+		return validatePlainDump(ctx, r, postCompress)
+	}
+	return s.listBackup(ctx, "", r)
+}
+
+// listBackup runs pg_restore --list on a custom-format dump, read from filePath or, when it is empty, from stdin.
+func (s *BackupService) listBackup(ctx context.Context, filePath string, stdin io.Reader) error {
+	args := []string{"--list"}
+	if filePath != "" {
+		args = append(args, filePath)
+	}
+	cmd := exec.CommandContext(ctx, s.pgRestorePath, args...)
+	cmd.Stdin = stdin
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		errMsg := strings.TrimSpace(string(output))
@@ -205,24 +366,80 @@ func (s *BackupService) validateBackupFile(ctx context.Context, filePath string)
 	return nil
 }
 
-// generateBackupFilename creates a timestamped filename with .dump extension.
-func generateBackupFilename() string {
-	timestamp := time.Now().Format("2006-01-02-150405")
-	return fmt.Sprintf("aeron-backup-%s.dump", timestamp)
+// generateBackupFilename creates a timestamped filename with the given extension.
+// The timestamp is in location, so the names of scheduled backups match their scheduled time.
+func generateBackupFilename(location *time.Location, extension string) string {
+	timestamp := time.Now().In(location).Format("2006-01-02-150405")
+	return "aeron-backup-" + timestamp + extension
+}
+
+// removePartialBackup deletes an unfinished backup file or directory, logging any failure.
+func (s *BackupService) removePartialBackup(name string) {
+	if err := s.backupRoot.RemoveAll(name); err != nil {
+		backupLog(backupPhaseCleanup, name).Warn("Failed to clean up partial backup", "error", err)
+	}
+}
+
+// removeStalePartialBackups deletes partial files left behind by an interrupted process.
+func (s *BackupService) removeStalePartialBackups() {
+	var stale []string
+	_ = s.walkBackups(func(name string, _ fs.DirEntry) {
+		if strings.HasSuffix(name, partialBackupSuffix) {
+			stale = append(stale, name)
+		}
+	})
+	for _, name := range stale {
+		backupLog(backupPhaseCleanup, filepath.Base(name)).Info("Removing stale partial backup")
+		s.removePartialBackup(filepath.FromSlash(name))
+	}
+}
+
+// pgDumpVersionMismatch is part of the error pg_dump reports when the server is newer than pg_dump itself.
+const pgDumpVersionMismatch = "server version mismatch"
+
+// pgDumpVersionsPattern extracts both version numbers from pg_dump's detail line, such as
+// "server version: 17.2 (Debian 17.2-1); pg_dump version: 16.4 (Debian 16.4-1)".
+var pgDumpVersionsPattern = regexp.MustCompile(`server version: (\d+(?:\.\d+)*)[^;]*; pg_dump version: (\d+(?:\.\d+)*)`)
+
+// versionMismatchMessage explains a pg_dump version mismatch, which pg_dump itself reports tersely,
+// and logs both versions when pg_dump included them.
+func versionMismatchMessage(logger *slog.Logger, output []byte) string {
+	match := pgDumpVersionsPattern.FindSubmatch(output)
+	if match == nil {
+		return "pg_dump is older than the database server: upgrade postgresql-client to the server's major version " +
+			"or point backup.pg_dump_path to a matching pg_dump"
+	}
+
+	serverVersion, pgDumpVersion := string(match[1]), string(match[2])
+	logger.Error("pg_dump is older than the database server", "server_version", serverVersion, "pg_dump_version", pgDumpVersion)
+	serverMajor, _, _ := strings.Cut(serverVersion, ".")
+	return fmt.Sprintf("pg_dump %s cannot back up PostgreSQL server %s: upgrade postgresql-client to version %s or later "+
+		"or point backup.pg_dump_path to a matching pg_dump", pgDumpVersion, serverVersion, serverMajor)
 }
 
 // executePgDump runs pg_dump and returns file info on success, cleaning up on failure.
-func (s *BackupService) executePgDump(ctx context.Context, pgDumpPath, filename, fullPath string, args []string) (os.FileInfo, time.Duration, error) {
+// When stdout is not nil it receives the dump, for invocations without --file.
+func (s *BackupService) executePgDump(ctx context.Context, pgDumpPath, filename, fullPath string, args []string, stdout io.Writer) (os.FileInfo, time.Duration, error) {
 	cmd := exec.CommandContext(ctx, pgDumpPath, args...)
 	cmd.Env = append(os.Environ(), "PGPASSWORD="+s.config.Database.Password)
 
+	logger := backupLog(backupPhaseDump, strings.TrimSuffix(filepath.Base(filename), partialBackupSuffix))
+
+	var combined bytes.Buffer
+	cmd.Stdout = &combined
+	cmd.Stderr = &combined
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+
 	start := time.Now()
-	output, err := cmd.CombinedOutput()
+	err := cmd.Run()
 	duration := time.Since(start)
+	output := combined.Bytes()
 
 	if err != nil {
 		if removeErr := s.backupRoot.Remove(filename); removeErr != nil && !os.IsNotExist(removeErr) {
-			slog.Warn("Failed to clean up failed backup", "filename", filename, "error", removeErr)
+			logger.Warn("Failed to clean up failed backup", "error", removeErr)
 		}
 
 		var errMsg string
@@ -231,13 +448,15 @@ func (s *BackupService) executePgDump(ctx context.Context, pgDumpPath, filename,
 			errMsg = fmt.Sprintf("backup timeout after %s (configure backup.timeout_minutes)", duration.Round(time.Second))
 		case ctx.Err() == context.Canceled:
 			errMsg = "backup cancelled"
+		case bytes.Contains(output, []byte(pgDumpVersionMismatch)):
+			errMsg = versionMismatchMessage(logger, output)
 		case len(output) > 0:
 			errMsg = strings.TrimSpace(string(output))
 		default:
 			errMsg = err.Error()
 		}
 
-		slog.Error("Backup failed", "error", err, "duration", duration, "output", string(output))
+		logger.Error("Backup failed", "error", err, logKeyBackupDuration, duration.Milliseconds(), "output", string(output))
 		return nil, 0, types.NewOperationError("create backup", errors.New(errMsg))
 	}
 
@@ -247,7 +466,7 @@ func (s *BackupService) executePgDump(ctx context.Context, pgDumpPath, filename,
 	}
 
 	if err := os.Chmod(fullPath, 0o600); err != nil {
-		slog.Warn("Could not set file permissions", "file", filename, "error", err)
+		logger.Warn("Could not set file permissions", "error", err)
 	}
 
 	return fileInfo, duration, nil
@@ -260,7 +479,7 @@ func (s *BackupService) Start(req BackupRequest) error {
 	if err := s.checkEnabled(); err != nil {
 		return err
 	}
-	if _, err := s.compressionLevel(req.Compression); err != nil {
+	if _, err := s.validateRequest(req); err != nil {
 		return err
 	}
 
@@ -274,6 +493,8 @@ func (s *BackupService) Start(req BackupRequest) error {
 	s.runner.Go(func() {
 		ctx, cancel := s.runner.Context(s.config.Backup.GetTimeout())
 		defer cancel()
+		s.setCancel(cancel)
+		defer s.setCancel(nil)
 
 		_ = s.execute(ctx, req) // Error tracked in status
 	})
@@ -288,11 +509,41 @@ func (s *BackupService) Run(ctx context.Context, req BackupRequest) error {
 	}
 	defer s.runner.Done()
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.setCancel(cancel)
+	defer s.setCancel(nil)
+
 	s.setStatusStarted()
 
 	return s.execute(ctx, req)
 }
 
+// IsRunning reports whether a backup is running. Follow-up work such as S3 sync does not count.
+func (s *BackupService) IsRunning() bool {
+	return s.runner.IsRunning()
+}
+
+// Cancel stops the running backup; it is recorded as failed with "backup cancelled".
+// Reports whether a backup was cancelled.
+func (s *BackupService) Cancel() bool {
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+
+	if s.cancel == nil {
+		return false
+	}
+	s.cancel()
+	return true
+}
+
+// setCancel records how to cancel the running backup.
+func (s *BackupService) setCancel(cancel context.CancelFunc) {
+	s.cancelMu.Lock()
+	s.cancel = cancel
+	s.cancelMu.Unlock()
+}
+
 // execute creates a database backup and synchronizes it to S3 if configured.
 // Note: Caller must call setStatusStarted() before invoking this method.
 func (s *BackupService) execute(ctx context.Context, req BackupRequest) error {
@@ -301,68 +552,311 @@ func (s *BackupService) execute(ctx context.Context, req BackupRequest) error {
 		return err
 	}
 
-	compression, err := s.compressionLevel(req.Compression)
+	req, err := s.validateRequest(req)
 	if err != nil {
 		s.setStatusDone(false, "", err.Error())
 		return err
 	}
 
-	filename := generateBackupFilename()
-	fullPath := filepath.Join(s.config.Backup.GetPath(), filename)
-	args := s.buildPgDumpArgs(compression)
+	extension := backupExtension(req.Format, req.PostCompress)
+	if s.config.Backup.PerTable {
+		extension = tableSetSuffix
+	}
+	if s.recipients != nil {
+		extension += encryptedBackupSuffix
+	}
+	filename := generateBackupFilename(s.config.Backup.Scheduler.GetLocation(), extension)
+
+	// With a subdir layout the backup is written to a date directory, such as 2024/01 for the monthly layout.
+	name := filepath.Join(backupDir(filename, s.config.Backup.GetSubdirLayout()), filename)
+	if err := s.backupRoot.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		err = types.NewOperationError("create backup", fmt.Errorf("create backup directory: %w", err))
+		s.setStatusDone(false, filename, err.Error())
+		return err
+	}
 
-	args = append(args, "--file="+fullPath)
+	// pg_dump writes to a partial file or directory that is only renamed once validated,
+	// so an interrupted backup never appears under a valid backup name.
+	partialName := name + partialBackupSuffix
+
+	// An encrypted backup is dumped and validated unencrypted, then encrypted into the partial file.
+	// The unencrypted dump is removed afterwards and never gets a final name.
+	dumpName := partialName
+	if s.recipients != nil {
+		dumpName = strings.TrimSuffix(name, encryptedBackupSuffix) + partialBackupSuffix
+	}
 
 	s.setStatusFilename(filename)
-	slog.Info("Backup started", "filename", filename)
+	backupLog(backupPhaseStart, filename).Info("Backup started")
 
-	fileInfo, duration, err := s.executePgDump(ctx, s.pgDumpPath, filename, fullPath, args)
+	var size int64
+	var duration time.Duration
+	if IsTableSet(filename) {
+		size, duration, err = s.dumpTables(ctx, filename, dumpName, req)
+	} else {
+		size, duration, err = s.dumpDatabase(ctx, filename, dumpName, req)
+	}
 	if err != nil {
+		s.removePartialBackup(dumpName)
+		s.removePartialBackup(partialName)
 		s.setStatusDone(false, filename, err.Error())
 		return err
 	}
-
-	// Validate backup file integrity
-	slog.Info("Validating backup", "filename", filename)
-
-	validateCtx, validateCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer validateCancel()
-
-	if err := s.validateBackupFile(validateCtx, fullPath); err != nil {
-		slog.Error("Backup validation failed", "filename", filename, "error", err)
+	if size, err = s.finalizeBackup(dumpName, partialName, name, size); err != nil {
 		s.setStatusDone(false, filename, err.Error())
 		return err
 	}
 
-	slog.Info("Backup validated", "filename", filename)
+	asyncValidation := s.recordValidationStatus(filename)
 
-	// Set S3 sync status before completing to prevent race condition in status reporting.
-	if s.s3 != nil {
-		s.setS3SyncStatus(false, "")
+	// Set S3 sync and post command status before completing to prevent race condition in status reporting.
+	if s.storage != nil {
+		s.setS3SyncStatus(false, nil)
+	}
+	if s.postCommandPath != "" && !asyncValidation {
+		s.setPostCommandStatus(filename, &PostCommandStatus{Status: PostCommandPending})
 	}
 
 	s.setStatusDone(true, filename, "")
-	slog.Info("Backup completed",
-		"filename", filename,
-		"size", util.FormatBytes(fileInfo.Size()),
-		"duration", duration.Round(time.Millisecond).String())
+	backupLog(backupPhaseDone, filename).Info("Backup completed",
+		logKeyBackupSize, size,
+		logKeyBackupDuration, duration.Milliseconds(),
+		"size", util.FormatBytes(size))
 
-	// Upload backup to S3 asynchronously
-	if s.s3 != nil {
-		s.runner.GoBackground(func() {
-			uploadCtx, cancel := context.WithTimeout(context.Background(), s.config.Backup.GetTimeout())
-			defer cancel()
+	s.dispatchFinishedBackup(filename, asyncValidation)
+	return nil
+}
 
-			if err := s.s3.upload(uploadCtx, filename, fullPath); err != nil {
-				slog.Error("S3 synchronization failed", "filename", filename, "error", err)
-				s.setS3SyncStatus(false, err.Error())
-			} else {
-				s.setS3SyncStatus(true, "")
+// finalizeBackup encrypts the dump when encryption is enabled and renames the partial backup to its final name.
+// It returns the size of the finished backup; on failure no partial file is left behind.
+func (s *BackupService) finalizeBackup(dumpName, partialName, name string, size int64) (int64, error) {
+	if s.recipients != nil {
+		var err error
+		size, err = s.encryptBackup(dumpName, partialName)
+		s.removePartialBackup(dumpName)
+		if err != nil {
+			s.removePartialBackup(partialName)
+			return 0, err
+		}
+	}
+
+	if err := s.backupRoot.Rename(partialName, name); err != nil {
+		s.removePartialBackup(partialName)
+		return 0, types.NewOperationError("create backup", fmt.Errorf("finalize backup file: %w", err))
+	}
+	return size, nil
+}
+
+// recordValidationStatus records how a finished backup is validated and reports whether
+// validation still has to run asynchronously.
+func (s *BackupService) recordValidationStatus(filename string) bool {
+	asyncValidation := s.config.Backup.GetValidate() && !s.validatesBeforeFinalize()
+	switch {
+	case !s.config.Backup.GetValidate():
+		backupLog(backupPhaseValidate, filename).Info("Backup validation skipped")
+		s.setValidationStatus(filename, BackupValidationSkipped, "")
+	case asyncValidation:
+		s.setValidationStatus(filename, BackupValidationPending, "")
+	default:
+		backupLog(backupPhaseValidate, filename).Info("Backup validated")
+	}
+	return asyncValidation
+}
+
+// dispatchFinishedBackup starts the work that follows a finished backup: remote storage sync,
+// the post command and cleanup of old backups.
+func (s *BackupService) dispatchFinishedBackup(filename string, asyncValidation bool) {
+	// With async validation, S3 sync and cleanup wait for the outcome, so an invalid backup
+	// is never uploaded and never replaces older backups under the retention rules.
+	if asyncValidation {
+		s.runner.GoBackground(func() {
+			if s.validateFinishedBackup(filename) {
+				s.syncToStorage(filename)
+				s.runPostCommand(filename)
+				s.cleanupOldBackups()
 			}
 		})
+		return
+	}
+
+	// Upload backup to remote storage asynchronously
+	if s.storage != nil {
+		s.runner.GoBackground(func() {
+			s.syncToStorage(filename)
+		})
+	}
+	if s.postCommandPath != "" {
+		s.runner.GoBackground(func() {
+			s.runPostCommand(filename)
+		})
 	}
 
 	s.cleanupOldBackups()
+}
+
+// syncToStorage uploads a finished backup to the remote storage and records the outcome in the status.
+func (s *BackupService) syncToStorage(filename string) {
+	if s.storage == nil {
+		return
+	}
+
+	uploadCtx, cancel := context.WithTimeout(context.Background(), s.config.Backup.GetTimeout())
+	defer cancel()
+
+	s.publishEvent(BackupEventS3Syncing, "")
+	if err := s.uploadToStorage(uploadCtx, filename); err != nil {
+		backupLog(backupPhaseS3Sync, filename).Error("Remote storage synchronization failed", "backend", s.config.Backup.GetStorageBackend(), "error", err)
+		s.setS3SyncStatus(false, err)
+		s.publishEvent(BackupEventS3Failed, "")
+	} else {
+		s.setS3SyncStatus(true, nil)
+		s.publishEvent(BackupEventS3Done, "")
+	}
+}
+
+// validateFinishedBackup validates a backup that has already been reported done and records the outcome.
+// An invalid backup is removed, just like a backup that fails validation before it is finalized.
+func (s *BackupService) validateFinishedBackup(filename string) bool {
+	ctx, cancel := s.runner.Context(s.config.Backup.GetTimeout())
+	defer cancel()
+
+	s.publishEvent(BackupEventValidating, "")
+	result := s.validateWithin(ctx, filename)
+	if !result.Valid {
+		backupLog(backupPhaseValidate, filename).Error("Backup validation failed", "error", result.Error)
+		if name, err := s.locateBackup(filename); err == nil {
+			if err := s.backupRoot.RemoveAll(name); err != nil {
+				backupLog(backupPhaseCleanup, filename).Warn("Failed to remove invalid backup", "error", err)
+			}
+			s.removeEmptyBackupDirs(name)
+		}
+		s.setValidationStatus(filename, BackupValidationInvalid, result.Error)
+		s.publishEvent(BackupEventInvalid, "")
+		return false
+	}
+
+	backupLog(backupPhaseValidate, filename).Info("Backup validated")
+	s.setValidationStatus(filename, BackupValidationValid, "")
+	s.publishEvent(BackupEventValidated, "")
+	return true
+}
+
+// dumpDatabase writes the schema to a single partial dump file and validates it.
+func (s *BackupService) dumpDatabase(ctx context.Context, filename, partialName string, req BackupRequest) (int64, time.Duration, error) {
+	partialPath := filepath.Join(s.config.Backup.GetPath(), partialName)
+
+	args := s.buildPgDumpArgs(req)
+	if req.PostCompress == "" {
+		args = append(args, "--file="+partialPath)
+	}
+	s.setStatusCommand(append([]string{s.pgDumpPath}, args...))
+
+	var fileInfo os.FileInfo
+	var duration time.Duration
+	var err error
+	if req.PostCompress == "" {
+		fileInfo, duration, err = s.executePgDump(ctx, s.pgDumpPath, partialName, partialPath, args, nil)
+	} else {
+		fileInfo, duration, err = s.executeCompressedPgDump(ctx, partialName, partialPath, args, req)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if s.validatesBeforeFinalize() {
+		if err := s.validateDump(filename, partialPath); err != nil {
+			return 0, 0, err
+		}
+	}
+	return fileInfo.Size(), duration, nil
+}
+
+// dumpTables writes each table of the schema to its own dump file inside a partial directory,
+// so a single table can be restored without the rest of the database.
+func (s *BackupService) dumpTables(ctx context.Context, filename, partialName string, req BackupRequest) (int64, time.Duration, error) {
+	tables, err := s.repo.GetTables(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	tables = slices.DeleteFunc(tables, func(table string) bool {
+		return slices.Contains(s.config.Backup.ExcludeTables, table)
+	})
+	if len(tables) == 0 {
+		return 0, 0, types.NewOperationError("create backup", errors.New("schema contains no tables"))
+	}
+
+	if err := s.backupRoot.Mkdir(partialName, 0o750); err != nil {
+		return 0, 0, types.NewOperationError("create backup", fmt.Errorf("create backup directory: %w", err))
+	}
+
+	var totalSize int64
+	var totalDuration time.Duration
+	for _, table := range tables {
+		name := filepath.Join(partialName, table+".dump")
+		fullPath := filepath.Join(s.config.Backup.GetPath(), name)
+
+		args := s.buildPgDumpArgs(req)
+		args = append(args, fmt.Sprintf("--table=%q.%q", s.config.Database.Schema, table), "--file="+fullPath)
+		s.setStatusCommand(append([]string{s.pgDumpPath}, args...))
+
+		fileInfo, duration, err := s.executePgDump(ctx, s.pgDumpPath, name, fullPath, args, nil)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if s.validatesBeforeFinalize() {
+			if err := s.validateDump(filename, fullPath); err != nil {
+				return 0, 0, err
+			}
+		}
+		totalSize += fileInfo.Size()
+		totalDuration += duration
+	}
+	return totalSize, totalDuration, nil
+}
+
+// validatesBeforeFinalize reports whether dump files are validated before the backup is finalized.
+// Encrypted backups are always validated before encryption, as validating them afterwards needs the private key.
+func (s *BackupService) validatesBeforeFinalize() bool {
+	return s.config.Backup.GetValidate() && (!s.config.Backup.AsyncValidation || s.recipients != nil)
+}
+
+// validateDump checks a freshly written dump file before the backup is finalized.
+func (s *BackupService) validateDump(filename, path string) error {
+	backupLog(backupPhaseValidate, filename).Info("Validating backup", "file", filepath.Base(path))
+	s.publishEvent(BackupEventValidating, filepath.Base(path))
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Backup.GetValidateTimeout())
+	defer cancel()
+
+	if err := s.validateBackupFile(ctx, path); err != nil {
+		backupLog(backupPhaseValidate, filename).Error("Backup validation failed", "file", filepath.Base(path), "error", err)
+		return err
+	}
+	return nil
+}
+
+// uploadToStorage uploads a backup to the remote storage. Per-table backups are uploaded file by file under a common key prefix.
+// Object keys do not include the subdirectories of backup.subdir_layout.
+func (s *BackupService) uploadToStorage(ctx context.Context, filename string) error {
+	name, err := s.locateBackup(filename)
+	if err != nil {
+		return err
+	}
+	fullPath := filepath.Join(s.config.Backup.GetPath(), name)
+	if !IsTableSet(filename) {
+		return s.storage.upload(ctx, filename, fullPath)
+	}
+
+	entries, err := fs.ReadDir(s.backupRoot.FS(), name)
+	if err != nil {
+		return types.NewOperationError("backup upload", err)
+	}
+	for _, entry := range entries {
+		if err := s.storage.upload(ctx, filename+"/"+entry.Name(), filepath.Join(fullPath, entry.Name())); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -381,10 +875,12 @@ func (s *BackupService) Status() *BackupStatus {
 }
 
 func (s *BackupService) setStatusStarted() {
-	s.statusMu.Lock()
-	defer s.statusMu.Unlock()
 	now := time.Now()
+	s.statusMu.Lock()
 	s.status = &BackupStatus{StartedAt: &now}
+	s.statusMu.Unlock()
+
+	s.publishEvent(BackupEventStarted, "")
 }
 
 func (s *BackupService) setStatusFilename(filename string) {
@@ -395,10 +891,17 @@ func (s *BackupService) setStatusFilename(filename string) {
 	}
 }
 
-func (s *BackupService) setStatusDone(success bool, filename, errMsg string) {
+func (s *BackupService) setStatusCommand(command []string) {
 	s.statusMu.Lock()
 	defer s.statusMu.Unlock()
+	if s.status != nil {
+		s.status.Command = command
+	}
+}
+
+func (s *BackupService) setStatusDone(success bool, filename, errMsg string) {
 	now := time.Now()
+	s.statusMu.Lock()
 	if s.status == nil {
 		s.status = &BackupStatus{StartedAt: &now}
 	}
@@ -408,13 +911,39 @@ func (s *BackupService) setStatusDone(success bool, filename, errMsg string) {
 	if filename != "" {
 		s.status.Filename = filename
 	}
+	s.statusMu.Unlock()
+
+	s.metrics.recordBackup(success)
+	if success {
+		s.publishEvent(BackupEventDone, "")
+	} else {
+		s.publishEvent(BackupEventFailed, "")
+	}
+}
+
+// setValidationStatus records the validation outcome, unless a newer backup has started in the meantime.
+func (s *BackupService) setValidationStatus(filename, status, errMsg string) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if s.status != nil && s.status.Filename == filename {
+		s.status.Validation = &BackupValidationStatus{Status: status, Error: errMsg}
+	}
 }
 
-func (s *BackupService) setS3SyncStatus(synced bool, errMsg string) {
+func (s *BackupService) setS3SyncStatus(synced bool, err error) {
+	status := &S3SyncStatus{Synced: synced}
+	if err != nil {
+		status.Error = err.Error()
+		var partErr *s3PartError
+		if errors.As(err, &partErr) {
+			status.FailedPart = partErr.part
+		}
+	}
+
 	s.statusMu.Lock()
 	defer s.statusMu.Unlock()
 	if s.status != nil {
-		s.status.S3Sync = &S3SyncStatus{Synced: synced, Error: errMsg}
+		s.status.S3Sync = status
 	}
 }
 
@@ -424,44 +953,46 @@ func (s *BackupService) List() (*BackupListResponse, error) {
 		return nil, err
 	}
 
-	backupPath := s.config.Backup.GetPath()
-	entries, err := os.ReadDir(backupPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return &BackupListResponse{
-				Backups:    []BackupInfo{},
-				TotalSize:  0,
-				TotalCount: 0,
-			}, nil
-		}
-		return nil, types.NewConfigError("backup.path", fmt.Sprintf("backup directory not readable: %v", err))
-	}
-
 	var backups []BackupInfo
 	var totalSize int64
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		name := entry.Name()
-		if !strings.HasPrefix(name, "aeron-backup-") || !strings.HasSuffix(name, ".dump") {
-			continue
+	err := s.walkBackups(func(name string, entry fs.DirEntry) {
+		// Partial files end in .partial and are skipped by the suffix check.
+		// Per-table backups are directories and count as a single backup.
+		filename := entry.Name()
+		if validateBackupFilename(filename) != nil || entry.IsDir() != IsTableSet(filename) {
+			return
 		}
 
 		info, err := entry.Info()
 		if err != nil {
-			continue
+			return
 		}
 
-		backups = append(backups, BackupInfo{
-			Filename:      name,
-			Size:          info.Size(),
-			SizeFormatted: util.FormatBytes(info.Size()),
-			CreatedAt:     info.ModTime(),
-		})
-		totalSize += info.Size()
+		backup := BackupInfo{
+			Filename:  filename,
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		}
+		backup.Format, backup.PostCompress = detectBackupFormat(filename)
+		backup.Encrypted = isEncryptedBackup(filename)
+		if entry.IsDir() {
+			backup.Size, backup.Tables = s.tableSetSize(name)
+		}
+		backup.SizeFormatted = util.FormatBytes(backup.Size)
+
+		backups = append(backups, backup)
+		totalSize += backup.Size
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &BackupListResponse{
+				Backups:    []BackupInfo{},
+				TotalSize:  0,
+				TotalCount: 0,
+			}, nil
+		}
+		return nil, types.NewConfigError("backup.path", fmt.Sprintf("backup directory not readable: %v", err))
 	}
 
 	slices.SortFunc(backups, func(a, b BackupInfo) int {
@@ -475,7 +1006,22 @@ func (s *BackupService) List() (*BackupListResponse, error) {
 	}, nil
 }
 
-// Delete removes a backup file from local storage and S3 if configured.
+// tableSetSize returns the combined size and number of table dumps in a per-table backup directory.
+func (s *BackupService) tableSetSize(name string) (size int64, tables int) {
+	entries, err := fs.ReadDir(s.backupRoot.FS(), name)
+	if err != nil {
+		return 0, 0
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !entry.IsDir() {
+			size += info.Size()
+			tables++
+		}
+	}
+	return size, tables
+}
+
+// Delete removes a backup file from local storage and remote storage if configured.
 func (s *BackupService) Delete(filename string) error {
 	if err := s.checkEnabled(); err != nil {
 		return err
@@ -485,24 +1031,36 @@ func (s *BackupService) Delete(filename string) error {
 		return err
 	}
 
-	if _, err := s.backupRoot.Stat(filename); os.IsNotExist(err) {
-		return types.NewNotFoundError("backup", filename)
+	name, err := s.locateBackup(filename)
+	if err != nil {
+		return err
 	}
 
-	if err := s.backupRoot.Remove(filename); err != nil {
+	remove := s.backupRoot.Remove
+	if IsTableSet(filename) {
+		remove = s.backupRoot.RemoveAll
+	}
+	if err := remove(name); err != nil {
 		return types.NewOperationError("delete backup", err)
 	}
+	s.removeEmptyBackupDirs(name)
 
-	slog.Info("Backup deleted", "filename", filename)
+	backupLog(backupPhaseDelete, filename).Info("Backup deleted")
 
-	// Delete from S3 asynchronously
-	if s.s3 != nil {
+	// Delete from remote storage asynchronously
+	if s.storage != nil {
 		s.runner.GoBackground(func() {
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 
-			if err := s.s3.delete(ctx, filename); err != nil {
-				slog.Warn("Failed to delete S3 backup", "filename", filename, "error", err)
+			deleteRemote := s.storage.delete
+			if IsTableSet(filename) {
+				deleteRemote = func(ctx context.Context, name string) error {
+					return deleteBlobPrefix(ctx, s.storage, name)
+				}
+			}
+			if err := deleteRemote(ctx, filename); err != nil {
+				backupLog(backupPhaseDelete, filename).Warn("Failed to delete remote backup", "error", err)
 			}
 		})
 	}
@@ -520,11 +1078,97 @@ func (s *BackupService) GetFilePath(filename string) (string, error) {
 		return "", err
 	}
 
-	if _, err := s.backupRoot.Stat(filename); os.IsNotExist(err) {
-		return "", types.NewNotFoundError("backup", filename)
+	name, err := s.locateBackup(filename)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.config.Backup.GetPath(), name), nil
+}
+
+// WriteArchive writes the given backups to w as a tar archive, gzip-compressed at the given level (1-9)
+// or uncompressed for level 0. Custom-format dumps are already compressed, so level 0 usually saves CPU
+// without producing a noticeably larger archive.
+func (s *BackupService) WriteArchive(w io.Writer, backups []BackupInfo, compression int) (err error) {
+	if err := s.checkEnabled(); err != nil {
+		return err
+	}
+	if compression < 0 || compression > 9 {
+		return types.NewValidationError("compress", fmt.Sprintf("invalid compression value: %d (use 0-9)", compression))
+	}
+
+	if compression > 0 {
+		gz, gzErr := gzip.NewWriterLevel(w, compression)
+		if gzErr != nil {
+			return types.NewOperationError("backup archive", gzErr)
+		}
+		defer func() {
+			if closeErr := gz.Close(); closeErr != nil && err == nil {
+				err = types.NewOperationError("backup archive", closeErr)
+			}
+		}()
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	for _, backup := range backups {
+		name, err := s.locateBackup(backup.Filename)
+		if err != nil {
+			return err
+		}
+		// Backups are archived under their filename, without the subdirectories of backup.subdir_layout.
+		if err := s.addToArchive(tw, name, backup.Filename); err != nil {
+			return types.NewOperationError("backup archive", fmt.Errorf("%s: %w", backup.Filename, err))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return types.NewOperationError("backup archive", err)
+	}
+	return nil
+}
+
+// addToArchive appends a backup file, or a per-table backup directory with its contents, to the tar archive
+// under archiveName.
+func (s *BackupService) addToArchive(tw *tar.Writer, name, archiveName string) error {
+	info, err := s.backupRoot.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(archiveName)
+
+	if info.IsDir() {
+		header.Name += "/"
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		entries, err := fs.ReadDir(s.backupRoot.FS(), filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := s.addToArchive(tw, filepath.Join(name, entry.Name()), header.Name+entry.Name()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := s.backupRoot.Open(name)
+	if err != nil {
+		return err
 	}
+	defer func() { _ = file.Close() }()
 
-	return filepath.Join(s.config.Backup.GetPath(), filename), nil
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
 }
 
 // ValidationResult represents the result of on-demand backup validation.
@@ -536,72 +1180,282 @@ type ValidationResult struct {
 
 // Validate checks backup file integrity using pg_restore --list.
 func (s *BackupService) Validate(filename string) (*ValidationResult, error) {
-	fullPath, err := s.GetFilePath(filename)
+	if _, err := s.GetFilePath(filename); err != nil {
+		return nil, err
+	}
+
+	result := s.validateWithin(context.Background(), filename)
+	return &result, nil
+}
+
+// ValidateAllRequest filters the backups checked by ValidateAll.
+type ValidateAllRequest struct {
+	MaxAgeDays int    `json:"max_age_days"` // only validate backups created within this many days, 0 for all
+	Format     string `json:"format"`       // only validate backups in this format (custom or plain), empty for all
+}
+
+// ValidationSummary contains the per-file results of validating multiple backups.
+type ValidationSummary struct {
+	Results []ValidationResult `json:"results"`
+	Total   int                `json:"total"`
+	Valid   int                `json:"valid"`
+	Invalid int                `json:"invalid"`
+}
+
+// ValidateAll checks the integrity of every backup file, running up to maxConcurrentValidations at once.
+// Files that have not been checked when ctx ends are reported as invalid with the context error.
+func (s *BackupService) ValidateAll(ctx context.Context, req ValidateAllRequest) (*ValidationSummary, error) {
+	if req.MaxAgeDays < 0 {
+		return nil, types.NewValidationError("max_age_days", "max_age_days cannot be negative")
+	}
+	switch req.Format {
+	case "", BackupFormatCustom, BackupFormatPlain:
+	default:
+		return nil, types.NewValidationError("format", fmt.Sprintf("invalid format: %s (use custom or plain)", req.Format))
+	}
+
+	list, err := s.List()
 	if err != nil {
 		return nil, err
 	}
 
-	result := &ValidationResult{
-		Filename: filename,
+	var cutoff time.Time
+	if req.MaxAgeDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -req.MaxAgeDays)
 	}
+	backups := slices.DeleteFunc(slices.Clone(list.Backups), func(b BackupInfo) bool {
+		return b.CreatedAt.Before(cutoff) || (req.Format != "" && b.Format != req.Format)
+	})
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	results := make([]ValidationResult, len(backups))
+	slots := make(chan struct{}, maxConcurrentValidations)
+	var wg sync.WaitGroup
+	for i, backup := range backups {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = s.validateWithin(ctx, backup.Filename)
+		}()
+	}
+	wg.Wait()
+
+	summary := &ValidationSummary{Results: results, Total: len(results)}
+	for _, result := range results {
+		if result.Valid {
+			summary.Valid++
+		} else {
+			summary.Invalid++
+		}
+	}
 
-	if err := s.validateBackupFile(ctx, fullPath); err != nil {
-		result.Valid = false
+	slog.Info("Backup validation sweep completed", "total", summary.Total, "valid", summary.Valid, "invalid", summary.Invalid)
+	return summary, nil
+}
+
+// validateWithin validates a single backup, bounded by both ctx and the per-file timeout.
+// For per-table backups every table dump is checked.
+func (s *BackupService) validateWithin(ctx context.Context, filename string) ValidationResult {
+	result := ValidationResult{Filename: filename}
+
+	name, err := s.locateBackup(filename)
+	if err != nil {
 		result.Error = err.Error()
-	} else {
-		result.Valid = true
+		return result
+	}
+
+	fullPath := filepath.Join(s.config.Backup.GetPath(), name)
+	files := []string{fullPath}
+	if IsTableSet(filename) {
+		entries, err := fs.ReadDir(s.backupRoot.FS(), name)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			files = append(files, filepath.Join(fullPath, entry.Name()))
+		}
 	}
 
-	return result, nil
+	for _, file := range files {
+		if err := s.validateFileWithin(ctx, file); err != nil {
+			result.Error = err.Error()
+			if len(files) > 1 {
+				result.Error = filepath.Base(file) + ": " + result.Error
+			}
+			return result
+		}
+	}
+	result.Valid = true
+	return result
+}
+
+// validateFileWithin validates one dump file, bounded by both ctx and the per-file timeout.
+func (s *BackupService) validateFileWithin(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Backup.GetValidateTimeout())
+	defer cancel()
+	return s.validateBackupFile(ctx, path)
 }
 
 // --- Background cleanup ---
 
-// cleanupOldBackups removes files exceeding retention days or max backup count.
+// cleanupOldBackups applies the retention rules to the local backups and, when enabled,
+// to the backups in remote storage.
 func (s *BackupService) cleanupOldBackups() {
+	s.cleanupLocalBackups()
+
+	if s.storage != nil && s.config.Backup.StorageApplyRetention() {
+		s.runner.GoBackground(s.pruneRemoteBackups)
+	}
+}
+
+// cleanupLocalBackups removes files exceeding retention days, max backup count, or the total size cap.
+// When retention tiers are configured they replace the age and count rules.
+// The most recent min_backups files are never removed, regardless of age or size.
+func (s *BackupService) cleanupLocalBackups() {
 	backups, err := s.List()
 	if err != nil {
-		slog.Error("Could not retrieve backups for cleanup", "error", err)
+		backupLog(backupPhaseCleanup, "").Error("Could not retrieve backups for cleanup", "error", err)
 		return
 	}
 
-	maxAge := time.Duration(s.config.Backup.GetRetentionDays()) * 24 * time.Hour
-	maxBackups := s.config.Backup.GetMaxBackups()
-	cutoff := time.Now().Add(-maxAge)
+	cfg := s.config.Backup
+	minBackups := cfg.GetMinBackups()
 
 	var deleted int
 
-	for _, backup := range backups.Backups {
-		if backup.CreatedAt.Before(cutoff) {
-			if err := s.Delete(backup.Filename); err != nil {
-				slog.Warn("Failed to delete backup (retention)", "filename", backup.Filename, "error", err)
-			} else {
+	if cfg.RetentionTiers.Enabled() {
+		keep := selectTieredBackups(backups.Backups, cfg.RetentionTiers)
+		for i, backup := range backups.Backups {
+			if i >= minBackups && !keep[backup.Filename] && s.deleteExpiredBackup(backup, "retention_tiers") {
 				deleted++
-				slog.Info("Old backup deleted (retention)", "filename", backup.Filename)
 			}
 		}
-	}
+	} else {
+		maxAge := time.Duration(cfg.GetRetentionDays()) * 24 * time.Hour
+		cutoff := time.Now().Add(-maxAge)
 
-	backups, err = s.List()
-	if err != nil {
-		slog.Error("Failed to retrieve backup list during cleanup", "error", err)
-		return
-	}
-	if len(backups.Backups) > maxBackups {
+		// Backups are sorted newest first, so the first minBackups entries are always kept.
+		for i, backup := range backups.Backups {
+			if i >= minBackups && backup.CreatedAt.Before(cutoff) && s.deleteExpiredBackup(backup, "retention") {
+				deleted++
+			}
+		}
+
+		backups, err = s.List()
+		if err != nil {
+			backupLog(backupPhaseCleanup, "").Error("Failed to retrieve backup list during cleanup", "error", err)
+			return
+		}
+		maxBackups := max(cfg.GetMaxBackups(), minBackups)
 		for i := maxBackups; i < len(backups.Backups); i++ {
-			if err := s.Delete(backups.Backups[i].Filename); err != nil {
-				slog.Warn("Failed to delete backup (max_backups)", "filename", backups.Backups[i].Filename, "error", err)
-			} else {
+			if s.deleteExpiredBackup(backups.Backups[i], "max_backups") {
 				deleted++
-				slog.Info("Old backup deleted (max_backups)", "filename", backups.Backups[i].Filename)
 			}
 		}
 	}
 
+	if maxTotalBytes := cfg.GetMaxTotalBytes(); maxTotalBytes > 0 {
+		n, err := s.enforceMaxTotalBytes(maxTotalBytes, minBackups)
+		deleted += n
+		if err != nil {
+			backupLog(backupPhaseCleanup, "").Error("Failed to retrieve backup list during cleanup", "error", err)
+			return
+		}
+	}
+
 	if deleted > 0 {
-		slog.Info("Backup cleanup completed", "deleted", deleted)
+		backupLog(backupPhaseCleanup, "").Info("Backup cleanup completed", "deleted", deleted)
+	}
+}
+
+// enforceMaxTotalBytes removes the oldest backups until their combined size fits within maxTotalBytes,
+// keeping the most recent minBackups files. It returns the number of removed backups.
+func (s *BackupService) enforceMaxTotalBytes(maxTotalBytes int64, minBackups int) (int, error) {
+	backups, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	totalSize := backups.TotalSize
+	for i := len(backups.Backups) - 1; i >= minBackups && totalSize > maxTotalBytes; i-- {
+		if s.deleteExpiredBackup(backups.Backups[i], "max_total_bytes") {
+			deleted++
+			totalSize -= backups.Backups[i].Size
+		}
 	}
+	return deleted, nil
+}
+
+// pruneRemoteBackups applies retention_days and max_backups to the backups stored in remote storage.
+// This also removes objects that no longer have a local copy, such as uploads from other hosts.
+func (s *BackupService) pruneRemoteBackups() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Backup.GetTimeout())
+	defer cancel()
+
+	cfg := s.config.Backup
+	maxAge := time.Duration(cfg.GetRetentionDays()) * 24 * time.Hour
+	minBackups := cfg.GetMinBackups()
+	maxBackups := max(cfg.GetMaxBackups(), minBackups)
+
+	deleted, err := pruneBlobs(ctx, s.storage, maxAge, maxBackups, minBackups)
+	if err != nil {
+		backupLog(backupPhaseCleanup, "").Error("Remote backup cleanup failed", "error", err)
+		return
+	}
+	if deleted > 0 {
+		backupLog(backupPhaseCleanup, "").Info("Remote backup cleanup completed", "deleted", deleted)
+	}
+}
+
+// deleteExpiredBackup removes a backup during cleanup and reports whether it was deleted.
+func (s *BackupService) deleteExpiredBackup(backup BackupInfo, reason string) bool {
+	if err := s.Delete(backup.Filename); err != nil {
+		backupLog(backupPhaseCleanup, backup.Filename).Warn("Failed to delete backup ("+reason+")", "error", err)
+		return false
+	}
+	backupLog(backupPhaseCleanup, backup.Filename).Info("Old backup deleted ("+reason+")", logKeyBackupSize, backup.Size, "size", backup.SizeFormatted)
+	return true
+}
+
+// selectTieredBackups returns the backups to keep under a grandfather-father-son policy.
+// Backups must be sorted newest first; the newest backup in each day, week, and month
+// bucket is kept until the configured count for that tier is reached.
+func selectTieredBackups(backups []BackupInfo, tiers config.RetentionTiersConfig) map[string]bool {
+	keep := make(map[string]bool)
+
+	keepNewestPerBucket := func(limit int, bucketKey func(time.Time) string) {
+		seen := make(map[string]bool)
+		for _, backup := range backups {
+			if len(seen) >= limit {
+				return
+			}
+			key := bucketKey(backup.CreatedAt)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keep[backup.Filename] = true
+		}
+	}
+
+	keepNewestPerBucket(tiers.Daily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepNewestPerBucket(tiers.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepNewestPerBucket(tiers.Monthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	return keep
 }
@@ -0,0 +1,114 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// encryptedBackupSuffix marks backups encrypted with age, appended to the regular backup extension.
+const encryptedBackupSuffix = ".age"
+
+// isEncryptedBackup reports whether a backup name refers to an age-encrypted backup, including partial files.
+func isEncryptedBackup(filename string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(filename, partialBackupSuffix), encryptedBackupSuffix)
+}
+
+// parseEncryption returns the age recipients and optional identities for backup.encryption.
+// It fails on malformed keys, so a misconfiguration is reported at startup instead of at the first backup.
+func parseEncryption(cfg *config.BackupConfig) ([]age.Recipient, []age.Identity, error) {
+	if !cfg.Encryption.Enabled {
+		return nil, nil, nil
+	}
+	if cfg.PerTable {
+		return nil, nil, types.NewConfigError("backup.encryption.enabled", "encryption is not supported for per-table backups")
+	}
+
+	recipients, err := age.ParseRecipients(strings.NewReader(cfg.Encryption.PublicKey))
+	if err != nil {
+		return nil, nil, types.NewConfigError("backup.encryption.public_key", fmt.Sprintf("invalid age public key: %v", err))
+	}
+
+	if cfg.Encryption.IdentityFile == "" {
+		return recipients, nil, nil
+	}
+	file, err := os.Open(cfg.Encryption.IdentityFile)
+	if err != nil {
+		return nil, nil, types.NewConfigError("backup.encryption.identity_file", fmt.Sprintf("identity file not readable: %v", err))
+	}
+	defer func() { _ = file.Close() }()
+
+	identities, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, nil, types.NewConfigError("backup.encryption.identity_file", fmt.Sprintf("invalid age identity file: %v", err))
+	}
+	return recipients, identities, nil
+}
+
+// encryptBackup encrypts the dump src into dst for the configured recipients and returns the encrypted size.
+// Both names are relative to the backup directory; src is left in place for the caller to remove.
+func (s *BackupService) encryptBackup(src, dst string) (int64, error) {
+	in, err := s.backupRoot.Open(src)
+	if err != nil {
+		return 0, types.NewOperationError("encrypt backup", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := s.backupRoot.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, types.NewOperationError("encrypt backup", err)
+	}
+	defer func() { _ = out.Close() }()
+
+	encrypter, err := age.Encrypt(out, s.recipients...)
+	if err != nil {
+		return 0, types.NewOperationError("encrypt backup", err)
+	}
+	if _, err := io.Copy(encrypter, in); err != nil {
+		return 0, types.NewOperationError("encrypt backup", err)
+	}
+	if err := encrypter.Close(); err != nil {
+		return 0, types.NewOperationError("encrypt backup", err)
+	}
+	if err := out.Close(); err != nil {
+		return 0, types.NewOperationError("encrypt backup", err)
+	}
+
+	info, err := s.backupRoot.Stat(dst)
+	if err != nil {
+		return 0, types.NewOperationError("encrypt backup", err)
+	}
+	return info.Size(), nil
+}
+
+// openBackupFile opens a dump file for reading, decrypting it when it is encrypted.
+func (s *BackupService) openBackupFile(path string) (io.ReadCloser, error) {
+	if isEncryptedBackup(path) && len(s.identities) == 0 {
+		return nil, errors.New("backup is encrypted and backup.encryption.identity_file is not set")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isEncryptedBackup(path) {
+		return file, nil
+	}
+
+	decrypter, err := age.Decrypt(file, s.identities...)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("decrypt backup: %w", err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{decrypter, file}, nil
+}
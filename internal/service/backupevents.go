@@ -0,0 +1,98 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// Backup event types, in the order a backup passes through them.
+const (
+	BackupEventStarted    = "started"
+	BackupEventValidating = "validating"
+	BackupEventDone       = "done"
+	BackupEventFailed     = "failed"
+	BackupEventValidated  = "validated" // asynchronous validation succeeded
+	BackupEventInvalid    = "invalid"   // asynchronous validation failed and the backup was removed
+	BackupEventS3Syncing  = "s3_syncing"
+	BackupEventS3Done     = "s3_done"
+	BackupEventS3Failed   = "s3_failed"
+
+	BackupEventPostCommandRunning = "post_command_running"
+	BackupEventPostCommandDone    = "post_command_done"
+	BackupEventPostCommandFailed  = "post_command_failed"
+)
+
+// backupEventBuffer is the number of events kept for a subscriber that does not keep up.
+const backupEventBuffer = 16
+
+// BackupEvent is a status transition of a backup together with the status right after it.
+type BackupEvent struct {
+	Type   string        `json:"type"`
+	Time   time.Time     `json:"time"`
+	File   string        `json:"file,omitempty"` // dump file being validated, for per-table backups one event per file
+	Status *BackupStatus `json:"status"`
+}
+
+// backupEvents fans out backup events to subscribers.
+// A subscriber that does not keep up misses events rather than holding up the backup.
+type backupEvents struct {
+	mu          sync.Mutex
+	subscribers map[chan BackupEvent]struct{}
+}
+
+// subscribe registers a new subscriber. The returned function unregisters it and closes the channel.
+func (e *backupEvents) subscribe() (<-chan BackupEvent, func()) {
+	ch := make(chan BackupEvent, backupEventBuffer)
+
+	e.mu.Lock()
+	if e.subscribers == nil {
+		e.subscribers = make(map[chan BackupEvent]struct{})
+	}
+	e.subscribers[ch] = struct{}{}
+	e.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.mu.Lock()
+			delete(e.subscribers, ch)
+			e.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish sends an event to all subscribers without blocking.
+func (e *backupEvents) publish(event BackupEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ch := range e.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeEvents returns a channel that receives backup status transitions as they happen.
+// The caller must call the returned function when it stops reading.
+func (s *BackupService) SubscribeEvents() (<-chan BackupEvent, func()) {
+	return s.events.subscribe()
+}
+
+// publishEvent sends an event of the given type with the current status to all subscribers.
+func (s *BackupService) publishEvent(eventType, file string) {
+	status := s.Status()
+	if eventType == BackupEventDone || eventType == BackupEventFailed {
+		status.Running = false // the runner is only released once the backup function has returned
+	}
+
+	s.events.publish(BackupEvent{
+		Type:   eventType,
+		Time:   time.Now(),
+		File:   file,
+		Status: status,
+	})
+}
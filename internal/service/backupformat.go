@@ -0,0 +1,204 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// Backup formats selected by BackupRequest.Format.
+const (
+	BackupFormatCustom = "custom" // pg_dump custom format, compressed internally by pg_dump
+	BackupFormatPlain  = "plain"  // plain SQL, optionally compressed afterwards with PostCompress
+)
+
+// Post-compression methods for plain-format backups, selected by BackupRequest.PostCompress.
+const (
+	PostCompressGzip = "gzip"
+	PostCompressZstd = "zstd"
+)
+
+// backupExtensions maps each backup file extension to the format and post-compression it holds.
+var backupExtensions = []struct {
+	suffix       string
+	format       string
+	postCompress string
+}{
+	{".dump", BackupFormatCustom, ""},
+	{".sql", BackupFormatPlain, ""},
+	{".sql.gz", BackupFormatPlain, PostCompressGzip},
+	{".sql.zst", BackupFormatPlain, PostCompressZstd},
+}
+
+// plainDumpHeader is the comment pg_dump starts every plain-format dump with.
+var plainDumpHeader = []byte("--\n-- PostgreSQL database dump")
+
+// backupExtension returns the file extension for a backup in the given format and post-compression.
+func backupExtension(format, postCompress string) string {
+	for _, ext := range backupExtensions {
+		if ext.format == format && ext.postCompress == postCompress {
+			return ext.suffix
+		}
+	}
+	return ".dump"
+}
+
+// hasBackupExtension reports whether a filename ends in one of the backup file extensions.
+func hasBackupExtension(filename string) bool {
+	filename = strings.TrimSuffix(filename, encryptedBackupSuffix)
+	for _, ext := range backupExtensions {
+		if strings.HasSuffix(filename, ext.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectBackupFormat returns the format and post-compression of a backup from its filename.
+// Per-table backups, partial files and encrypted backups are recognized as well.
+func detectBackupFormat(filename string) (format, postCompress string) {
+	filename = strings.TrimSuffix(strings.TrimSuffix(filename, partialBackupSuffix), encryptedBackupSuffix)
+	for _, ext := range backupExtensions {
+		if strings.HasSuffix(filename, ext.suffix) {
+			return ext.format, ext.postCompress
+		}
+	}
+	return BackupFormatCustom, ""
+}
+
+// validateFormat checks the format and post-compression of a backup request and applies the default format.
+func (s *BackupService) validateFormat(req BackupRequest) (BackupRequest, error) {
+	switch req.Format {
+	case "":
+		req.Format = BackupFormatCustom
+	case BackupFormatCustom, BackupFormatPlain:
+	default:
+		return req, types.NewValidationError("format", fmt.Sprintf("invalid format: %s (use custom or plain)", req.Format))
+	}
+
+	switch req.PostCompress {
+	case "":
+	case PostCompressGzip, PostCompressZstd:
+		if req.Format != BackupFormatPlain {
+			return req, types.NewValidationError("post_compress", "post_compress is only supported for plain-format backups")
+		}
+	default:
+		return req, types.NewValidationError("post_compress", fmt.Sprintf("invalid post_compress value: %s (use gzip or zstd)", req.PostCompress))
+	}
+
+	if req.Format == BackupFormatPlain && s.config.Backup.PerTable {
+		return req, types.NewValidationError("format", "plain format is not supported for per-table backups")
+	}
+	return req, nil
+}
+
+// newPostCompressor wraps w in a compressor for the given method and compression level (1-9).
+func newPostCompressor(w io.Writer, method string, level int) (io.WriteCloser, error) {
+	switch method {
+	case PostCompressGzip:
+		return gzip.NewWriterLevel(w, level)
+	case PostCompressZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	default:
+		return nil, fmt.Errorf("unknown post-compression method: %s", method)
+	}
+}
+
+// newPostDecompressor wraps r in a decompressor for the given method.
+func newPostDecompressor(r io.Reader, method string) (io.ReadCloser, error) {
+	switch method {
+	case PostCompressGzip:
+		return gzip.NewReader(r)
+	case PostCompressZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unknown post-compression method: %s", method)
+	}
+}
+
+// executeCompressedPgDump runs a plain-format pg_dump and compresses its output into the partial file.
+func (s *BackupService) executeCompressedPgDump(ctx context.Context, partialName, partialPath string, args []string, req BackupRequest) (os.FileInfo, time.Duration, error) {
+	file, err := s.backupRoot.OpenFile(partialName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, 0, types.NewOperationError("create backup", err)
+	}
+	defer file.Close()
+
+	compressor, err := newPostCompressor(file, req.PostCompress, req.Compression)
+	if err != nil {
+		return nil, 0, types.NewOperationError("create backup", err)
+	}
+
+	_, duration, err := s.executePgDump(ctx, s.pgDumpPath, partialName, partialPath, args, compressor)
+	if closeErr := compressor.Close(); err == nil && closeErr != nil {
+		err = types.NewOperationError("create backup", fmt.Errorf("compress backup: %w", closeErr))
+	}
+	if err == nil {
+		if closeErr := file.Close(); closeErr != nil {
+			err = types.NewOperationError("create backup", fmt.Errorf("write backup file: %w", closeErr))
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	fileInfo, err := s.backupRoot.Stat(partialName)
+	if err != nil {
+		return nil, 0, types.NewOperationError("create backup", fmt.Errorf("backup file not found after creation: %w", err))
+	}
+	return fileInfo, duration, nil
+}
+
+// validatePlainDump checks that a plain-format dump starts with the pg_dump header and,
+// when post-compressed, decompresses completely.
+func validatePlainDump(ctx context.Context, r io.Reader, postCompress string) error {
+	if postCompress != "" {
+		decompressor, err := newPostDecompressor(r, postCompress)
+		if err != nil {
+			return types.NewOperationError("backup validation", fmt.Errorf("file is corrupt or unreadable: %w", err))
+		}
+		defer decompressor.Close()
+		r = decompressor
+	}
+
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(len(plainDumpHeader))
+	if err != nil || !bytes.Equal(header, plainDumpHeader) {
+		return types.NewOperationError("backup validation", errors.New("file is not a pg_dump plain-format dump"))
+	}
+
+	// Reading to the end verifies the checksums of compressed dumps.
+	if _, err := io.Copy(io.Discard, contextReader{ctx: ctx, r: buffered}); err != nil {
+		return types.NewOperationError("backup validation", fmt.Errorf("file is corrupt or unreadable: %w", err))
+	}
+	return nil
+}
+
+// contextReader is an io.Reader that stops once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read implements io.Reader.
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,141 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// Post command states reported in PostCommandStatus.
+const (
+	PostCommandPending   = "pending"
+	PostCommandRunning   = "running"
+	PostCommandSucceeded = "succeeded"
+	PostCommandFailed    = "failed"
+)
+
+// maxPostCommandOutput is the number of bytes of post command output kept in the status.
+// Longer output is cut at the start, as the end usually explains a failure.
+const maxPostCommandOutput = 4096
+
+// postCommandWaitDelay is how long the post command may keep its output open after it exits or is killed,
+// for example through a child process that inherited it.
+const postCommandWaitDelay = 5 * time.Second
+
+// PostCommandStatus represents the outcome of running backup.post_command for a finished backup.
+type PostCommandStatus struct {
+	Status   string `json:"status"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Output   string `json:"output,omitempty"` // combined stdout and stderr, at most the last 4 KB
+	Error    string `json:"error,omitempty"`
+}
+
+// resolvePostCommand returns the path to the executable configured as backup.post_command.
+// Names without a path separator are looked up in PATH.
+func resolvePostCommand(command string) (string, error) {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return "", types.NewConfigError("backup.post_command", fmt.Sprintf("post command not found or not executable: %s", command))
+	}
+	return path, nil
+}
+
+// runPostCommand runs backup.post_command with the path of a finished backup and records the outcome.
+// It runs after the backup is reported done, so a failing or hanging command never fails the backup.
+func (s *BackupService) runPostCommand(filename string) {
+	if s.postCommandPath == "" {
+		return
+	}
+
+	logger := backupLog(backupPhasePostCommand, filename)
+	timeout := s.config.Backup.GetPostCommandTimeout()
+	ctx, cancel := s.runner.Context(timeout)
+	defer cancel()
+
+	name, err := s.locateBackup(filename)
+	if err != nil {
+		logger.Error("Post command skipped", "error", err)
+		s.setPostCommandStatus(filename, &PostCommandStatus{Status: PostCommandFailed, Error: err.Error()})
+		s.publishEvent(BackupEventPostCommandFailed, "")
+		return
+	}
+	backupPath := filepath.Join(s.config.Backup.GetPath(), name)
+	if absPath, err := filepath.Abs(backupPath); err == nil {
+		backupPath = absPath
+	}
+
+	s.setPostCommandStatus(filename, &PostCommandStatus{Status: PostCommandRunning})
+	s.publishEvent(BackupEventPostCommandRunning, "")
+
+	output := &tailBuffer{limit: maxPostCommandOutput}
+	cmd := exec.CommandContext(ctx, s.postCommandPath, backupPath)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = postCommandWaitDelay
+
+	start := time.Now()
+	err = cmd.Run()
+	duration := time.Since(start)
+
+	status := &PostCommandStatus{Status: PostCommandSucceeded, Output: strings.TrimSpace(output.String())}
+	if cmd.ProcessState != nil {
+		exitCode := cmd.ProcessState.ExitCode()
+		status.ExitCode = &exitCode
+	}
+
+	if err != nil {
+		status.Status = PostCommandFailed
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			status.Error = fmt.Sprintf("post command timeout after %s (configure backup.post_command_timeout_seconds)", timeout)
+		case errors.Is(ctx.Err(), context.Canceled):
+			status.Error = "post command cancelled"
+		default:
+			status.Error = err.Error()
+		}
+		logger.Error("Post command failed", "error", status.Error, logKeyBackupDuration, duration.Milliseconds(), "output", status.Output)
+		s.setPostCommandStatus(filename, status)
+		s.publishEvent(BackupEventPostCommandFailed, "")
+		return
+	}
+
+	logger.Info("Post command completed", logKeyBackupDuration, duration.Milliseconds())
+	s.setPostCommandStatus(filename, status)
+	s.publishEvent(BackupEventPostCommandDone, "")
+}
+
+// setPostCommandStatus records the post command outcome, unless a newer backup has started in the meantime.
+func (s *BackupService) setPostCommandStatus(filename string, status *PostCommandStatus) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if s.status != nil && s.status.Filename == filename {
+		s.status.PostCommand = status
+	}
+}
+
+// tailBuffer is an io.Writer that keeps only the last limit bytes written to it.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+// Write implements io.Writer.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if excess := len(b.buf) - b.limit; excess > 0 {
+		b.buf = append(b.buf[:0], b.buf[excess:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the kept bytes.
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
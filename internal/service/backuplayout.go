@@ -0,0 +1,116 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// Backup directory layouts selected by backup.subdir_layout.
+const (
+	backupLayoutFlat    = "flat"    // all backups directly in the backup directory
+	backupLayoutDaily   = "daily"   // YYYY/MM/DD/
+	backupLayoutMonthly = "monthly" // YYYY/MM/
+)
+
+// backupLayouts lists every layout, so backups stay reachable after backup.subdir_layout is changed.
+var backupLayouts = []string{backupLayoutFlat, backupLayoutMonthly, backupLayoutDaily}
+
+// maxBackupDirDepth is the deepest level of date directories, as used by the daily layout.
+const maxBackupDirDepth = 3
+
+// backupDir returns the directory, relative to the backup directory, in which a backup belongs under layout.
+// The date is taken from the timestamp in the filename; names without one belong in the top directory.
+func backupDir(filename, layout string) string {
+	timestamp, ok := strings.CutPrefix(filename, "aeron-backup-")
+	if !ok || len(timestamp) < len("2006-01-02") {
+		return "."
+	}
+	date, err := time.Parse("2006-01-02", timestamp[:len("2006-01-02")])
+	if err != nil {
+		return "."
+	}
+
+	switch layout {
+	case backupLayoutDaily:
+		return date.Format("2006/01/02")
+	case backupLayoutMonthly:
+		return date.Format("2006/01")
+	default:
+		return "."
+	}
+}
+
+// locateBackup returns the path of a backup relative to the backup directory. The configured layout is tried
+// first, then the other layouts, so backups made before a layout change are still found.
+func (s *BackupService) locateBackup(filename string) (string, error) {
+	tried := make(map[string]bool, len(backupLayouts)+1)
+	for _, layout := range append([]string{s.config.Backup.GetSubdirLayout()}, backupLayouts...) {
+		name := filepath.Join(backupDir(filename, layout), filename)
+		if tried[name] {
+			continue
+		}
+		tried[name] = true
+		if _, err := s.backupRoot.Stat(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", types.NewNotFoundError("backup", filename)
+}
+
+// walkBackups calls fn for every entry in the backup directory and its date directories.
+// Per-table backup directories and partial directories are reported but not descended into.
+func (s *BackupService) walkBackups(fn func(name string, entry fs.DirEntry)) error {
+	return fs.WalkDir(s.backupRoot.FS(), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if name == "." {
+				return err
+			}
+			return nil // skip unreadable subdirectories
+		}
+		if name == "." {
+			return nil
+		}
+
+		if entry.IsDir() && !isBackupDateDir(name) {
+			fn(name, entry)
+			return fs.SkipDir
+		}
+		if !entry.IsDir() {
+			fn(name, entry)
+		}
+		return nil
+	})
+}
+
+// isBackupDateDir reports whether name is a YYYY, YYYY/MM or YYYY/MM/DD directory created by a subdir layout.
+func isBackupDateDir(name string) bool {
+	parts := strings.Split(name, "/")
+	if len(parts) > maxBackupDirDepth {
+		return false
+	}
+	for i, part := range parts {
+		want := 2
+		if i == 0 {
+			want = 4
+		}
+		if len(part) != want || strings.Trim(part, "0123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// removeEmptyBackupDirs removes the date directories above name once they are empty.
+func (s *BackupService) removeEmptyBackupDirs(name string) {
+	for dir := filepath.Dir(name); dir != "."; dir = filepath.Dir(dir) {
+		// Remove fails on directories that still contain backups, which ends the walk up.
+		if err := s.backupRoot.Remove(dir); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/image"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// Benchmark limits.
+const (
+	DefaultBenchmarkCount = 100
+	MaxBenchmarkCount     = 1000
+)
+
+// ImageBenchmark contains the throughput of the image optimizer on this host.
+type ImageBenchmark struct {
+	Count          int     `json:"count"`
+	Workers        int     `json:"workers"`
+	SampleSize     int     `json:"sample_size"`
+	GeneratedImage bool    `json:"generated_image"`
+	TotalMs        float64 `json:"total_ms"`
+	ImagesPerSec   float64 `json:"images_per_sec"`
+	P50Ms          float64 `json:"p50_ms"`
+	P95Ms          float64 `json:"p95_ms"`
+	AverageSavings float64 `json:"average_savings_percent"`
+}
+
+// BenchmarkImages runs the image optimizer count times over sample and reports throughput and latency.
+// Images are processed by as many workers as background image jobs use, so the result reflects bulk imports.
+// Without a sample, a photo-like image twice the target size is generated. Nothing is stored.
+func (s *MediaService) BenchmarkImages(ctx context.Context, count int, sample []byte) (*ImageBenchmark, error) {
+	if count == 0 {
+		count = DefaultBenchmarkCount
+	}
+	if count < 1 || count > MaxBenchmarkCount {
+		return nil, types.NewValidationError("count", fmt.Sprintf("must be between 1 and %d", MaxBenchmarkCount))
+	}
+
+	generated := len(sample) == 0
+	if generated {
+		var err error
+		sample, err = image.SampleJPEG(s.config.Image.TargetWidth*2, s.config.Image.TargetHeight*2)
+		if err != nil {
+			return nil, types.NewOperationError("generate benchmark image", err)
+		}
+	}
+
+	imgConfig := s.imageConfig()
+	if _, err := image.Process(sample, imgConfig); err != nil {
+		return nil, types.NewValidationError("image", fmt.Sprintf("processing failed: %v", err))
+	}
+
+	workers := min(s.config.Image.GetJobWorkers(), count)
+	durations := make([]time.Duration, count)
+	savings := make([]float64, count)
+	errs := make([]error, count)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				itemStart := time.Now()
+				result, err := image.Process(sample, imgConfig)
+				durations[i] = time.Since(itemStart)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				savings[i] = result.Savings
+			}
+		}()
+	}
+
+	cancelled := false
+	for i := 0; i < count && !cancelled; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			cancelled = true
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	total := time.Since(start)
+
+	if cancelled {
+		return nil, types.NewOperationError("benchmark images", context.Cause(ctx))
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, types.NewOperationError("benchmark images", err)
+		}
+	}
+
+	slices.Sort(durations)
+	var totalSavings float64
+	for _, saving := range savings {
+		totalSavings += saving
+	}
+
+	result := &ImageBenchmark{
+		Count:          count,
+		Workers:        workers,
+		SampleSize:     len(sample),
+		GeneratedImage: generated,
+		TotalMs:        durationMs(total),
+		ImagesPerSec:   float64(count) / total.Seconds(),
+		P50Ms:          durationMs(percentile(durations, 50)),
+		P95Ms:          durationMs(percentile(durations, 95)),
+		AverageSavings: totalSavings / float64(count),
+	}
+	slog.Info("Image benchmark completed", "count", count, "workers", workers, "imagesPerSec", result.ImagesPerSec)
+	return result, nil
+}
+
+// percentile returns the p-th percentile of sorted durations using the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
+
+// durationMs converts a duration to fractional milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,78 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jmoiron/sqlx"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// ConfigCheckResult describes the outcome of a single configuration check.
+type ConfigCheckResult struct {
+	Name    string
+	Skipped bool // the check does not apply to this configuration
+	Err     error
+}
+
+// CheckConfig verifies that the external dependencies referenced by the configuration are reachable,
+// without starting any services. Checks for disabled features are reported as skipped.
+func CheckConfig(ctx context.Context, db *sqlx.DB, cfg *config.Config) []ConfigCheckResult {
+	results := []ConfigCheckResult{
+		{Name: "database", Err: db.PingContext(ctx)},
+	}
+
+	backupEnabled := cfg.Backup.Enabled
+	for _, tool := range []struct{ name, customPath string }{
+		{"pg_dump", cfg.Backup.PgDumpPath},
+		{"pg_restore", cfg.Backup.PgRestorePath},
+	} {
+		result := ConfigCheckResult{Name: tool.name, Skipped: !backupEnabled}
+		if backupEnabled {
+			_, result.Err = resolveToolPath(tool.customPath, tool.name)
+		}
+		results = append(results, result)
+	}
+
+	postCommandResult := ConfigCheckResult{Name: "post_command", Skipped: !backupEnabled || cfg.Backup.PostCommand == ""}
+	if !postCommandResult.Skipped {
+		_, postCommandResult.Err = resolvePostCommand(cfg.Backup.PostCommand)
+	}
+	results = append(results, postCommandResult)
+
+	encryptionResult := ConfigCheckResult{Name: "encryption", Skipped: !backupEnabled || !cfg.Backup.Encryption.Enabled}
+	if !encryptionResult.Skipped {
+		_, _, encryptionResult.Err = parseEncryption(&cfg.Backup)
+	}
+	results = append(results, encryptionResult)
+
+	backend := cfg.Backup.GetStorageBackend()
+	storageResult := ConfigCheckResult{Name: backend, Skipped: !backupEnabled || !cfg.Backup.StorageEnabled()}
+	if !storageResult.Skipped {
+		switch backend {
+		case "gcs":
+			storageResult.Err = checkGCSAccess(ctx, &cfg.Backup.GCS)
+		case "azure":
+			storageResult.Err = checkAzureAccess(ctx, &cfg.Backup.Azure)
+		default:
+			storageResult.Err = checkS3Access(ctx, &cfg.Backup.S3)
+		}
+	}
+	results = append(results, storageResult)
+
+	return results
+}
+
+// checkS3Access verifies that the configured credentials can access the backup bucket.
+func checkS3Access(ctx context.Context, cfg *config.S3Config) error {
+	_, err := newS3Client(cfg).HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(cfg.Bucket),
+	})
+	if err != nil {
+		return types.NewOperationError("S3 bucket access", err)
+	}
+	return nil
+}
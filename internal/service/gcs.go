@@ -0,0 +1,143 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// gcsService manages uploads and deletions of backup files to Google Cloud Storage.
+type gcsService struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+// newGCSService creates a Google Cloud Storage client for backup synchronization.
+func newGCSService(cfg *config.GCSConfig) (*gcsService, error) {
+	client, err := newGCSClient(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("GCS sync enabled",
+		"bucket", cfg.Bucket,
+		"prefix", cfg.GetPathPrefix())
+
+	return &gcsService{
+		bucket: client.Bucket(cfg.Bucket),
+		prefix: cfg.GetPathPrefix(),
+	}, nil
+}
+
+// newGCSClient creates a Google Cloud Storage client using the service account key from the configuration.
+func newGCSClient(ctx context.Context, cfg *config.GCSConfig) (*storage.Client, error) {
+	client, err := storage.NewClient(ctx, option.WithAuthCredentialsFile(option.ServiceAccount, cfg.CredentialsFile))
+	if err != nil {
+		return nil, types.NewConfigError("backup.gcs.credentials_file", fmt.Sprintf("GCS client cannot be created: %v", err))
+	}
+	return client, nil
+}
+
+// upload transfers a backup file to Google Cloud Storage.
+// The CRC32C checksum reported by GCS is checked against the uploaded data.
+func (s *gcsService) upload(ctx context.Context, filename, localPath string) (err error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return types.NewOperationError("GCS upload", fmt.Errorf("open file: %w", err))
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = types.NewOperationError("GCS upload", fmt.Errorf("close file: %w", closeErr))
+		}
+	}()
+
+	key := s.prefix + filename
+	start := time.Now()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // aborts the upload if it does not complete
+
+	writer := s.bucket.Object(key).NewWriter(ctx)
+	checksum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if _, err := io.Copy(writer, io.TeeReader(file, checksum)); err != nil {
+		cancel()
+		_ = writer.Close()
+		return types.NewOperationError("GCS upload", err)
+	}
+	if err := writer.Close(); err != nil {
+		return types.NewOperationError("GCS upload", err)
+	}
+
+	if got, want := writer.Attrs().CRC32C, checksum.Sum32(); got != want {
+		if deleteErr := s.delete(context.WithoutCancel(ctx), filename); deleteErr != nil {
+			slog.Warn("Failed to remove corrupt GCS backup", "key", key, "error", deleteErr)
+		}
+		return types.NewOperationError("GCS upload", fmt.Errorf("integrity check failed: CRC32C %08x does not match expected %08x", got, want))
+	}
+
+	backupLog(backupPhaseS3Sync, filename).Info("Backup uploaded to GCS",
+		"key", key,
+		logKeyBackupDuration, time.Since(start).Milliseconds())
+
+	return nil
+}
+
+// delete removes a backup file from Google Cloud Storage. Objects that no longer exist are ignored.
+func (s *gcsService) delete(ctx context.Context, filename string) error {
+	key := s.prefix + filename
+
+	if err := s.bucket.Object(key).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return types.NewOperationError("GCS delete", err)
+	}
+
+	slog.Info("Backup deleted from GCS", "key", key)
+	return nil
+}
+
+// list returns the objects whose name starts with prefix.
+func (s *gcsService) list(ctx context.Context, prefix string) ([]blobObject, error) {
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: s.prefix + prefix})
+
+	var objects []blobObject
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, types.NewOperationError("GCS list", err)
+		}
+		objects = append(objects, blobObject{
+			key:          strings.TrimPrefix(attrs.Name, s.prefix),
+			lastModified: attrs.Updated,
+		})
+	}
+	return objects, nil
+}
+
+// checkGCSAccess verifies that the configured service account can access the backup bucket.
+func checkGCSAccess(ctx context.Context, cfg *config.GCSConfig) error {
+	client, err := newGCSClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	if _, err := client.Bucket(cfg.Bucket).Attrs(ctx); err != nil {
+		return types.NewOperationError("GCS bucket access", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// healthCache keeps the last database health report, so frequent health requests from dashboards
+// do not query the table statistics every time.
+type healthCache struct {
+	mu         sync.Mutex
+	health     *DatabaseHealth // nil until the first report, and after invalidation
+	refreshing bool            // a background refresh is running
+}
+
+// get returns the cached report and its age, or nil when there is none.
+func (c *healthCache) get() (*DatabaseHealth, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.health == nil {
+		return nil, 0
+	}
+	return c.health, time.Since(c.health.CheckedAt)
+}
+
+// set stores a freshly computed report.
+func (c *healthCache) set(health *DatabaseHealth) {
+	c.mu.Lock()
+	c.health = health
+	c.mu.Unlock()
+}
+
+// invalidate drops the cached report, so the next request computes a new one.
+func (c *healthCache) invalidate() {
+	c.mu.Lock()
+	c.health = nil
+	c.mu.Unlock()
+}
+
+// startRefresh reports whether the caller should refresh the report in the background.
+// Only one background refresh runs at a time; the caller must call endRefresh when done.
+func (c *healthCache) startRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refreshing {
+		return false
+	}
+	c.refreshing = true
+	return true
+}
+
+// endRefresh marks the background refresh as done.
+func (c *healthCache) endRefresh() {
+	c.mu.Lock()
+	c.refreshing = false
+	c.mu.Unlock()
+}
+
+// cachedHealth returns the cached health report if it may still be served, or nil when a new one must be computed.
+// Within maintenance.health_cache_seconds the report is served as is. After that it is served with Stale set,
+// while a background refresh computes a new one, until it is older than maintenance.health_max_stale_seconds.
+func (s *MaintenanceService) cachedHealth() *DatabaseHealth {
+	ttl := s.config.Maintenance.GetHealthCacheTTL()
+	if ttl == 0 {
+		return nil
+	}
+
+	cached, age := s.health.get()
+	switch {
+	case cached == nil:
+		return nil
+	case age < ttl:
+		return cached
+	case age >= s.config.Maintenance.GetHealthMaxStale():
+		return nil
+	}
+
+	if s.health.startRefresh() {
+		s.runner.GoBackground(func() {
+			defer s.health.endRefresh()
+			ctx, cancel := s.runner.Context(s.config.API.GetRequestTimeout())
+			defer cancel()
+
+			health, err := s.computeHealth(ctx)
+			if err != nil {
+				slog.Warn("Failed to refresh database health", "error", err)
+				return
+			}
+			s.health.set(health)
+		})
+	}
+
+	stale := *cached
+	stale.Stale = true
+	return &stale
+}
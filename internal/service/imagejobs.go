@@ -0,0 +1,332 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/async"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// Image job states.
+const (
+	ImageJobQueued    = "queued"
+	ImageJobRunning   = "running"
+	ImageJobCompleted = "completed"
+)
+
+// Image job item states.
+const (
+	ImageJobItemPending = "pending"
+	ImageJobItemSuccess = "success"
+	ImageJobItemFailed  = "failed"
+)
+
+// ImageJobService processes batches of image uploads in the background using a bounded worker pool.
+// Job state is kept in memory and discarded after the configured retention period.
+type ImageJobService struct {
+	media  *MediaService
+	config *config.Config
+	runner *async.Runner
+	slots  chan struct{} // limits concurrent image processing across all jobs
+
+	mu   sync.RWMutex
+	jobs map[string]*imageJob
+}
+
+// ImageJob represents the progress and per-item results of a batch image upload.
+type ImageJob struct {
+	ID        string             `json:"id"`
+	Status    string             `json:"status"`
+	Total     int                `json:"total"`
+	Done      int                `json:"done"`
+	Failed    int                `json:"failed"`
+	CreatedAt time.Time          `json:"created_at"`
+	StartedAt *time.Time         `json:"started_at,omitempty"`
+	EndedAt   *time.Time         `json:"ended_at,omitempty"`
+	Items     []ImageJobItemInfo `json:"items"`
+}
+
+// ImageJobItemInfo represents the result of a single upload within an image job.
+type ImageJobItemInfo struct {
+	EntityType     types.EntityType `json:"entity_type"`
+	ID             string           `json:"id"`
+	Status         string           `json:"status"`
+	OriginalSize   int              `json:"original_size,omitzero"`
+	OptimizedSize  int              `json:"optimized_size,omitzero"`
+	SavingsPercent float64          `json:"savings_percent,omitzero"`
+	Quality        int              `json:"quality,omitzero"`
+	Encoder        string           `json:"encoder,omitempty"`
+	Unchanged      bool             `json:"unchanged,omitzero"`
+	Reused         bool             `json:"reused,omitzero"`           // the image was downloaded and optimized for an earlier item with the same URL
+	DetectedFormat string           `json:"detected_format,omitempty"` // set with claimed_format when the declared format was wrong
+	ClaimedFormat  string           `json:"claimed_format,omitempty"`
+	Error          string           `json:"error,omitempty"`
+}
+
+// imageJob holds the public job state together with the pending upload parameters.
+type imageJob struct {
+	ImageJob
+	params []ImageUploadParams
+	urls   *urlImageCache
+}
+
+// urlImageCache shares the processed image of a URL between the items of a job that reference it,
+// so artwork used by many tracks is downloaded and optimized only once. All items of a job are
+// processed with the same image settings, so the URL alone identifies the result.
+type urlImageCache struct {
+	mu      sync.Mutex
+	entries map[string]*urlImageEntry
+}
+
+// urlImageEntry is the processed image of one URL.
+type urlImageEntry struct {
+	once    sync.Once
+	result  *processedImage
+	err     error
+	pending int // items that have not been processed yet; the entry is dropped when this reaches zero
+}
+
+// newURLImageCache creates a urlImageCache for the URLs that occur more than once in items.
+func newURLImageCache(items []ImageUploadParams) *urlImageCache {
+	counts := make(map[string]int)
+	for i := range items {
+		if items[i].ImageURL != "" {
+			counts[items[i].ImageURL]++
+		}
+	}
+
+	c := &urlImageCache{entries: make(map[string]*urlImageEntry)}
+	for url, count := range counts {
+		if count > 1 {
+			c.entries[url] = &urlImageEntry{pending: count}
+		}
+	}
+	return c
+}
+
+// process returns the processed image for params. For a shared URL, only the first call runs fn;
+// reused reports whether the result came from an earlier item.
+func (c *urlImageCache) process(params *ImageUploadParams, fn func(*ImageUploadParams) (*processedImage, error)) (result *processedImage, reused bool, err error) {
+	c.mu.Lock()
+	entry := c.entries[params.ImageURL]
+	c.mu.Unlock()
+
+	if entry == nil {
+		result, err = fn(params)
+		return result, false, err
+	}
+
+	reused = true
+	entry.once.Do(func() {
+		reused = false
+		entry.result, entry.err = fn(params)
+	})
+	return entry.result, reused, entry.err
+}
+
+// release marks an item with the given URL as processed, dropping the cached image after its last item.
+func (c *urlImageCache) release(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry := c.entries[url]; entry != nil {
+		entry.pending--
+		if entry.pending == 0 {
+			delete(c.entries, url)
+		}
+	}
+}
+
+// newImageJobService creates an ImageJobService that uploads images through the given MediaService.
+func newImageJobService(media *MediaService, cfg *config.Config) *ImageJobService {
+	return &ImageJobService{
+		media:  media,
+		config: cfg,
+		runner: async.New(),
+		slots:  make(chan struct{}, cfg.Image.GetJobWorkers()),
+		jobs:   make(map[string]*imageJob),
+	}
+}
+
+// Close cancels pending uploads and waits for running jobs to finish.
+func (s *ImageJobService) Close() {
+	s.runner.Close()
+}
+
+// Submit validates a batch of uploads and starts processing it in the background.
+func (s *ImageJobService) Submit(items []ImageUploadParams) (*ImageJob, error) {
+	if len(items) == 0 {
+		return nil, types.NewValidationError("items", "at least one item is required")
+	}
+	if err := CheckBatchSize(&s.config.API, "items", len(items)); err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if err := validateImageUploadParams(&items[i]); err != nil {
+			return nil, types.NewValidationError("items", fmt.Sprintf("item %d: %v", i, err))
+		}
+	}
+
+	id, err := newImageJobID()
+	if err != nil {
+		return nil, types.NewOperationError("create image job", err)
+	}
+
+	job := &imageJob{
+		ImageJob: ImageJob{
+			ID:        id,
+			Status:    ImageJobQueued,
+			Total:     len(items),
+			CreatedAt: time.Now(),
+			Items:     make([]ImageJobItemInfo, len(items)),
+		},
+		params: items,
+		urls:   newURLImageCache(items),
+	}
+	for i := range items {
+		job.Items[i] = ImageJobItemInfo{
+			EntityType: items[i].EntityType,
+			ID:         items[i].ID,
+			Status:     ImageJobItemPending,
+		}
+	}
+
+	s.mu.Lock()
+	s.purgeExpiredLocked()
+	s.jobs[id] = job
+	snapshot := job.snapshot()
+	s.mu.Unlock()
+
+	slog.Info("Image job submitted", "job", id, "items", len(items))
+	s.runner.GoBackground(func() {
+		s.run(job)
+	})
+
+	return snapshot, nil
+}
+
+// Get returns the current state of an image job.
+func (s *ImageJobService) Get(id string) (*ImageJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpiredLocked()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, types.NewNotFoundError("image job", id)
+	}
+	return job.snapshot(), nil
+}
+
+// run processes all items of a job, bounded by the shared worker slots.
+func (s *ImageJobService) run(job *imageJob) {
+	s.mu.Lock()
+	now := time.Now()
+	job.Status = ImageJobRunning
+	job.StartedAt = &now
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := range job.params {
+		s.slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-s.slots }()
+			s.processItem(job, i)
+		}()
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	now = time.Now()
+	job.Status = ImageJobCompleted
+	job.EndedAt = &now
+	job.params = nil
+	done, failed := job.Done, job.Failed
+	s.mu.Unlock()
+
+	slog.Info("Image job completed", "job", job.ID, "done", done, "failed", failed)
+	s.media.refreshStatisticsSoon()
+}
+
+// processItem uploads a single image and records its result on the job.
+func (s *ImageJobService) processItem(job *imageJob, index int) {
+	ctx, cancel := s.runner.Context(s.config.API.GetRequestTimeout())
+	defer cancel()
+
+	var result *ImageUploadResult
+	var reused bool
+	var err error
+	if ctx.Err() != nil {
+		err = fmt.Errorf("job cancelled: %w", context.Cause(ctx))
+	} else {
+		result, err = s.media.uploadImage(ctx, &job.params[index], func(params *ImageUploadParams) (*processedImage, error) {
+			processed, fromCache, err := job.urls.process(params, s.media.processUploadImage)
+			reused = fromCache
+			return processed, err
+		})
+	}
+	job.urls.release(job.params[index].ImageURL)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := &job.Items[index]
+	job.Done++
+	job.params[index].ImageData = nil // Release memory as soon as the item is processed
+
+	if err != nil {
+		job.Failed++
+		item.Status = ImageJobItemFailed
+		item.Error = err.Error()
+		return
+	}
+
+	item.Status = ImageJobItemSuccess
+	item.OriginalSize = result.OriginalSize
+	item.OptimizedSize = result.OptimizedSize
+	item.SavingsPercent = result.SizeReductionPercent
+	item.Quality = result.Quality
+	item.Encoder = result.Encoder
+	item.Unchanged = result.Unchanged
+	item.Reused = reused
+	if result.FormatMismatch() {
+		item.DetectedFormat = result.DetectedFormat
+		item.ClaimedFormat = result.ClaimedFormat
+	}
+}
+
+// purgeExpiredLocked removes completed jobs older than the retention period. Caller must hold s.mu.
+func (s *ImageJobService) purgeExpiredLocked() {
+	cutoff := time.Now().Add(-s.config.Image.GetJobRetention())
+	for id, job := range s.jobs {
+		if job.EndedAt != nil && job.EndedAt.Before(cutoff) {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// snapshot returns a copy of the public job state that is safe to use without locking.
+func (j *imageJob) snapshot() *ImageJob {
+	job := j.ImageJob
+	job.Items = append([]ImageJobItemInfo(nil), j.Items...)
+	return &job
+}
+
+// newImageJobID generates a random identifier for an image job.
+func newImageJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -21,6 +21,7 @@ type MaintenanceService struct {
 	runner   *async.Runner
 	statusMu sync.RWMutex
 	status   *MaintenanceStatus
+	health   healthCache
 }
 
 // MaintenanceStatus tracks the progress of an async maintenance operation.
@@ -63,7 +64,23 @@ type DatabaseHealth struct {
 	Tables           []TableHealth `json:"tables"`
 	NeedsMaintenance bool          `json:"needs_maintenance"`
 	Recommendations  []string      `json:"recommendations"`
+	Warnings         []string      `json:"warnings"`
+	ConnectionPool   *PoolHealth   `json:"connection_pool,omitempty"`
 	CheckedAt        time.Time     `json:"checked_at"`
+	Stale            bool          `json:"stale,omitempty"` // served from the cache after maintenance.health_cache_seconds; a refresh is running
+}
+
+// PoolHealth compares the application's connection pool with the connection limit of the server.
+type PoolHealth struct {
+	MaxOpenConns         int      `json:"max_open_conns"`
+	HealthCheckConns     int      `json:"health_check_conns"`
+	OpenConnections      int      `json:"open_connections"`
+	InUse                int      `json:"in_use"`
+	Idle                 int      `json:"idle"`
+	WaitCount            int64    `json:"wait_count"`
+	WaitDuration         string   `json:"wait_duration"`
+	ServerMaxConnections int      `json:"server_max_connections"`
+	Recommendations      []string `json:"recommendations"`
 }
 
 // TableHealth represents health statistics for a single table.
@@ -93,8 +110,10 @@ type TableHealth struct {
 
 // VacuumOptions configures vacuum operation parameters.
 type VacuumOptions struct {
-	Tables  []string // Tables lists the tables to vacuum; auto-selects when empty
-	Analyze bool     // Analyze runs ANALYZE after VACUUM completes
+	Tables    []string // Tables lists the tables to vacuum; auto-selects when empty
+	Analyze   bool     // Analyze runs ANALYZE after VACUUM completes
+	Full      bool     // Full rewrites each table with VACUUM FULL to return free space to the operating system
+	Confirmed bool     // Confirmed allows VACUUM FULL when its disk space check asks for confirmation
 }
 
 // MaintenanceResult represents the result of a maintenance operation (vacuum or analyze) on a single table.
@@ -106,6 +125,7 @@ type MaintenanceResult struct {
 	DeadTupleRatio float64 `json:"dead_tuple_ratio_before"`
 	Duration       string  `json:"duration,omitempty"`
 	Analyzed       bool    `json:"analyzed"`
+	LockTimeout    bool    `json:"lock_timeout,omitempty"` // the table was locked by another session; safe to retry later
 	Skipped        bool    `json:"skipped,omitempty"`
 	SkippedReason  string  `json:"skipped_reason,omitempty"`
 }
@@ -147,37 +167,72 @@ type maintenanceContext struct {
 
 // --- Health operations ---
 
-// GetHealth retrieves comprehensive database health information.
+// GetHealth retrieves comprehensive database health information, from the cache when maintenance.health_cache_seconds is set.
+// Failures of individual queries are reported as warnings; an error is only returned when no information could be collected.
 func (s *MaintenanceService) GetHealth(ctx context.Context) (*DatabaseHealth, error) {
+	if health := s.cachedHealth(); health != nil {
+		return health, nil
+	}
+
+	health, err := s.computeHealth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.Maintenance.GetHealthCacheTTL() > 0 {
+		s.health.set(health)
+	}
+	return health, nil
+}
+
+// computeHealth queries the database for a new health report.
+func (s *MaintenanceService) computeHealth(ctx context.Context) (*DatabaseHealth, error) {
 	schema := s.repo.Schema()
 	health := &DatabaseHealth{
-		DatabaseName: s.config.Database.Name,
-		SchemaName:   schema,
-		CheckedAt:    time.Now(),
+		DatabaseName:    s.config.Database.Name,
+		SchemaName:      schema,
+		Tables:          []TableHealth{},
+		Recommendations: []string{},
+		Warnings:        []string{},
+		CheckedAt:       time.Now(),
 	}
 
 	var version string
-	if err := s.repo.DB().GetContext(ctx, &version, "SELECT version()"); err == nil {
+	versionErr := s.repo.DB().GetContext(ctx, &version, "SELECT version()")
+	if versionErr != nil {
+		health.Warnings = append(health.Warnings, types.NewOperationError("get database version", versionErr).Error())
+	} else {
 		health.DatabaseVersion = version
 	}
 
-	dbSize, dbSizeRaw, err := s.getDatabaseSize(ctx)
-	if err != nil {
-		return nil, types.NewOperationError("get database size", err)
+	dbSize, dbSizeRaw, sizeErr := s.getDatabaseSize(ctx)
+	if sizeErr != nil {
+		health.Warnings = append(health.Warnings, types.NewOperationError("get database size", sizeErr).Error())
+	} else {
+		health.DatabaseSize = dbSize
+		health.DatabaseSizeRaw = dbSizeRaw
 	}
-	health.DatabaseSize = dbSize
-	health.DatabaseSizeRaw = dbSizeRaw
 
-	tables, err := s.getTableHealth(ctx)
-	if err != nil {
-		return nil, types.NewOperationError("get table statistics", err)
+	tables, tablesErr := s.getTableHealth(ctx)
+	if tablesErr != nil {
+		health.Warnings = append(health.Warnings, tablesErr.Error())
+	} else {
+		health.Tables = tables
+		health.Recommendations = s.generateRecommendations(tables)
 	}
-	health.Tables = tables
 
-	health.Recommendations = s.generateRecommendations(tables)
+	if versionErr != nil && sizeErr != nil && tablesErr != nil {
+		return nil, tablesErr
+	}
 
-	for i := range tables {
-		if tables[i].NeedsVacuum || tables[i].NeedsAnalyze {
+	pool, poolErr := s.getPoolHealth(ctx)
+	if poolErr != nil {
+		health.Warnings = append(health.Warnings, poolErr.Error())
+	} else {
+		health.ConnectionPool = pool
+	}
+
+	for i := range health.Tables {
+		if health.Tables[i].NeedsVacuum || health.Tables[i].NeedsAnalyze {
 			health.NeedsMaintenance = true
 			break
 		}
@@ -267,6 +322,46 @@ func (s *MaintenanceService) getTableHealth(ctx context.Context) ([]TableHealth,
 	return tables, nil
 }
 
+// getPoolHealth reports the connection pool usage with sizing advice.
+// Pool sizes are the effective configured limits; the health check pool counts towards the server limit too.
+func (s *MaintenanceService) getPoolHealth(ctx context.Context) (*PoolHealth, error) {
+	serverMax, err := s.repo.GetMaxConnections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := s.repo.PoolStats()
+	pool := &PoolHealth{
+		MaxOpenConns:         s.config.Database.GetMaxOpenConns(),
+		HealthCheckConns:     s.config.Database.GetHealthCheckConns(),
+		OpenConnections:      stats.OpenConnections,
+		InUse:                stats.InUse,
+		Idle:                 stats.Idle,
+		WaitCount:            stats.WaitCount,
+		WaitDuration:         stats.WaitDuration.String(),
+		ServerMaxConnections: serverMax,
+		Recommendations:      []string{},
+	}
+
+	cfg := s.config.Maintenance
+	total := pool.MaxOpenConns + pool.HealthCheckConns
+	switch {
+	case total >= serverMax:
+		pool.Recommendations = append(pool.Recommendations, fmt.Sprintf(
+			"Connection pool can open %d connections but the server allows %d - lower max_open_conns to leave room for Aeron", total, serverMax))
+	case total*100 > serverMax*cfg.GetPoolServerSharePct():
+		pool.Recommendations = append(pool.Recommendations, fmt.Sprintf(
+			"Connection pool can use %d of the server's %d connections (over %d%%) - consider lowering max_open_conns", total, serverMax, cfg.GetPoolServerSharePct()))
+	}
+
+	if stats.WaitCount > cfg.GetPoolWaitCountThreshold() {
+		pool.Recommendations = append(pool.Recommendations, fmt.Sprintf(
+			"Requests waited %d times for a free connection (%s in total) - consider raising max_open_conns", stats.WaitCount, stats.WaitDuration.Round(time.Millisecond)))
+	}
+
+	return pool, nil
+}
+
 // generateRecommendations returns maintenance recommendations for tables requiring attention.
 func (s *MaintenanceService) generateRecommendations(tables []TableHealth) []string {
 	var recs []string
@@ -383,22 +478,21 @@ func (mctx *maintenanceContext) selectTablesToProcess(requestedTables []string,
 	return tablesToProcess, skipped
 }
 
-// executeVacuum executes VACUUM on a table with optional ANALYZE.
-func (s *MaintenanceService) executeVacuum(ctx context.Context, tableName string, analyze bool) error {
+// executeVacuum executes VACUUM, optionally FULL, on a table with optional ANALYZE.
+func (s *MaintenanceService) executeVacuum(ctx context.Context, tableName string, full, analyze bool) error {
 	if !types.IsValidIdentifier(tableName) {
 		return types.NewValidationError("table", fmt.Sprintf("invalid table name: %s", tableName))
 	}
 
-	schema := s.repo.Schema()
-	var query string
+	command := "VACUUM"
+	if full {
+		command += " FULL"
+	}
 	if analyze {
-		query = fmt.Sprintf("VACUUM ANALYZE %s.%s", schema, tableName)
-	} else {
-		query = fmt.Sprintf("VACUUM %s.%s", schema, tableName)
+		command += " ANALYZE"
 	}
-
-	_, err := s.repo.DB().ExecContext(ctx, query)
-	return err
+	query := fmt.Sprintf("%s %s.%s", command, s.repo.Schema(), tableName)
+	return s.repo.ExecWithLockTimeout(ctx, query, s.config.Maintenance.GetLockTimeout())
 }
 
 // executeAnalyze executes ANALYZE on the specified table.
@@ -409,15 +503,14 @@ func (s *MaintenanceService) executeAnalyze(ctx context.Context, tableName strin
 
 	schema := s.repo.Schema()
 	query := fmt.Sprintf("ANALYZE %s.%s", schema, tableName)
-	_, err := s.repo.DB().ExecContext(ctx, query)
-	return err
+	return s.repo.ExecWithLockTimeout(ctx, query, s.config.Maintenance.GetLockTimeout())
 }
 
 // --- Async operations ---
 
 // maintenanceTask defines parameters for a generic maintenance operation.
 type maintenanceTask struct {
-	operationName string                                        // "VACUUM", "VACUUM ANALYZE", "ANALYZE"
+	operationName string                                        // "VACUUM", "VACUUM FULL ANALYZE", "ANALYZE"
 	tables        []string                                      // Requested tables (empty = auto-select)
 	autoSelect    func(TableHealth) bool                        // Auto-selection criteria
 	execute       func(ctx context.Context, table string) error // Execute operation on a table
@@ -439,27 +532,34 @@ func (s *MaintenanceService) Status() *MaintenanceStatus {
 }
 
 // StartVacuum starts an async vacuum operation.
-// Returns an error if a maintenance operation is already running.
-func (s *MaintenanceService) StartVacuum(opts VacuumOptions) error {
+// Returns an error if a maintenance operation is already running. A VACUUM FULL run is first checked
+// against the free disk space; ctx only bounds that check.
+func (s *MaintenanceService) StartVacuum(ctx context.Context, opts VacuumOptions) error {
+	if opts.Full {
+		if err := s.checkVacuumFull(ctx, opts); err != nil {
+			return err
+		}
+	}
+
 	if !s.runner.TryStart() {
 		return types.NewConflictError("maintenance", "maintenance operation already in progress")
 	}
 
 	statusKey, opName := "vacuum", "VACUUM"
+	if opts.Full {
+		statusKey, opName = "vacuum_full", "VACUUM FULL"
+	}
 	if opts.Analyze {
-		statusKey, opName = "vacuum_analyze", "VACUUM ANALYZE"
+		statusKey, opName = statusKey+"_analyze", opName+" ANALYZE"
 	}
 	s.initStatus(statusKey)
 
-	cfg := s.config.Maintenance
 	task := maintenanceTask{
 		operationName: opName,
 		tables:        opts.Tables,
-		autoSelect: func(t TableHealth) bool {
-			return t.DeadTupleRatio > cfg.GetBloatThreshold() || t.DeadTuples > cfg.GetDeadTupleThreshold()
-		},
+		autoSelect:    s.needsVacuum,
 		execute: func(ctx context.Context, table string) error {
-			return s.executeVacuum(ctx, table, opts.Analyze)
+			return s.executeVacuum(ctx, table, opts.Full, opts.Analyze)
 		},
 		analyzed: opts.Analyze,
 	}
@@ -511,6 +611,12 @@ func (s *MaintenanceService) StartAnalyze(tableNames []string) error {
 	return nil
 }
 
+// needsVacuum reports whether VACUUM selects a table when no tables are requested.
+func (s *MaintenanceService) needsVacuum(t TableHealth) bool {
+	cfg := s.config.Maintenance
+	return t.DeadTupleRatio > cfg.GetBloatThreshold() || t.DeadTuples > cfg.GetDeadTupleThreshold()
+}
+
 // initStatus initializes the status for a new maintenance operation.
 func (s *MaintenanceService) initStatus(operation string) {
 	now := time.Now()
@@ -566,11 +672,17 @@ func (s *MaintenanceService) runMaintenance(ctx context.Context, task maintenanc
 		err := task.execute(ctx, tables[i].Name)
 		result.Duration = time.Since(start).Round(time.Millisecond).String()
 
-		if err != nil {
+		switch {
+		case database.IsLockTimeout(err):
+			result.Success = false
+			result.LockTimeout = true
+			result.Message = fmt.Sprintf("%s could not acquire lock on '%s' within %s; retry later", task.operationName, tables[i].Name, s.config.Maintenance.GetLockTimeout())
+			response.TablesFailed++
+		case err != nil:
 			result.Success = false
 			result.Message = fmt.Sprintf("%s failed on '%s': %v", task.operationName, tables[i].Name, err)
 			response.TablesFailed++
-		} else {
+		default:
 			result.Success = true
 			result.Message = fmt.Sprintf("%s completed successfully on '%s'", task.operationName, tables[i].Name)
 			response.TablesSuccess++
@@ -584,6 +696,9 @@ func (s *MaintenanceService) runMaintenance(ctx context.Context, task maintenanc
 
 // completeWithResult marks the maintenance operation as completed with a result.
 func (s *MaintenanceService) completeWithResult(result *MaintenanceResponse) {
+	// VACUUM and ANALYZE change the table statistics, so a cached health report is outdated.
+	s.health.invalidate()
+
 	now := time.Now()
 	s.statusMu.Lock()
 	defer s.statusMu.Unlock()
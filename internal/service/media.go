@@ -2,27 +2,51 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/database"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/image"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/util"
 )
 
 // MediaService handles artist, track, image, and playlist operations.
 type MediaService struct {
-	repo   *database.Repository
-	config *config.Config
+	repo    *database.Repository
+	config  *config.Config
+	stats   *imageStatsCache // nil when the statistics cache is disabled
+	thumbs  *thumbnailCache
+	metrics *metrics
 }
 
 // newMediaService creates a MediaService with the provided repository and configuration.
+// With the statistics cache enabled, it starts computing image statistics in the background.
 func newMediaService(repo *database.Repository, cfg *config.Config) *MediaService {
-	return &MediaService{
+	s := &MediaService{
 		repo:   repo,
 		config: cfg,
+		thumbs: newThumbnailCache(cfg.Image.GetThumbnailCacheEntries()),
+	}
+	if cfg.Image.StatsCache {
+		s.stats = newImageStatsCache(s, cfg.Image.GetStatsRefreshInterval(), cfg.API.GetRequestTimeout())
+	}
+	return s
+}
+
+// Close stops the background refresh of the statistics cache.
+func (s *MediaService) Close() {
+	if s.stats != nil {
+		s.stats.Close()
 	}
 }
 
@@ -33,6 +57,44 @@ func (s *MediaService) GetArtist(ctx context.Context, id string) (*database.Arti
 	return s.repo.GetArtist(ctx, id)
 }
 
+// ArtistUpdate contains the artist fields to change. Fields that are nil are left unchanged,
+// so an absent field can be told apart from one that is cleared with an empty string.
+type ArtistUpdate struct {
+	Info      *string `json:"info"`
+	Website   *string `json:"website"`
+	Twitter   *string `json:"twitter"`
+	Instagram *string `json:"instagram"`
+}
+
+// UpdateArtist changes the given fields of an artist and returns the updated artist.
+func (s *MediaService) UpdateArtist(ctx context.Context, id string, update *ArtistUpdate) (*database.ArtistDetails, error) {
+	values := make(map[string]any)
+	for column, value := range map[string]*string{
+		"info":      update.Info,
+		"website":   update.Website,
+		"twitter":   update.Twitter,
+		"instagram": update.Instagram,
+	} {
+		if value != nil {
+			values[column] = *value
+		}
+	}
+	if len(values) == 0 {
+		return nil, types.NewValidationError("body", "no fields to update: use info, website, twitter or instagram")
+	}
+
+	found, err := s.repo.UpdateEntityColumns(ctx, types.TableArtist, id, values)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, types.NewNotFoundError("artist", id)
+	}
+
+	slog.Info("Artist updated", "artistid", id, "fields", slices.Sorted(maps.Keys(values)))
+	return s.repo.GetArtist(ctx, id)
+}
+
 // --- Track operations ---
 
 // GetTrack retrieves a track by ID.
@@ -40,6 +102,298 @@ func (s *MediaService) GetTrack(ctx context.Context, id string) (*database.Track
 	return s.repo.GetTrack(ctx, id)
 }
 
+// TrackUpdate contains the track fields to change. Fields that are nil are left unchanged,
+// so an absent field can be told apart from one that is cleared or set to zero.
+type TrackUpdate struct {
+	Website   *string `json:"website"`
+	Conductor *string `json:"conductor"`
+	Orchestra *string `json:"orchestra"`
+	Rating    *int    `json:"rating"`
+	BPM       *int    `json:"bpm"`
+}
+
+// UpdateTrack changes the given fields of a track and returns the updated track.
+func (s *MediaService) UpdateTrack(ctx context.Context, id string, update *TrackUpdate) (*database.TrackDetails, error) {
+	values := make(map[string]any)
+	for column, value := range map[string]*string{
+		"website":   update.Website,
+		"conductor": update.Conductor,
+		"orchestra": update.Orchestra,
+	} {
+		if value != nil {
+			values[column] = *value
+		}
+	}
+	for column, value := range map[string]*int{
+		"rating": update.Rating,
+		"bpm":    update.BPM,
+	} {
+		if value == nil {
+			continue
+		}
+		if *value < 0 {
+			return nil, types.NewValidationError(column, fmt.Sprintf("%s must not be negative", column))
+		}
+		values[column] = *value
+	}
+	if len(values) == 0 {
+		return nil, types.NewValidationError("body", "no fields to update: use website, conductor, orchestra, rating or bpm")
+	}
+
+	found, err := s.repo.UpdateEntityColumns(ctx, types.TableTrack, id, values)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, types.NewNotFoundError("track", id)
+	}
+
+	slog.Info("Track updated", "titleid", id, "fields", slices.Sorted(maps.Keys(values)))
+	return s.repo.GetTrack(ctx, id)
+}
+
+// TrackBatchResult contains the tracks found by a batch lookup, keyed by track ID.
+type TrackBatchResult struct {
+	Tracks   map[string]*database.TrackDetails `json:"tracks"`
+	NotFound []string                          `json:"not_found"`
+}
+
+// GetTracks retrieves details for multiple tracks with a single query.
+// IDs are validated and deduplicated; unknown IDs are listed in NotFound.
+func (s *MediaService) GetTracks(ctx context.Context, ids []string) (*TrackBatchResult, error) {
+	if len(ids) == 0 {
+		return nil, types.NewValidationError("ids", "at least one ID is required")
+	}
+	if err := CheckBatchSize(&s.config.API, "ids", len(ids)); err != nil {
+		return nil, err
+	}
+
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if err := util.ValidateEntityID(id, "track"); err != nil {
+			return nil, err
+		}
+		id = strings.ToLower(id)
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	tracks, err := s.repo.GetTracks(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TrackBatchResult{
+		Tracks:   make(map[string]*database.TrackDetails, len(tracks)),
+		NotFound: []string{},
+	}
+	for i := range tracks {
+		result.Tracks[strings.ToLower(tracks[i].ID)] = &tracks[i]
+	}
+	for _, id := range unique {
+		if result.Tracks[id] == nil {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+	return result, nil
+}
+
+// Pagination limits for listing an artist's tracks.
+const (
+	DefaultArtistTracksLimit = 50
+	MaxArtistTracksLimit     = 500
+)
+
+// ArtistTracks contains a page of tracks belonging to an artist.
+type ArtistTracks struct {
+	ArtistID string           `json:"artist_id"`
+	Tracks   []database.Track `json:"tracks"`
+	Total    int              `json:"total"`
+	Limit    int              `json:"limit"`
+	Offset   int              `json:"offset"`
+}
+
+// GetArtistTracks returns a page of tracks linked to an artist.
+// A limit of 0 uses the default; larger limits are capped at MaxArtistTracksLimit.
+func (s *MediaService) GetArtistTracks(ctx context.Context, artistID string, limit, offset int) (*ArtistTracks, error) {
+	if _, err := s.repo.GetArtist(ctx, artistID); err != nil {
+		return nil, err
+	}
+
+	limit = min(cmp.Or(limit, DefaultArtistTracksLimit), MaxArtistTracksLimit)
+	tracks, total, err := s.repo.GetTracksByArtist(ctx, artistID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArtistTracks{
+		ArtistID: artistID,
+		Tracks:   tracks,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	}, nil
+}
+
+// Pagination limits for listing recently added tracks.
+const (
+	DefaultRecentTracksLimit = 50
+	MaxRecentTracksLimit     = 500
+)
+
+// RecentTracks contains a page of tracks added at or after a point in time.
+// TimestampColumn names the track column that was filtered on; it may record a modification rather than creation time.
+type RecentTracks struct {
+	AddedSince      string                 `json:"added_since"`
+	TimestampColumn string                 `json:"timestamp_column"`
+	Tracks          []database.RecentTrack `json:"tracks"`
+	Total           int                    `json:"total"`
+	Limit           int                    `json:"limit"`
+	Offset          int                    `json:"offset"`
+}
+
+// GetTracksAddedSince returns a page of tracks added at or after since, newest first.
+// It fails with a validation error when the track table has no timestamp column to filter on.
+// A limit of 0 uses the default; larger limits are capped at MaxRecentTracksLimit.
+func (s *MediaService) GetTracksAddedSince(ctx context.Context, since time.Time, limit, offset int) (*RecentTracks, error) {
+	if s.repo.TrackAddedColumn() == "" {
+		return nil, types.NewValidationError("added_since", "this Aeron database has no timestamp column on the track table, so recently added tracks cannot be listed")
+	}
+
+	limit = min(cmp.Or(limit, DefaultRecentTracksLimit), MaxRecentTracksLimit)
+	tracks, total, err := s.repo.GetTracksAddedSince(ctx, since, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecentTracks{
+		AddedSince:      since.Format("2006-01-02T15:04:05"),
+		TimestampColumn: s.repo.TrackAddedColumn(),
+		Tracks:          tracks,
+		Total:           total,
+		Limit:           limit,
+		Offset:          offset,
+	}, nil
+}
+
+// Pagination limits for listing artists.
+const (
+	DefaultArtistListLimit = 50
+	MaxArtistListLimit     = 500
+)
+
+// ArtistListOptions filters and orders an artist listing.
+type ArtistListOptions struct {
+	HasImage *bool // nil for all artists
+	Desc     bool  // order by name descending instead of ascending
+	Limit    int
+	Offset   int
+}
+
+// ArtistList contains a page of artists.
+type ArtistList struct {
+	Artists []database.Artist `json:"artists"`
+	Total   int               `json:"total"`
+	Limit   int               `json:"limit"`
+	Offset  int               `json:"offset"`
+}
+
+// ListArtists returns a page of artists ordered by name.
+// A limit of 0 uses the default; larger limits are capped at MaxArtistListLimit.
+func (s *MediaService) ListArtists(ctx context.Context, opts ArtistListOptions) (*ArtistList, error) {
+	limit := min(cmp.Or(opts.Limit, DefaultArtistListLimit), MaxArtistListLimit)
+	artists, total, err := s.repo.ListArtists(ctx, opts.HasImage, opts.Desc, limit, opts.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArtistList{
+		Artists: artists,
+		Total:   total,
+		Limit:   limit,
+		Offset:  opts.Offset,
+	}, nil
+}
+
+// Pagination limits for listing tracks.
+const (
+	DefaultTrackListLimit = 50
+	MaxTrackListLimit     = 500
+)
+
+// TrackListOptions filters a track listing.
+type TrackListOptions struct {
+	Query    string // case-insensitive match against title and artist, empty for all tracks
+	HasImage *bool  // nil for all tracks
+	Limit    int
+	Offset   int
+}
+
+// TrackList contains a page of tracks.
+type TrackList struct {
+	Tracks []database.Track `json:"tracks"`
+	Total  int              `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
+// ListTracks returns a page of tracks ordered by title.
+// A limit of 0 uses the default; larger limits are capped at MaxTrackListLimit.
+func (s *MediaService) ListTracks(ctx context.Context, opts TrackListOptions) (*TrackList, error) {
+	limit := min(cmp.Or(opts.Limit, DefaultTrackListLimit), MaxTrackListLimit)
+	tracks, total, err := s.repo.ListTracks(ctx, strings.TrimSpace(opts.Query), opts.HasImage, limit, opts.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrackList{
+		Tracks: tracks,
+		Total:  total,
+		Limit:  limit,
+		Offset: opts.Offset,
+	}, nil
+}
+
+// Result limits per entity type for searching.
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 100
+)
+
+// SearchResult contains the artists and tracks matching a search.
+type SearchResult struct {
+	Query   string            `json:"query"`
+	Artists []database.Artist `json:"artists"`
+	Tracks  []database.Track  `json:"tracks"`
+}
+
+// Search finds artists by name and tracks by title. Each limit of 0 uses the default;
+// larger limits are capped at MaxSearchLimit.
+func (s *MediaService) Search(ctx context.Context, query string, artistLimit, trackLimit int) (*SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, types.NewValidationError("q", "search query is required")
+	}
+
+	artists, err := s.repo.SearchArtists(ctx, query, min(cmp.Or(artistLimit, DefaultSearchLimit), MaxSearchLimit))
+	if err != nil {
+		return nil, err
+	}
+	tracks, err := s.repo.SearchTracks(ctx, query, min(cmp.Or(trackLimit, DefaultSearchLimit), MaxSearchLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &SearchResult{
+		Query:   query,
+		Artists: artists,
+		Tracks:  tracks,
+	}, nil
+}
+
 // --- Image operations ---
 
 // GetImage retrieves the image for an entity.
@@ -48,18 +402,114 @@ func (s *MediaService) GetImage(ctx context.Context, entityType types.EntityType
 	return s.repo.GetImage(ctx, table, id)
 }
 
+// GetImageHash returns the hex-encoded MD5 hash of an entity's stored image, or an empty string if it has none.
+// The hash is computed by the database, so it is a cheap way to check whether a cached copy is still current.
+func (s *MediaService) GetImageHash(ctx context.Context, entityType types.EntityType, id string) (string, error) {
+	return s.repo.GetImageHash(ctx, types.Table(entityType), id)
+}
+
+// Image variants that can be requested with GetImageVariant.
+const (
+	ImageVariantThumb  = "thumb"
+	ImageVariantMedium = "medium"
+	ImageVariantFull   = "full" // the image stored in Aeron itself
+)
+
+// GetImageVariant retrieves an image in the requested variant. The thumb and medium variants are
+// only available when image variants are enabled and the image was uploaded since.
+func (s *MediaService) GetImageVariant(ctx context.Context, entityType types.EntityType, id, variant string) ([]byte, error) {
+	table := types.Table(entityType)
+	switch variant {
+	case "", ImageVariantFull:
+		return s.GetImage(ctx, entityType, id)
+	case ImageVariantThumb, ImageVariantMedium:
+		if !s.config.Image.StoreVariants {
+			return nil, types.NewValidationError("variant", "image variants are not enabled")
+		}
+		return s.repo.GetImageVariant(ctx, table, id, variant)
+	default:
+		return nil, types.NewValidationError("variant", fmt.Sprintf("invalid variant: use '%s', '%s' or '%s'", ImageVariantThumb, ImageVariantMedium, ImageVariantFull))
+	}
+}
+
+// GetImageSize retrieves an image resized on the fly to one of the configured thumbnail sizes.
+// Resized images are cached in memory by the hash of the stored image, so they are only
+// recomputed after the image changes.
+func (s *MediaService) GetImageSize(ctx context.Context, entityType types.EntityType, id, size string) ([]byte, error) {
+	sizes := s.config.Image.GetThumbnailSizes()
+	dims, ok := sizes[size]
+	if !ok {
+		names := slices.Sorted(maps.Keys(sizes))
+		return nil, types.NewValidationError("size", fmt.Sprintf("invalid size: use one of %s", strings.Join(names, ", ")))
+	}
+
+	table := types.Table(entityType)
+	hash, err := s.repo.GetImageHash(ctx, table, id)
+	if err != nil {
+		return nil, err
+	}
+	if hash == "" {
+		// Let the repository report the missing image the same way as for full images.
+		return s.GetImage(ctx, entityType, id)
+	}
+
+	key := thumbnailKey{entityType: entityType, id: id, size: size, hash: hash}
+	if data, ok := s.thumbs.get(key); ok {
+		return data, nil
+	}
+
+	imageData, err := s.GetImage(ctx, entityType, id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := image.Process(imageData, resizeConfig(s.imageConfig(), dims.Width, dims.Height))
+	if err != nil {
+		return nil, types.NewOperationError(fmt.Sprintf("create %s image", size), err)
+	}
+
+	s.thumbs.put(key, result.Data)
+	return result.Data, nil
+}
+
+// GetEntityName returns the display name of an artist, or the "artist - title" label of a track.
+func (s *MediaService) GetEntityName(ctx context.Context, entityType types.EntityType, id string) (string, error) {
+	if entityType == types.EntityTypeArtist {
+		artist, err := s.repo.GetArtist(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return artist.ArtistName, nil
+	}
+
+	track, err := s.repo.GetTrack(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if track.Artist == "" {
+		return track.TrackTitle, nil
+	}
+	return track.Artist + " - " + track.TrackTitle, nil
+}
+
 // DeleteImage removes the image from an entity.
 func (s *MediaService) DeleteImage(ctx context.Context, entityType types.EntityType, id string) error {
 	table := types.Table(entityType)
-	return s.repo.DeleteImage(ctx, table, id)
+	if err := s.repo.DeleteImage(ctx, table, id); err != nil {
+		return err
+	}
+	if s.config.Image.StoreVariants {
+		return s.repo.DeleteImageVariants(ctx, table, id)
+	}
+	return nil
 }
 
 // ImageUploadParams contains the parameters for image upload operations.
 type ImageUploadParams struct {
-	EntityType types.EntityType
-	ID         string
-	ImageURL   string
-	ImageData  []byte
+	EntityType    types.EntityType
+	ID            string
+	ImageURL      string
+	ImageData     []byte
+	ClaimedFormat string // format the client declared for ImageData, empty if undeclared
 }
 
 // ImageUploadResult contains the results of an image upload operation.
@@ -69,10 +519,74 @@ type ImageUploadResult struct {
 	OriginalSize         int
 	OptimizedSize        int
 	SizeReductionPercent float64
+	Format               string // format of the stored image
+	Quality              int    // quality used for encoding, 0 if the original was kept
+	Encoder              string // which encoding was stored, compared with the alternatives and their sizes
+	PerceptualHash       string // hex-encoded perceptual hash, empty unless enabled in the configuration
+	Unchanged            bool   // the stored image was already identical, so no update was written
+	ImageData            []byte // the image as stored in the database
+	DetectedFormat       string // format of the uploaded image, as decoded
+	ClaimedFormat        string // format declared by the client or download server, empty if undeclared
+}
+
+// FormatMismatch reports whether the uploaded image was declared as a different format than it is.
+func (r *ImageUploadResult) FormatMismatch() bool {
+	return r.ClaimedFormat != "" && r.ClaimedFormat != r.DetectedFormat
+}
+
+// imageUnchanged reports whether the stored image is byte-for-byte identical to data.
+// Skipping identical writes avoids needless TOAST churn and dead tuples.
+func (s *MediaService) imageUnchanged(ctx context.Context, table types.Table, id string, data []byte) (bool, error) {
+	storedHash, err := s.repo.GetImageHash(ctx, table, id)
+	if err != nil {
+		return false, err
+	}
+	if storedHash == "" {
+		return false, nil
+	}
+	sum := md5.Sum(data) // Matches PostgreSQL's md5(); used for change detection only
+	return storedHash == hex.EncodeToString(sum[:]), nil
+}
+
+// imageConfig returns the image processing settings from the configuration.
+func (s *MediaService) imageConfig() image.Config {
+	return image.Config{
+		TargetWidth:      s.config.Image.TargetWidth,
+		TargetHeight:     s.config.Image.TargetHeight,
+		Quality:          s.config.Image.Quality,
+		AdaptiveQuality:  s.config.Image.AdaptiveQuality,
+		MinQuality:       s.config.Image.GetMinQuality(),
+		MaxQuality:       s.config.Image.GetMaxQuality(),
+		RejectSmaller:    s.config.Image.RejectSmaller,
+		MinSourceBytes:   s.config.Image.MinSourceBytes,
+		SkipBelowBytes:   s.config.Image.SkipOptimizationBelowBytes,
+		NotSmallerPolicy: s.config.Image.GetNotSmallerPolicy(),
+		OutputFormat:     s.config.Image.GetOutputFormat(),
+		EnableAVIF:       s.config.Image.EnableAVIF,
+		EnableHEIC:       s.config.Image.EnableHEIC,
+		PerceptualHash:   s.config.Image.PerceptualHash,
+		StripMetadata:    s.config.Image.StripMetadata,
+		Background:       s.config.Image.BackgroundRGBA(),
+		MaxPixels:        s.config.Image.GetMaxPixels(),
+		MaxWidth:         s.config.Image.GetMaxWidth(),
+		MaxHeight:        s.config.Image.GetMaxHeight(),
+	}
 }
 
 // UploadImage downloads, resizes, optimizes, and stores an image for an artist or track.
 func (s *MediaService) UploadImage(ctx context.Context, params *ImageUploadParams) (*ImageUploadResult, error) {
+	return s.uploadImage(ctx, params, s.processUploadImage)
+}
+
+// processedImage is an uploaded image after optimization, together with the format it was declared as.
+type processedImage struct {
+	result        *image.ProcessingResult
+	claimedFormat string
+}
+
+// uploadImage stores an image for an artist or track. The image is obtained from process,
+// so batch uploads can share the result of one download and optimization between entities.
+func (s *MediaService) uploadImage(ctx context.Context, params *ImageUploadParams, process func(*ImageUploadParams) (*processedImage, error)) (*ImageUploadResult, error) {
 	slog.Debug("Image upload started", "entityType", params.EntityType, "id", params.ID, "hasURL", params.ImageURL != "", "hasData", len(params.ImageData) > 0)
 
 	if err := validateImageUploadParams(params); err != nil {
@@ -96,47 +610,125 @@ func (s *MediaService) UploadImage(ctx context.Context, params *ImageUploadParam
 		title = track.TrackTitle
 	}
 
-	var imageData []byte
-	var err error
-	if params.ImageURL != "" {
-		imageData, err = image.DownloadImage(params.ImageURL, s.config.Image.GetMaxDownloadBytes())
-		if err != nil {
-			slog.Error("Image download failed", "url", params.ImageURL, "error", err)
-			return nil, types.NewValidationError("image", fmt.Sprintf("download failed: %v", err))
-		}
-	} else {
-		imageData = params.ImageData
+	processed, err := process(params)
+	if err != nil {
+		return nil, err
 	}
+	processingResult := processed.result
 
-	imgConfig := image.Config{
-		TargetWidth:   s.config.Image.TargetWidth,
-		TargetHeight:  s.config.Image.TargetHeight,
-		Quality:       s.config.Image.Quality,
-		RejectSmaller: s.config.Image.RejectSmaller,
-	}
-	slog.Debug("Image processing started", "inputSize", len(imageData), "targetWidth", imgConfig.TargetWidth, "targetHeight", imgConfig.TargetHeight)
-	processingResult, err := image.Process(imageData, imgConfig)
-	if err != nil {
-		slog.Error("Image processing failed", "error", err)
-		return nil, types.NewValidationError("image", fmt.Sprintf("processing failed: %v", err))
+	imgConfig := s.imageConfig()
+	var perceptualHash string
+	if imgConfig.PerceptualHash {
+		perceptualHash = image.FormatHash(processingResult.Hash)
 	}
-	slog.Debug("Image processing completed", "originalSize", processingResult.Original.Size, "optimizedSize", processingResult.Optimized.Size, "savings", processingResult.Savings)
 
 	table := types.Table(params.EntityType)
-	if err := s.repo.UpdateImage(ctx, table, params.ID, processingResult.Data); err != nil {
+	unchanged, err := s.imageUnchanged(ctx, table, params.ID, processingResult.Data)
+	if err != nil {
+		return nil, err
+	}
+	if unchanged {
+		slog.Info("Image unchanged, skipping update", "entityType", params.EntityType, "id", params.ID)
+	} else if err := s.repo.UpdateImage(ctx, table, params.ID, processingResult.Data); err != nil {
 		slog.Error("Image save failed", "entityType", params.EntityType, "id", params.ID, "error", err)
 		return nil, err
 	}
 
+	// Variants are also stored for unchanged images, so re-uploading fills in missing ones.
+	if s.config.Image.StoreVariants {
+		if err := s.storeImageVariants(ctx, table, params.ID, processingResult.Data, imgConfig); err != nil {
+			slog.Error("Image variants save failed", "entityType", params.EntityType, "id", params.ID, "error", err)
+			return nil, err
+		}
+	}
+
+	s.metrics.observeImageSavings(params.EntityType, processingResult.Savings)
+
 	return &ImageUploadResult{
 		OriginalSize:         processingResult.Original.Size,
 		OptimizedSize:        processingResult.Optimized.Size,
 		SizeReductionPercent: processingResult.Savings,
+		Format:               processingResult.Optimized.Format,
+		Quality:              processingResult.Quality,
+		Encoder:              processingResult.Encoder,
+		PerceptualHash:       perceptualHash,
+		Unchanged:            unchanged,
+		ImageData:            processingResult.Data,
 		ArtistName:           name,
 		TrackTitle:           title,
+		DetectedFormat:       processingResult.Original.Format,
+		ClaimedFormat:        processed.claimedFormat,
 	}, nil
 }
 
+// processUploadImage downloads the image of params if it has a URL, then resizes and optimizes it.
+// The format is always taken from the decoded image; a different declared format is logged, or
+// rejected when image.reject_format_mismatch is set.
+func (s *MediaService) processUploadImage(params *ImageUploadParams) (*processedImage, error) {
+	imageData, claimedFormat := params.ImageData, image.NormalizeFormat(params.ClaimedFormat)
+	if params.ImageURL != "" {
+		var err error
+		imageData, claimedFormat, err = image.DownloadImage(params.ImageURL, s.config.Image.GetMaxDownloadBytes(), s.config.Image.GetMaxDownloadRedirects(), s.config.Image.GetMaxPixels())
+		if err != nil {
+			slog.Error("Image download failed", "url", params.ImageURL, "error", err)
+			return nil, types.NewValidationError("image", fmt.Sprintf("download failed: %v", err))
+		}
+	}
+
+	imgConfig := s.imageConfig()
+	slog.Debug("Image processing started", "inputSize", len(imageData), "targetWidth", imgConfig.TargetWidth, "targetHeight", imgConfig.TargetHeight)
+	processingResult, err := image.Process(imageData, imgConfig)
+	if err != nil {
+		slog.Error("Image processing failed", "error", err)
+		return nil, types.NewValidationError("image", fmt.Sprintf("processing failed: %v", err))
+	}
+	slog.Debug("Image processing completed", "originalSize", processingResult.Original.Size, "optimizedSize", processingResult.Optimized.Size, "savings", processingResult.Savings, "quality", processingResult.Quality)
+
+	if detected := processingResult.Original.Format; claimedFormat != "" && claimedFormat != detected {
+		if s.config.Image.RejectFormatMismatch {
+			return nil, types.NewValidationError("image", fmt.Sprintf("image content is %s but was declared as %s", detected, claimedFormat))
+		}
+		slog.Warn("Image format differs from declared format", "entityType", params.EntityType, "id", params.ID, "declared", claimedFormat, "detected", detected)
+	}
+	if processingResult.Sanitized {
+		slog.Warn("Image has data after the end of the image, storing re-encoded version", "entityType", params.EntityType, "id", params.ID, "format", processingResult.Original.Format)
+	}
+
+	return &processedImage{result: processingResult, claimedFormat: claimedFormat}, nil
+}
+
+// storeImageVariants derives the thumb and medium variants from the stored image and saves them.
+func (s *MediaService) storeImageVariants(ctx context.Context, table types.Table, id string, imageData []byte, base image.Config) error {
+	sizes := map[string]int{
+		ImageVariantThumb:  s.config.Image.GetThumbSize(),
+		ImageVariantMedium: s.config.Image.GetMediumSize(),
+	}
+
+	variants := make(map[string][]byte, len(sizes))
+	for variant, size := range sizes {
+		result, err := image.Process(imageData, resizeConfig(base, size, size))
+		if err != nil {
+			return types.NewOperationError(fmt.Sprintf("create %s image", variant), err)
+		}
+		variants[variant] = result.Data
+	}
+
+	return s.repo.SaveImageVariants(ctx, table, id, variants)
+}
+
+// resizeConfig derives the configuration for a smaller copy of an already stored image.
+// The source already passed validation, so only the target size changes.
+func resizeConfig(base image.Config, width, height int) image.Config {
+	cfg := base
+	cfg.TargetWidth = width
+	cfg.TargetHeight = height
+	cfg.RejectSmaller = false
+	cfg.MinSourceBytes = 0
+	cfg.NotSmallerPolicy = image.NotSmallerKeep
+	cfg.PerceptualHash = false
+	return cfg
+}
+
 // --- Statistics operations ---
 
 // ImageStats represents statistics about images in the database.
@@ -144,22 +736,59 @@ type ImageStats struct {
 	Total         int
 	WithImages    int
 	WithoutImages int
+	ComputedAt    time.Time
 }
 
 // GetStatistics returns image statistics for entities of the specified type.
-func (s *MediaService) GetStatistics(ctx context.Context, entityType types.EntityType) (*ImageStats, error) {
+// With activeOnly, tracks excluded from operations are left out so coverage reflects the tracks that actually air.
+// When the statistics cache is enabled, the cached values are returned once they have been computed.
+func (s *MediaService) GetStatistics(ctx context.Context, entityType types.EntityType, activeOnly bool) (*ImageStats, error) {
 	if err := validateEntityType(entityType); err != nil {
 		return nil, err
 	}
 
+	if s.stats != nil {
+		if stats, ok := s.stats.get(imageStatsKey{entityType: entityType, activeOnly: activeOnly}); ok {
+			return stats, nil
+		}
+	}
+	return s.RefreshStatistics(ctx, entityType, activeOnly)
+}
+
+// RefreshStatistics computes image statistics for entities of the specified type, bypassing the statistics cache.
+// The result replaces the cached values.
+func (s *MediaService) RefreshStatistics(ctx context.Context, entityType types.EntityType, activeOnly bool) (*ImageStats, error) {
+	if err := validateEntityType(entityType); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.computeStatistics(ctx, entityType, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	if s.stats != nil {
+		s.stats.set(imageStatsKey{entityType: entityType, activeOnly: activeOnly}, stats)
+	}
+	return stats, nil
+}
+
+// refreshStatisticsSoon schedules a background refresh of the statistics cache after a bulk change.
+func (s *MediaService) refreshStatisticsSoon() {
+	if s.stats != nil {
+		s.stats.refreshSoon()
+	}
+}
+
+// computeStatistics counts the entities with and without an image.
+func (s *MediaService) computeStatistics(ctx context.Context, entityType types.EntityType, activeOnly bool) (*ImageStats, error) {
 	table := types.Table(entityType)
 
-	withImages, err := s.repo.CountWithImages(ctx, table)
+	withImages, err := s.repo.CountWithImages(ctx, table, activeOnly)
 	if err != nil {
 		return nil, err
 	}
 
-	withoutImages, err := s.repo.CountWithoutImages(ctx, table)
+	withoutImages, err := s.repo.CountWithoutImages(ctx, table, activeOnly)
 	if err != nil {
 		return nil, err
 	}
@@ -168,9 +797,226 @@ func (s *MediaService) GetStatistics(ctx context.Context, entityType types.Entit
 		Total:         withImages + withoutImages,
 		WithImages:    withImages,
 		WithoutImages: withoutImages,
+		ComputedAt:    time.Now(),
+	}, nil
+}
+
+// AttentionCounts contains the number of artists and tracks that still need an image.
+type AttentionCounts struct {
+	ArtistsWithoutImages int       `json:"artists_without_images"`
+	TracksWithoutImages  int       `json:"tracks_without_images"`
+	Total                int       `json:"total"`
+	ComputedAt           time.Time `json:"computed_at"` // when the oldest of the underlying counts was computed
+}
+
+// GetAttentionCounts combines the artist and track image statistics into the counts for a dashboard indicator.
+// It uses the statistics cache when enabled, so it is as cheap as the statistics endpoints.
+func (s *MediaService) GetAttentionCounts(ctx context.Context, activeOnly bool) (*AttentionCounts, error) {
+	artists, err := s.GetStatistics(ctx, types.EntityTypeArtist, false)
+	if err != nil {
+		return nil, err
+	}
+	tracks, err := s.GetStatistics(ctx, types.EntityTypeTrack, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	computedAt := artists.ComputedAt
+	if tracks.ComputedAt.Before(computedAt) {
+		computedAt = tracks.ComputedAt
+	}
+	return &AttentionCounts{
+		ArtistsWithoutImages: artists.WithoutImages,
+		TracksWithoutImages:  tracks.WithoutImages,
+		Total:                artists.WithoutImages + tracks.WithoutImages,
+		ComputedAt:           computedAt,
 	}, nil
 }
 
+// ImageSizeStats contains the stored image size histogram per entity type.
+type ImageSizeStats struct {
+	Artists []database.ImageSizeBucket `json:"artists"`
+	Tracks  []database.ImageSizeBucket `json:"tracks"`
+}
+
+// GetImageSizeStats returns how the stored artist and track images are distributed over size buckets.
+func (s *MediaService) GetImageSizeStats(ctx context.Context) (*ImageSizeStats, error) {
+	artists, err := s.repo.GetImageSizeBuckets(ctx, types.TableArtist)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks, err := s.repo.GetImageSizeBuckets(ctx, types.TableTrack)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImageSizeStats{
+		Artists: artists,
+		Tracks:  tracks,
+	}, nil
+}
+
+// DefaultSimilarityThreshold is the default maximum Hamming distance between near-duplicate images.
+const DefaultSimilarityThreshold = 5
+
+// MaxSimilarityThreshold is the highest accepted similarity threshold; beyond it unrelated images start to match.
+const MaxSimilarityThreshold = 16
+
+// SimilarImage is one entity in a group of near-duplicate images.
+type SimilarImage struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Hash     string `json:"perceptual_hash"`
+	Distance int    `json:"distance"` // Hamming distance to the group hash
+}
+
+// SimilarImageGroup is a set of entities whose stored images are near-duplicates of each other.
+type SimilarImageGroup struct {
+	EntityType types.EntityType `json:"entity_type"`
+	Hash       string           `json:"perceptual_hash"` // most common hash in the group
+	Count      int              `json:"count"`
+	Items      []SimilarImage   `json:"items"`
+}
+
+// hashedImage identifies an entity whose stored image has been hashed.
+type hashedImage struct {
+	id   string
+	name string
+}
+
+// FindSimilarImages groups artists and tracks whose stored images have perceptual hashes within
+// threshold bits of each other, and calls fn for each group. Artist groups are reported before
+// track groups, each ordered from largest to smallest. Images that cannot be decoded are skipped.
+func (s *MediaService) FindSimilarImages(ctx context.Context, threshold int, fn func(SimilarImageGroup) error) error {
+	if threshold < 0 || threshold > MaxSimilarityThreshold {
+		return types.NewValidationError("threshold", fmt.Sprintf("must be between 0 and %d", MaxSimilarityThreshold))
+	}
+
+	for _, entityType := range []types.EntityType{types.EntityTypeArtist, types.EntityTypeTrack} {
+		byHash, err := s.hashStoredImages(ctx, entityType)
+		if err != nil {
+			return err
+		}
+
+		groups, err := groupSimilarHashes(ctx, entityType, byHash, threshold)
+		if err != nil {
+			return err
+		}
+
+		for i := range groups {
+			if err := fn(groups[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hashStoredImages computes the perceptual hash of every stored image of an entity type.
+func (s *MediaService) hashStoredImages(ctx context.Context, entityType types.EntityType) (map[uint64][]hashedImage, error) {
+	maxPixels := s.config.Image.GetMaxPixels()
+	byHash := make(map[uint64][]hashedImage)
+
+	err := s.repo.EachImage(ctx, types.Table(entityType), func(stored database.StoredImage) error {
+		hash, err := image.HashImageData(stored.Picture, maxPixels)
+		if err != nil {
+			slog.Debug("Skipping image that cannot be hashed", "entity_type", entityType, "id", stored.ID, "error", err)
+			return nil
+		}
+		byHash[hash] = append(byHash[hash], hashedImage{id: stored.ID, name: stored.Name})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return byHash, nil
+}
+
+// groupSimilarHashes clusters hashes that are within threshold bits of each other, directly or
+// through a chain of similar hashes. Only clusters with at least two entities are returned.
+func groupSimilarHashes(ctx context.Context, entityType types.EntityType, byHash map[uint64][]hashedImage, threshold int) ([]SimilarImageGroup, error) {
+	hashes := slices.Sorted(maps.Keys(byHash))
+
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	for i := range hashes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for j := i + 1; j < len(hashes); j++ {
+			if image.HammingDistance(hashes[i], hashes[j]) <= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	clusters := make(map[int][]uint64)
+	for i, hash := range hashes {
+		root := find(i)
+		clusters[root] = append(clusters[root], hash)
+	}
+
+	var groups []SimilarImageGroup
+	for _, cluster := range clusters {
+		if group, ok := buildSimilarImageGroup(entityType, byHash, cluster); ok {
+			groups = append(groups, group)
+		}
+	}
+
+	slices.SortFunc(groups, func(a, b SimilarImageGroup) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Hash, b.Hash))
+	})
+	return groups, nil
+}
+
+// buildSimilarImageGroup converts a cluster of hashes into a group, reporting false if it holds a single entity.
+func buildSimilarImageGroup(entityType types.EntityType, byHash map[uint64][]hashedImage, cluster []uint64) (SimilarImageGroup, bool) {
+	groupHash := cluster[0]
+	count := 0
+	for _, hash := range cluster {
+		count += len(byHash[hash])
+		if len(byHash[hash]) > len(byHash[groupHash]) {
+			groupHash = hash
+		}
+	}
+	if count < 2 {
+		return SimilarImageGroup{}, false
+	}
+
+	items := make([]SimilarImage, 0, count)
+	for _, hash := range cluster {
+		for _, img := range byHash[hash] {
+			items = append(items, SimilarImage{
+				ID:       img.id,
+				Name:     img.name,
+				Hash:     image.FormatHash(hash),
+				Distance: image.HammingDistance(hash, groupHash),
+			})
+		}
+	}
+	slices.SortFunc(items, func(a, b SimilarImage) int {
+		return cmp.Or(cmp.Compare(a.Distance, b.Distance), strings.Compare(a.ID, b.ID))
+	})
+
+	return SimilarImageGroup{
+		EntityType: entityType,
+		Hash:       image.FormatHash(groupHash),
+		Count:      count,
+		Items:      items,
+	}, true
+}
+
 // DeleteResult contains the results of a bulk image deletion operation.
 type DeleteResult struct {
 	CountBefore  int
@@ -185,7 +1031,7 @@ func (s *MediaService) DeleteAllImages(ctx context.Context, entityType types.Ent
 
 	table := types.Table(entityType)
 
-	count, err := s.repo.CountWithImages(ctx, table)
+	count, err := s.repo.CountWithImages(ctx, table, false)
 	if err != nil {
 		return nil, err
 	}
@@ -199,6 +1045,14 @@ func (s *MediaService) DeleteAllImages(ctx context.Context, entityType types.Ent
 		return nil, err
 	}
 
+	s.refreshStatisticsSoon()
+
+	if s.config.Image.StoreVariants {
+		if err := s.repo.DeleteAllImageVariants(ctx, table); err != nil {
+			return nil, err
+		}
+	}
+
 	return &DeleteResult{CountBefore: count, DeletedCount: deleted}, nil
 }
 
@@ -209,14 +1063,24 @@ type PlaylistOptions struct {
 	BlockID     string
 	Date        string
 	ExportTypes []int
+	Modes       []int // only include items with one of these modes, all modes if empty
+	ModeLabels  bool  // add the configured mode label to each item
+	HasArtist   *bool // only include items with (true) or without (false) a real artist
 	Limit       int
 	Offset      int
 	SortBy      string
 	SortDesc    bool
+	Sort        []PlaylistSort // multi-column sort, takes precedence over SortBy and SortDesc
 	TrackImage  *bool
 	ArtistImage *bool
+
+	// OmitMissingArtist reports an empty artistid instead of the all-zero placeholder for items without an artist.
+	OmitMissingArtist bool
 }
 
+// PlaylistSort is one column of a multi-column playlist sort.
+type PlaylistSort = database.PlaylistSort
+
 // DefaultPlaylistOptions returns playlist query options with sensible defaults.
 func DefaultPlaylistOptions() PlaylistOptions {
 	return PlaylistOptions{
@@ -231,14 +1095,86 @@ func (s *MediaService) GetPlaylist(ctx context.Context, opts *PlaylistOptions) (
 		BlockID:     opts.BlockID,
 		Date:        opts.Date,
 		ExportTypes: opts.ExportTypes,
+		Modes:       opts.Modes,
+		HasArtist:   opts.HasArtist,
 		Limit:       opts.Limit,
 		Offset:      opts.Offset,
 		SortBy:      opts.SortBy,
 		SortDesc:    opts.SortDesc,
+		Sort:        opts.Sort,
 		TrackImage:  opts.TrackImage,
 		ArtistImage: opts.ArtistImage,
 	}
-	return s.repo.GetPlaylist(ctx, dbOpts)
+	items, err := s.repo.GetPlaylist(ctx, dbOpts)
+	if err != nil {
+		return nil, err
+	}
+	s.decoratePlaylistItems(items, opts)
+	return items, nil
+}
+
+// decoratePlaylistItems applies the presentation options to playlist items in place.
+// Mode labels are only set for modes with a configured label.
+func (s *MediaService) decoratePlaylistItems(items []database.PlaylistItem, opts *PlaylistOptions) {
+	labels := s.config.Metadata.GetClassifications()["mode"]
+	for i := range items {
+		if opts.ModeLabels {
+			items[i].ModeLabel = labels[items[i].Mode]
+		}
+		if opts.OmitMissingArtist && items[i].ArtistID == types.NoArtistID {
+			items[i].ArtistID = ""
+		}
+	}
+}
+
+// PlaylistStats summarizes the programming of one day.
+type PlaylistStats struct {
+	Date string `json:"date,omitempty"`
+	database.PlaylistStats
+	TrackImageCoverage  float64 `json:"track_image_coverage"`  // percentage of music items with a track image
+	ArtistImageCoverage float64 `json:"artist_image_coverage"` // percentage of music items with an artist image
+}
+
+// GetPlaylistStats returns aggregate numbers for the playlist of a date, or of today if date is empty.
+func (s *MediaService) GetPlaylistStats(ctx context.Context, date string) (*PlaylistStats, error) {
+	stats, err := s.repo.GetPlaylistStats(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PlaylistStats{Date: date, PlaylistStats: *stats}
+	if stats.MusicItems > 0 {
+		result.TrackImageCoverage = float64(stats.MusicTrackImage) / float64(stats.MusicItems) * 100
+		result.ArtistImageCoverage = float64(stats.MusicArtistImage) / float64(stats.MusicItems) * 100
+	}
+	return result, nil
+}
+
+// UpdatePlaylistItemStart moves a playlist item to a new scheduled start within its block.
+func (s *MediaService) UpdatePlaylistItemStart(ctx context.Context, id int64, start time.Time) (*database.PlaylistItemSchedule, error) {
+	schedule, err := s.repo.GetPlaylistItemSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	outsideBlock := types.NewValidationError("start", fmt.Sprintf("start time must be within the block (%s - %s)",
+		schedule.BlockStart.Format(time.DateTime), schedule.BlockEnd.Format(time.DateTime)))
+	if start.Before(schedule.BlockStart) || !start.Before(schedule.BlockEnd) {
+		return nil, outsideBlock
+	}
+
+	updated, err := s.repo.UpdatePlaylistItemStart(ctx, id, start)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		// The block was changed or the item removed since it was read.
+		return nil, outsideBlock
+	}
+
+	slog.Info("Playlist item start updated", "itemid", id, "blockid", schedule.BlockID, "from", schedule.Start.Format(time.DateTime), "to", start.Format(time.DateTime))
+	schedule.Start = start
+	return schedule, nil
 }
 
 // PlaylistBlockWithTracks represents a playlist block with its associated tracks.
@@ -247,9 +1183,15 @@ type PlaylistBlockWithTracks struct {
 	Tracks []database.PlaylistItem `json:"tracks"`
 }
 
-// GetPlaylistWithTracks retrieves all playlist blocks for a date with their tracks.
-func (s *MediaService) GetPlaylistWithTracks(ctx context.Context, date string) ([]PlaylistBlockWithTracks, error) {
-	blocks, tracksByBlock, err := s.repo.GetPlaylistWithTracks(ctx, date)
+// GetPlaylistWithTracks retrieves all playlist blocks for opts.Date with their tracks.
+// Pagination, sorting, and image filters do not apply.
+func (s *MediaService) GetPlaylistWithTracks(ctx context.Context, opts *PlaylistOptions) ([]PlaylistBlockWithTracks, error) {
+	blocks, tracksByBlock, err := s.repo.GetPlaylistWithTracks(ctx, &database.PlaylistOptions{
+		Date:        opts.Date,
+		ExportTypes: opts.ExportTypes,
+		Modes:       opts.Modes,
+		HasArtist:   opts.HasArtist,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -260,6 +1202,7 @@ func (s *MediaService) GetPlaylistWithTracks(ctx context.Context, date string) (
 		if tracks == nil {
 			tracks = []database.PlaylistItem{}
 		}
+		s.decoratePlaylistItems(tracks, opts)
 		result[i] = PlaylistBlockWithTracks{
 			PlaylistBlock: blocks[i],
 			Tracks:        tracks,
@@ -0,0 +1,111 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"database/sql"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// metrics holds the Prometheus collectors that the services update as they work.
+// A nil *metrics records nothing.
+type metrics struct {
+	imageSavings      *prometheus.HistogramVec
+	backups           *prometheus.CounterVec
+	backupLastSuccess prometheus.Gauge
+}
+
+// newMetrics creates the service metrics and registers them, together with runtime, connection pool,
+// and backup status collectors, on registry. healthDB may be nil.
+func newMetrics(registry *prometheus.Registry, db, healthDB *sql.DB, backup *BackupService) *metrics {
+	m := &metrics{
+		imageSavings: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "aeron_image_savings_percent",
+			Help:    "Size reduction of uploaded images by optimization, in percent.",
+			Buckets: prometheus.LinearBuckets(0, 10, 11),
+		}, []string{"entity_type"}),
+		backups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "aeron_backups_total",
+			Help: "Completed backups by result.",
+		}, []string{"result"}),
+		backupLastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "aeron_backup_last_success_timestamp_seconds",
+			Help: "Time of the last successful backup since startup, as a Unix timestamp.",
+		}),
+	}
+	// Start both results at zero, so rates and alerts work before the first failure.
+	m.backups.WithLabelValues("success")
+	m.backups.WithLabelValues("failed")
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "main"),
+		m.imageSavings,
+		m.backups,
+		m.backupLastSuccess,
+		&backupCollector{backup: backup},
+	)
+	if healthDB != nil {
+		registry.MustRegister(collectors.NewDBStatsCollector(healthDB, "health"))
+	}
+	return m
+}
+
+// observeImageSavings records the size reduction of an uploaded image.
+func (m *metrics) observeImageSavings(entityType types.EntityType, percent float64) {
+	if m == nil {
+		return
+	}
+	m.imageSavings.WithLabelValues(string(entityType)).Observe(percent)
+}
+
+// recordBackup counts a completed backup.
+func (m *metrics) recordBackup(success bool) {
+	if m == nil {
+		return
+	}
+	if !success {
+		m.backups.WithLabelValues("failed").Inc()
+		return
+	}
+	m.backups.WithLabelValues("success").Inc()
+	m.backupLastSuccess.SetToCurrentTime()
+}
+
+// backupCollector reports the current backup status at scrape time.
+type backupCollector struct {
+	backup *BackupService
+}
+
+var (
+	backupRunningDesc = prometheus.NewDesc("aeron_backup_running",
+		"Whether a backup is running (1) or not (0).", nil, nil)
+	backupLastResultDesc = prometheus.NewDesc("aeron_backup_last_result",
+		"Whether the most recent completed backup succeeded (1) or failed (0).", nil, nil)
+)
+
+// Describe implements prometheus.Collector.
+func (c *backupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- backupRunningDesc
+	ch <- backupLastResultDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *backupCollector) Collect(ch chan<- prometheus.Metric) {
+	status := c.backup.Status()
+	ch <- prometheus.MustNewConstMetric(backupRunningDesc, prometheus.GaugeValue, boolToFloat(status.Running))
+	if status.EndedAt != nil {
+		ch <- prometheus.MustNewConstMetric(backupLastResultDesc, prometheus.GaugeValue, boolToFloat(status.Success))
+	}
+}
+
+// boolToFloat converts a boolean to a 0 or 1 metric value.
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,393 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/async"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/database"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/image"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// Orders in which a bulk reoptimization processes the stored images.
+const (
+	ReoptimizeOrderTable         = "table" // artists first, then tracks, each ordered by ID
+	ReoptimizeOrderSmallestFirst = "smallest_first"
+	ReoptimizeOrderLargestFirst  = "largest_first"
+)
+
+// Reoptimize job states.
+const (
+	ReoptimizeRunning   = "running"
+	ReoptimizePaused    = "paused"
+	ReoptimizeCompleted = "completed"
+	ReoptimizeCancelled = "cancelled"
+	ReoptimizeFailed    = "failed"
+)
+
+// MaxReoptimizeWorkers limits the concurrency of a bulk reoptimization, so it cannot exhaust the connection pool.
+const MaxReoptimizeWorkers = 16
+
+// maxReoptimizeErrors limits the number of failed images listed on a job.
+const maxReoptimizeErrors = 50
+
+// ReoptimizeOptions configures a bulk reoptimization.
+type ReoptimizeOptions struct {
+	Workers int    // 0 uses image.reoptimize_workers
+	Order   string // empty uses ReoptimizeOrderTable
+}
+
+// ReoptimizeJob represents the progress of a bulk reoptimization.
+type ReoptimizeJob struct {
+	ID             string            `json:"id"`
+	Status         string            `json:"status"`
+	Order          string            `json:"order"`
+	Workers        int               `json:"workers"`
+	Total          int               `json:"total"`
+	Done           int               `json:"done"`
+	Updated        int               `json:"updated"`
+	Unchanged      int               `json:"unchanged"`
+	Failed         int               `json:"failed"`
+	BytesBefore    int64             `json:"bytes_before"`
+	BytesAfter     int64             `json:"bytes_after"`
+	BytesReclaimed int64             `json:"bytes_reclaimed"`
+	StartedAt      time.Time         `json:"started_at"`
+	PausedAt       *time.Time        `json:"paused_at,omitempty"`
+	EndedAt        *time.Time        `json:"ended_at,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	Errors         []ReoptimizeError `json:"errors"`
+}
+
+// ReoptimizeError describes an image that could not be reoptimized.
+type ReoptimizeError struct {
+	EntityType types.EntityType `json:"entity_type"`
+	ID         string           `json:"id"`
+	Error      string           `json:"error"`
+}
+
+// reoptimizeItem is a stored image queued for reoptimization.
+type reoptimizeItem struct {
+	entityType types.EntityType
+	ref        database.ImageRef
+}
+
+// ReoptimizeService reprocesses all stored images with the current image settings.
+// Only one reoptimization runs at a time; it can be paused, resumed, and cancelled.
+type ReoptimizeService struct {
+	media  *MediaService
+	config *config.Config
+	runner *async.Runner
+
+	mu     sync.Mutex
+	job    *ReoptimizeJob // the latest job, nil before the first run
+	cancel context.CancelFunc
+	resume chan struct{} // closed when a paused job resumes, nil while not paused
+}
+
+// newReoptimizeService creates a ReoptimizeService that reprocesses images through the given MediaService.
+func newReoptimizeService(media *MediaService, cfg *config.Config) *ReoptimizeService {
+	return &ReoptimizeService{
+		media:  media,
+		config: cfg,
+		runner: async.New(),
+	}
+}
+
+// Close cancels a running reoptimization and waits for it to stop.
+func (s *ReoptimizeService) Close() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+	s.runner.Close()
+}
+
+// Start begins a bulk reoptimization in the background.
+func (s *ReoptimizeService) Start(opts ReoptimizeOptions) (*ReoptimizeJob, error) {
+	order := cmp.Or(opts.Order, ReoptimizeOrderTable)
+	if !slices.Contains([]string{ReoptimizeOrderTable, ReoptimizeOrderSmallestFirst, ReoptimizeOrderLargestFirst}, order) {
+		return nil, types.NewValidationError("order", fmt.Sprintf("invalid order: use '%s', '%s' or '%s'",
+			ReoptimizeOrderTable, ReoptimizeOrderSmallestFirst, ReoptimizeOrderLargestFirst))
+	}
+	workers := cmp.Or(opts.Workers, s.config.Image.GetReoptimizeWorkers())
+	if workers < 1 || workers > MaxReoptimizeWorkers {
+		return nil, types.NewValidationError("workers", fmt.Sprintf("must be between 1 and %d", MaxReoptimizeWorkers))
+	}
+
+	if !s.runner.TryStart() {
+		return nil, types.NewConflictError("reoptimize", "image reoptimization is already running")
+	}
+
+	id, err := newImageJobID()
+	if err != nil {
+		s.runner.Done()
+		return nil, types.NewOperationError("create reoptimize job", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &ReoptimizeJob{
+		ID:        id,
+		Status:    ReoptimizeRunning,
+		Order:     order,
+		Workers:   workers,
+		StartedAt: time.Now(),
+		Errors:    []ReoptimizeError{},
+	}
+
+	s.mu.Lock()
+	s.job = job
+	s.cancel = cancel
+	s.resume = nil
+	snapshot := s.snapshotLocked()
+	s.mu.Unlock()
+
+	slog.Info("Image reoptimization started", "job", id, "order", order, "workers", workers)
+	s.runner.Go(func() {
+		defer cancel()
+		s.run(ctx, job)
+	})
+
+	return snapshot, nil
+}
+
+// Get returns the state of the latest reoptimization.
+func (s *ReoptimizeService) Get() (*ReoptimizeJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.job == nil {
+		return nil, types.NewNotFoundError("image reoptimization", "")
+	}
+	return s.snapshotLocked(), nil
+}
+
+// Pause stops handing out new images; images already being processed are finished.
+func (s *ReoptimizeService) Pause() (*ReoptimizeJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.job == nil || s.job.Status != ReoptimizeRunning {
+		return nil, types.NewConflictError("reoptimize", "no image reoptimization is running")
+	}
+	now := time.Now()
+	s.job.Status = ReoptimizePaused
+	s.job.PausedAt = &now
+	s.resume = make(chan struct{})
+	slog.Info("Image reoptimization paused", "job", s.job.ID, "done", s.job.Done)
+	return s.snapshotLocked(), nil
+}
+
+// Resume continues a paused reoptimization.
+func (s *ReoptimizeService) Resume() (*ReoptimizeJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.job == nil || s.job.Status != ReoptimizePaused {
+		return nil, types.NewConflictError("reoptimize", "no image reoptimization is paused")
+	}
+	s.job.Status = ReoptimizeRunning
+	s.job.PausedAt = nil
+	close(s.resume)
+	s.resume = nil
+	slog.Info("Image reoptimization resumed", "job", s.job.ID)
+	return s.snapshotLocked(), nil
+}
+
+// Cancel stops a running or paused reoptimization. Images being processed are interrupted,
+// so the job reaches the cancelled state shortly after.
+func (s *ReoptimizeService) Cancel() (*ReoptimizeJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.job == nil || (s.job.Status != ReoptimizeRunning && s.job.Status != ReoptimizePaused) {
+		return nil, types.NewConflictError("reoptimize", "no image reoptimization is running")
+	}
+	s.cancel()
+	slog.Info("Image reoptimization cancellation requested", "job", s.job.ID)
+	return s.snapshotLocked(), nil
+}
+
+// run lists the stored images and reprocesses them with the job's number of workers.
+func (s *ReoptimizeService) run(ctx context.Context, job *ReoptimizeJob) {
+	items, err := s.listItems(ctx, job.Order)
+	if err != nil {
+		slog.Error("Image reoptimization failed", "job", job.ID, "error", err)
+		s.finish(job, ReoptimizeFailed, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	job.Total = len(items)
+	s.mu.Unlock()
+
+	queue := make(chan reoptimizeItem)
+	var wg sync.WaitGroup
+	for range job.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				s.processItem(ctx, job, item)
+			}
+		}()
+	}
+
+dispatch:
+	for _, item := range items {
+		if err := s.waitWhilePaused(ctx); err != nil {
+			break
+		}
+		select {
+		case queue <- item:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	status := ReoptimizeCompleted
+	if ctx.Err() != nil {
+		status = ReoptimizeCancelled
+	}
+	s.finish(job, status, "")
+}
+
+// listItems returns the stored artist and track images in the requested order.
+func (s *ReoptimizeService) listItems(ctx context.Context, order string) ([]reoptimizeItem, error) {
+	var items []reoptimizeItem
+	for _, entityType := range []types.EntityType{types.EntityTypeArtist, types.EntityTypeTrack} {
+		listCtx, cancel := context.WithTimeout(ctx, s.config.API.GetRequestTimeout())
+		refs, err := s.media.repo.ListImageRefs(listCtx, types.Table(entityType))
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			items = append(items, reoptimizeItem{entityType: entityType, ref: ref})
+		}
+	}
+
+	switch order {
+	case ReoptimizeOrderSmallestFirst:
+		slices.SortStableFunc(items, func(a, b reoptimizeItem) int { return cmp.Compare(a.ref.Size, b.ref.Size) })
+	case ReoptimizeOrderLargestFirst:
+		slices.SortStableFunc(items, func(a, b reoptimizeItem) int { return cmp.Compare(b.ref.Size, a.ref.Size) })
+	}
+	return items, nil
+}
+
+// waitWhilePaused blocks while the job is paused. It returns an error when the job is cancelled.
+func (s *ReoptimizeService) waitWhilePaused(ctx context.Context) error {
+	s.mu.Lock()
+	resume := s.resume
+	s.mu.Unlock()
+
+	if resume == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// processItem reoptimizes a single image and records the result on the job.
+func (s *ReoptimizeService) processItem(ctx context.Context, job *ReoptimizeJob, item reoptimizeItem) {
+	itemCtx, cancel := context.WithTimeout(ctx, s.config.API.GetRequestTimeout())
+	defer cancel()
+
+	before, after, updated, err := s.media.reoptimizeImage(itemCtx, item.entityType, item.ref.ID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.Done++
+	if err != nil {
+		job.Failed++
+		if len(job.Errors) < maxReoptimizeErrors {
+			job.Errors = append(job.Errors, ReoptimizeError{EntityType: item.entityType, ID: item.ref.ID, Error: err.Error()})
+		}
+		return
+	}
+
+	job.BytesBefore += int64(before)
+	job.BytesAfter += int64(after)
+	job.BytesReclaimed = job.BytesBefore - job.BytesAfter
+	if updated {
+		job.Updated++
+	} else {
+		job.Unchanged++
+	}
+}
+
+// finish records the final state of a job.
+func (s *ReoptimizeService) finish(job *ReoptimizeJob, status, message string) {
+	s.mu.Lock()
+	now := time.Now()
+	job.Status = status
+	job.Error = message
+	job.PausedAt = nil
+	job.EndedAt = &now
+	s.resume = nil
+	done, updated, failed, reclaimed := job.Done, job.Updated, job.Failed, job.BytesReclaimed
+	s.mu.Unlock()
+
+	slog.Info("Image reoptimization finished", "job", job.ID, "status", status, "done", done, "updated", updated, "failed", failed, "bytesReclaimed", reclaimed)
+}
+
+// snapshotLocked returns a copy of the latest job that is safe to use without locking. Caller must hold s.mu.
+func (s *ReoptimizeService) snapshotLocked() *ReoptimizeJob {
+	job := *s.job
+	job.Errors = slices.Clone(s.job.Errors)
+	return &job
+}
+
+// reoptimizeImage reprocesses an entity's stored image with the current image settings and stores the result
+// when it differs. It returns the stored size before and after, and whether the image was rewritten.
+func (s *MediaService) reoptimizeImage(ctx context.Context, entityType types.EntityType, id string) (before, after int, updated bool, err error) {
+	table := types.Table(entityType)
+	stored, err := s.repo.GetImage(ctx, table, id)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	// The stored image was accepted before, so only the optimization settings apply.
+	imgConfig := s.imageConfig()
+	imgConfig.RejectSmaller = false
+	imgConfig.MinSourceBytes = 0
+	imgConfig.PerceptualHash = false
+	if imgConfig.NotSmallerPolicy == image.NotSmallerReject {
+		imgConfig.NotSmallerPolicy = image.NotSmallerKeep
+	}
+
+	result, err := image.Process(stored, imgConfig)
+	if err != nil {
+		return 0, 0, false, types.NewValidationError("image", fmt.Sprintf("processing failed: %v", err))
+	}
+	if bytes.Equal(result.Data, stored) {
+		return len(stored), len(stored), false, nil
+	}
+
+	if err := s.repo.UpdateImage(ctx, table, id, result.Data); err != nil {
+		return 0, 0, false, err
+	}
+	if s.config.Image.StoreVariants {
+		if err := s.storeImageVariants(ctx, table, id, result.Data, imgConfig); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	return len(stored), len(result.Data), true, nil
+}
@@ -0,0 +1,291 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// customFormatMagic is the header every pg_dump custom-format file starts with.
+// Backups without it are treated as plain SQL and restored with psql.
+var customFormatMagic = []byte("PGDMP")
+
+// maxRestoreStderr is the number of bytes of restore output kept in the result.
+// Restores stop at the first error, so the end of the output explains a failure.
+const maxRestoreStderr = 4096
+
+// RestoreRequest selects the backup to restore into the configured database.
+type RestoreRequest struct {
+	Filename string `json:"filename"`
+	Clean    bool   `json:"clean"` // drop existing objects before recreating them
+}
+
+// RestoreResult describes a completed restore.
+type RestoreResult struct {
+	Filename   string `json:"filename"`
+	Tool       string `json:"tool"` // pg_restore or psql
+	Clean      bool   `json:"clean"`
+	DurationMs int64  `json:"duration_ms"`
+	Stderr     string `json:"stderr,omitempty"` // at most the last 4 KB
+}
+
+// Restore loads a backup into the configured database and schema, blocking until completion.
+// Custom-format dumps are restored with pg_restore in a single transaction, plain SQL dumps with psql,
+// after decompressing them when they were post-compressed.
+// A restore claims the same runner as backups, so neither can start while the other is running.
+func (s *BackupService) Restore(req RestoreRequest) (*RestoreResult, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	if err := validateBackupFilename(req.Filename); err != nil {
+		return nil, err
+	}
+
+	name, err := s.locateBackup(req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.restoreFiles(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if isEncryptedBackup(name) && len(s.identities) == 0 {
+		return nil, types.NewValidationError("filename", "backup is encrypted: restoring it requires the private key in backup.encryption.identity_file")
+	}
+
+	plain, err := s.isPlainDump(name)
+	if err != nil {
+		return nil, types.NewOperationError("restore backup", err)
+	}
+	tool := s.pgRestorePath
+	if plain {
+		if req.Clean {
+			return nil, types.NewValidationError("clean", "clean is only supported for custom-format backups")
+		}
+		if tool, err = resolveToolPath("", "psql"); err != nil {
+			return nil, err
+		}
+	}
+
+	if !s.runner.TryStart() {
+		return nil, types.NewConflictError("restore", "backup or restore already in progress")
+	}
+	defer s.runner.Done()
+
+	ctx, cancel := s.runner.Context(s.config.Backup.GetTimeout())
+	defer cancel()
+
+	logger := backupLog(backupPhaseRestore, req.Filename)
+	logger.Warn("Restore started", "clean", req.Clean, "tool", filepath.Base(tool))
+
+	stderr := &tailBuffer{limit: maxRestoreStderr}
+	start := time.Now()
+	for _, file := range files {
+		if err := s.restoreFile(ctx, logger, stderr, tool, file, plain, req.Clean); err != nil {
+			duration := time.Since(start)
+			errMsg := restoreErrorMessage(ctx, err, stderr.String(), duration)
+			logger.Error("Restore failed", "error", errMsg, logKeyBackupDuration, duration.Milliseconds())
+			return nil, types.NewOperationError("restore backup", errors.New(errMsg))
+		}
+	}
+	duration := time.Since(start)
+
+	logger.Info("Restore completed", logKeyBackupDuration, duration.Milliseconds())
+
+	return &RestoreResult{
+		Filename:   req.Filename,
+		Tool:       filepath.Base(tool),
+		Clean:      req.Clean,
+		DurationMs: duration.Milliseconds(),
+		Stderr:     strings.TrimSpace(stderr.String()),
+	}, nil
+}
+
+// restoreFiles returns the dump files that make up a backup, in restore order.
+// The name is the path of the backup relative to the backup directory.
+func (s *BackupService) restoreFiles(name string) ([]string, error) {
+	fullPath := filepath.Join(s.config.Backup.GetPath(), name)
+	if !IsTableSet(name) {
+		return []string{fullPath}, nil
+	}
+
+	entries, err := fs.ReadDir(s.backupRoot.FS(), name)
+	if err != nil {
+		return nil, types.NewOperationError("restore backup", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, filepath.Join(fullPath, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, types.NewValidationError("filename", "per-table backup contains no table dumps")
+	}
+	return files, nil
+}
+
+// isPlainDump reports whether a backup is a plain SQL dump, by its extension or a missing custom-format header.
+// Per-table backups are always written by pg_dump in custom format. Encrypted backups are judged by their extension only.
+func (s *BackupService) isPlainDump(name string) (bool, error) {
+	if IsTableSet(name) {
+		return false, nil
+	}
+	if format, _ := detectBackupFormat(name); format == BackupFormatPlain || isEncryptedBackup(name) {
+		return format == BackupFormatPlain, nil
+	}
+
+	f, err := s.backupRoot.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	header := make([]byte, len(customFormatMagic))
+	if _, err := io.ReadFull(f, header); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	return !bytes.Equal(header, customFormatMagic), nil
+}
+
+// connectionArgs returns the libpq connection arguments shared by pg_restore and psql.
+func (s *BackupService) connectionArgs() []string {
+	return []string{
+		"--host=" + s.config.Database.Host,
+		"--port=" + s.config.Database.Port,
+		"--username=" + s.config.Database.User,
+		"--dbname=" + s.config.Database.Name,
+		"--no-password",
+	}
+}
+
+// buildPgRestoreArgs constructs pg_restore arguments that restore only the configured schema.
+// An empty file makes pg_restore read the dump from stdin.
+func (s *BackupService) buildPgRestoreArgs(file string, clean bool) []string {
+	args := append(s.connectionArgs(),
+		"--schema="+s.config.Database.Schema,
+		"--single-transaction",
+		"--verbose",
+	)
+	if clean {
+		args = append(args, "--clean", "--if-exists")
+	}
+	if file == "" {
+		return args
+	}
+	return append(args, file)
+}
+
+// buildPsqlArgs constructs psql arguments that run a plain SQL dump and stop at the first error.
+func (s *BackupService) buildPsqlArgs(file string) []string {
+	return append(s.connectionArgs(),
+		"--single-transaction",
+		"--set=ON_ERROR_STOP=1",
+		"--echo-errors",
+		"--quiet",
+		"--file="+file,
+	)
+}
+
+// restoreFile restores one dump file. Encrypted and post-compressed dumps are decrypted and decompressed
+// into the standard input of the restore tool.
+func (s *BackupService) restoreFile(ctx context.Context, logger *slog.Logger, stderr io.Writer, tool, file string, plain, clean bool) error {
+	_, postCompress := detectBackupFormat(file)
+	if postCompress == "" && !isEncryptedBackup(file) {
+		if plain {
+			return s.runRestoreCommand(ctx, logger, stderr, nil, tool, s.buildPsqlArgs(file))
+		}
+		return s.runRestoreCommand(ctx, logger, stderr, nil, tool, s.buildPgRestoreArgs(file, clean))
+	}
+
+	r, err := s.openBackupFile(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	var stdin io.Reader = r
+	if postCompress != "" {
+		decompressor, err := newPostDecompressor(r, postCompress)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = decompressor.Close() }()
+		stdin = decompressor
+	}
+
+	if plain {
+		return s.runRestoreCommand(ctx, logger, stderr, stdin, tool, s.buildPsqlArgs("-"))
+	}
+	return s.runRestoreCommand(ctx, logger, stderr, stdin, tool, s.buildPgRestoreArgs("", clean))
+}
+
+// runRestoreCommand runs a restore tool, logging each line of its output as it arrives.
+// When stdin is not nil it is fed to the tool, for psql reading a decompressed dump.
+func (s *BackupService) runRestoreCommand(ctx context.Context, logger *slog.Logger, stderr io.Writer, stdin io.Reader, tool string, args []string) error {
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Stdin = stdin
+	cmd.Env = append(os.Environ(),
+		"PGPASSWORD="+s.config.Database.Password,
+		"PGOPTIONS=-c search_path="+s.config.Database.Schema,
+	)
+
+	pipe, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(pipe)
+	for scanner.Scan() {
+		line := scanner.Text()
+		logger.Info("Restore progress", "output", line)
+		_, _ = io.WriteString(stderr, line+"\n")
+	}
+	// Drain whatever the scanner could not read, so the tool never blocks on a full pipe.
+	_, _ = io.Copy(io.Discard, pipe)
+
+	return cmd.Wait()
+}
+
+// restoreErrorMessage explains why a restore command failed.
+func restoreErrorMessage(ctx context.Context, err error, output string, duration time.Duration) string {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Sprintf("restore timeout after %s (configure backup.timeout_minutes)", duration.Round(time.Second))
+	case errors.Is(ctx.Err(), context.Canceled):
+		return "restore cancelled"
+	}
+
+	// Verbose progress lines precede the failure, so report only the error lines.
+	var errorLines []string
+	for line := range strings.Lines(output) {
+		if strings.Contains(line, "error:") || strings.Contains(line, "ERROR:") {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	}
+	switch {
+	case len(errorLines) > 0:
+		return strings.Join(errorLines, "; ")
+	case strings.TrimSpace(output) != "":
+		return strings.TrimSpace(output)
+	default:
+		return err.Error()
+	}
+}
@@ -2,35 +2,85 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
 )
 
+// Multipart upload settings. Each part is retried on its own, so a failure late in a large
+// upload does not require sending the whole file again.
+const (
+	s3PartSize        = 16 * 1024 * 1024
+	s3PartMaxAttempts = 3
+	s3PartRetryDelay  = 2 * time.Second
+)
+
+// s3PartError identifies the part of a multipart upload that could not be uploaded.
+type s3PartError struct {
+	part int32
+	err  error
+}
+
+// Error implements the error interface.
+func (e *s3PartError) Error() string {
+	return fmt.Sprintf("part %d: %v", e.part, e.err)
+}
+
+// Unwrap implements error unwrapping for errors.Is and errors.As.
+func (e *s3PartError) Unwrap() error {
+	return e.err
+}
+
 // s3Service manages uploads and deletions of backup files to S3-compatible storage.
 type s3Service struct {
-	uploader *manager.Uploader
-	client   *s3.Client
-	bucket   string
-	prefix   string
+	client         *s3.Client
+	bucket         string
+	prefix         string
+	uploadSlots    chan struct{} // limits concurrent uploads
+	bytesPerSecond int64         // upload bandwidth limit, 0 for unlimited
 }
 
-// newS3Service creates an S3 client for backup synchronization, or returns nil if disabled.
-func newS3Service(cfg *config.S3Config) (*s3Service, error) {
-	if !cfg.Enabled {
-		return nil, nil
+// newS3Service creates an S3 client for backup synchronization.
+func newS3Service(cfg *config.S3Config) *s3Service {
+	client := newS3Client(cfg)
+
+	slog.Info("S3 sync enabled",
+		"bucket", cfg.Bucket,
+		"region", cfg.Region,
+		"endpoint", cfg.Endpoint,
+		"prefix", cfg.GetPathPrefix(),
+		"max_concurrent_uploads", cfg.GetMaxConcurrentUploads(),
+		"max_upload_bytes_per_second", cfg.MaxUploadBytesPerSecond)
+
+	return &s3Service{
+		client:         client,
+		bucket:         cfg.Bucket,
+		prefix:         cfg.GetPathPrefix(),
+		uploadSlots:    make(chan struct{}, cfg.GetMaxConcurrentUploads()),
+		bytesPerSecond: cfg.MaxUploadBytesPerSecond,
 	}
+}
 
-	client := s3.New(s3.Options{
+// newS3Client creates an S3 client using the static credentials from the configuration.
+func newS3Client(cfg *config.S3Config) *s3.Client {
+	return s3.New(s3.Options{
 		Region:       cfg.Region,
 		BaseEndpoint: ptrOrNil(cfg.Endpoint),
 		UsePathStyle: cfg.ForcePathStyle,
@@ -40,19 +90,6 @@ func newS3Service(cfg *config.S3Config) (*s3Service, error) {
 			"",
 		),
 	})
-
-	slog.Info("S3 sync enabled",
-		"bucket", cfg.Bucket,
-		"region", cfg.Region,
-		"endpoint", cfg.Endpoint,
-		"prefix", cfg.GetPathPrefix())
-
-	return &s3Service{
-		uploader: manager.NewUploader(client),
-		client:   client,
-		bucket:   cfg.Bucket,
-		prefix:   cfg.GetPathPrefix(),
-	}, nil
 }
 
 // ptrOrNil returns nil for empty strings, otherwise a pointer to the string.
@@ -63,8 +100,17 @@ func ptrOrNil(s string) *string {
 	return aws.String(s)
 }
 
-// upload transfers a backup file to S3 storage.
+// upload transfers a backup file to S3 storage as a multipart upload.
+// Failed parts are retried individually and the completed object is checked against the uploaded data.
+// Uploads wait for a free slot when the concurrency limit is reached.
 func (s *s3Service) upload(ctx context.Context, filename, localPath string) (err error) {
+	select {
+	case s.uploadSlots <- struct{}{}:
+		defer func() { <-s.uploadSlots }()
+	case <-ctx.Done():
+		return types.NewOperationError("S3 upload", fmt.Errorf("waiting for upload slot: %w", ctx.Err()))
+	}
+
 	file, err := os.Open(localPath)
 	if err != nil {
 		return types.NewOperationError("S3 upload", fmt.Errorf("open file: %w", err))
@@ -78,22 +124,155 @@ func (s *s3Service) upload(ctx context.Context, filename, localPath string) (err
 	key := s.prefix + filename
 	start := time.Now()
 
-	_, err = s.uploader.Upload(ctx, &s3.PutObjectInput{
+	// The rate limit applies to the part requests on the wire, shared by all parts and retries of this upload.
+	var partOptions []func(*s3.Options)
+	if s.bytesPerSecond > 0 {
+		partOptions = append(partOptions, (&uploadThrottle{bytesPerSecond: s.bytesPerSecond}).apply)
+	}
+
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
-		Body:   file,
 	})
 	if err != nil {
 		return types.NewOperationError("S3 upload", err)
 	}
+	uploadID := created.UploadId
+
+	parts, partSums, err := s.uploadParts(ctx, key, uploadID, file, partOptions)
+	if err != nil {
+		s.abortUpload(ctx, key, uploadID)
+		return types.NewOperationError("S3 upload", err)
+	}
+
+	completed, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		s.abortUpload(ctx, key, uploadID)
+		return types.NewOperationError("S3 upload", err)
+	}
+
+	if err := verifyMultipartETag(aws.ToString(completed.ETag), partSums); err != nil {
+		if deleteErr := s.delete(context.WithoutCancel(ctx), filename); deleteErr != nil {
+			slog.Warn("Failed to remove corrupt S3 backup", "key", key, "error", deleteErr)
+		}
+		return types.NewOperationError("S3 upload", err)
+	}
 
-	slog.Info("Backup uploaded to S3",
+	backupLog(backupPhaseS3Sync, filename).Info("Backup uploaded to S3",
 		"key", key,
-		"duration", time.Since(start).Round(time.Millisecond))
+		"parts", len(parts),
+		logKeyBackupDuration, time.Since(start).Milliseconds())
 
 	return nil
 }
 
+// uploadParts reads body in s3PartSize chunks and uploads each as a part of the multipart upload.
+// It returns the completed parts and the MD5 sum of each part.
+func (s *s3Service) uploadParts(ctx context.Context, key string, uploadID *string, body io.Reader, optFns []func(*s3.Options)) ([]s3types.CompletedPart, [][]byte, error) {
+	var parts []s3types.CompletedPart
+	var partSums [][]byte
+	buf := make([]byte, s3PartSize)
+
+	for partNumber := int32(1); ; partNumber++ {
+		n, readErr := io.ReadFull(body, buf)
+		if readErr == io.EOF && partNumber > 1 {
+			break
+		}
+		if readErr != nil && readErr != io.EOF && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return nil, nil, &s3PartError{part: partNumber, err: fmt.Errorf("read file: %w", readErr)}
+		}
+
+		sum := md5.Sum(buf[:n])
+		etag, err := s.uploadPart(ctx, key, uploadID, partNumber, buf[:n], sum[:], optFns)
+		if err != nil {
+			return nil, nil, &s3PartError{part: partNumber, err: err}
+		}
+		parts = append(parts, s3types.CompletedPart{ETag: etag, PartNumber: aws.Int32(partNumber)})
+		partSums = append(partSums, sum[:])
+
+		// A short read means this was the last part.
+		if readErr != nil {
+			break
+		}
+	}
+
+	return parts, partSums, nil
+}
+
+// uploadPart uploads a single part with its Content-MD5 so S3 rejects corrupted transfers,
+// retrying up to s3PartMaxAttempts times with a growing delay.
+func (s *s3Service) uploadPart(ctx context.Context, key string, uploadID *string, partNumber int32, data, sum []byte, optFns []func(*s3.Options)) (*string, error) {
+	var err error
+	for attempt := 1; attempt <= s3PartMaxAttempts; attempt++ {
+		var out *s3.UploadPartOutput
+		out, err = s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(key),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(partNumber),
+			Body:          bytes.NewReader(data),
+			ContentLength: aws.Int64(int64(len(data))),
+			ContentMD5:    aws.String(base64.StdEncoding.EncodeToString(sum)),
+		}, optFns...)
+		if err == nil {
+			return out.ETag, nil
+		}
+		if attempt == s3PartMaxAttempts {
+			break
+		}
+
+		slog.Warn("S3 part upload failed, retrying", "key", key, "part", partNumber, "attempt", attempt, "error", err)
+		timer := time.NewTimer(s3PartRetryDelay * time.Duration(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", s3PartMaxAttempts, err)
+}
+
+// abortUpload discards the parts of a failed multipart upload so they do not linger in the bucket.
+func (s *s3Service) abortUpload(ctx context.Context, key string, uploadID *string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	if _, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	}); err != nil {
+		slog.Warn("Failed to abort S3 multipart upload", "key", key, "error", err)
+	}
+}
+
+// verifyMultipartETag checks the ETag of a completed multipart upload against the uploaded parts.
+// S3 computes it as the MD5 of the concatenated part MD5s followed by the part count; stores that
+// return an ETag in another format are not checked beyond the per-part Content-MD5.
+func verifyMultipartETag(etag string, partSums [][]byte) error {
+	etag = strings.Trim(etag, `"`)
+	if !strings.Contains(etag, "-") {
+		slog.Debug("S3 ETag is not a multipart digest, skipping integrity check", "etag", etag)
+		return nil
+	}
+
+	hash := md5.New()
+	for _, sum := range partSums {
+		hash.Write(sum)
+	}
+	expected := fmt.Sprintf("%x-%d", hash.Sum(nil), len(partSums))
+	if etag != expected {
+		return fmt.Errorf("integrity check failed: ETag %s does not match expected %s", etag, expected)
+	}
+	return nil
+}
+
 // delete removes a backup file from S3 storage.
 func (s *s3Service) delete(ctx context.Context, filename string) error {
 	key := s.prefix + filename
@@ -109,3 +288,98 @@ func (s *s3Service) delete(ctx context.Context, filename string) error {
 	slog.Info("Backup deleted from S3", "key", key)
 	return nil
 }
+
+// list returns the objects whose key starts with prefix.
+func (s *s3Service) list(ctx context.Context, prefix string) ([]blobObject, error) {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix + prefix),
+	})
+
+	var objects []blobObject
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, types.NewOperationError("S3 list", err)
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, blobObject{
+				key:          strings.TrimPrefix(aws.ToString(obj.Key), s.prefix),
+				lastModified: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// uploadThrottle paces the request bodies of one upload to an average rate. Idle time, such as a retry
+// delay, does not build up credit, so sending resumes at the configured rate instead of in a burst.
+type uploadThrottle struct {
+	bytesPerSecond int64
+
+	mu   sync.Mutex
+	next time.Time // when the data sent so far is paid for
+}
+
+// apply sends the request bodies of an operation through the throttle.
+func (t *uploadThrottle) apply(o *s3.Options) {
+	o.HTTPClient = &throttledHTTPClient{client: o.HTTPClient, throttle: t}
+}
+
+// wait blocks until n more bytes fit within the rate.
+func (t *uploadThrottle) wait(ctx context.Context, n int) error {
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	t.next = t.next.Add(time.Duration(float64(n) / float64(t.bytesPerSecond) * float64(time.Second)))
+	wait := time.Until(t.next)
+	t.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledHTTPClient sends request bodies through an uploadThrottle.
+type throttledHTTPClient struct {
+	client   s3.HTTPClient
+	throttle *uploadThrottle
+}
+
+// Do implements s3.HTTPClient.
+func (c *throttledHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = &throttledReader{ctx: req.Context(), ReadCloser: req.Body, throttle: c.throttle}
+	}
+	return c.client.Do(req)
+}
+
+// throttledReader limits the rate at which data is read from a request body.
+type throttledReader struct {
+	io.ReadCloser
+	ctx      context.Context
+	throttle *uploadThrottle
+}
+
+// Read implements io.Reader, sleeping as needed to stay within the configured rate.
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > t.throttle.bytesPerSecond {
+		p = p[:t.throttle.bytesPerSecond]
+	}
+
+	n, err := t.ReadCloser.Read(p)
+	if waitErr := t.throttle.wait(t.ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	cron "github.com/netresearch/go-cron"
@@ -19,25 +21,52 @@ type Scheduler struct {
 	cron    *cron.Cron
 	service *AeronService
 	jobs    []string // names of registered jobs for logging
+
+	stop          chan struct{} // closed by Stop to end waits for a running backup
+	backupPending atomic.Bool   // a scheduled backup is waiting for a running backup to finish
+
+	backupMu     sync.Mutex
+	backupPaused bool // the backup job is removed from cron until resumed
 }
 
+// backupJobName is the cron entry name of the scheduled backup job.
+const backupJobName = "backup"
+
+// BackupSchedule describes the scheduled backup job.
+type BackupSchedule struct {
+	Enabled       bool       `json:"enabled"` // backup.scheduler.enabled; pausing does not change it
+	Schedule      string     `json:"schedule,omitempty"`
+	Paused        bool       `json:"paused"`
+	NextRun       *time.Time `json:"next_run,omitempty"` // absent while paused
+	OverlapPolicy string     `json:"overlap_policy,omitempty"`
+}
+
+// Backup overlap policies for backup.overlap_policy.
+const (
+	backupOverlapSkip           = "skip"
+	backupOverlapQueue          = "queue"
+	backupOverlapCancelPrevious = "cancel-previous"
+)
+
+// backupOverlapPollInterval is how often a waiting scheduled backup checks whether the running backup has ended.
+const backupOverlapPollInterval = time.Second
+
 // NewScheduler creates a scheduler and registers all enabled scheduled jobs.
-// The scheduler uses the system's local timezone (set via TZ environment variable).
+// Jobs use their configured timezone, or the system's local timezone (set via TZ environment variable).
 func NewScheduler(svc *AeronService) (*Scheduler, error) {
 	cfg := svc.Config()
 
 	slog.Info("Scheduler using system timezone", "timezone", time.Local.String())
 
-	c := cron.New(
-		cron.WithLocation(time.Local),
-		cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)),
-	)
+	// Jobs are not wrapped in SkipIfStillRunning: a scheduled backup that finds another backup
+	// running applies backup.overlap_policy instead, and maintenance jobs return immediately.
+	c := cron.New(cron.WithLocation(time.Local))
 
-	s := &Scheduler{cron: c, service: svc}
+	s := &Scheduler{cron: c, service: svc, stop: make(chan struct{})}
 
 	// Register backup job if enabled
 	if cfg.Backup.Enabled && cfg.Backup.Scheduler.Enabled {
-		if err := s.addJob(cfg.Backup.Scheduler, "backup", s.runBackup); err != nil {
+		if err := s.addJob(cfg.Backup.Scheduler, backupJobName, s.runBackup); err != nil {
 			return nil, err
 		}
 	}
@@ -53,16 +82,25 @@ func NewScheduler(svc *AeronService) (*Scheduler, error) {
 }
 
 // addJob registers a scheduled job using the scheduler's configured timezone.
+// A job without a timezone of its own runs in the system timezone.
 func (s *Scheduler) addJob(cfg config.SchedulerConfig, name string, job func()) error {
-	if _, err := s.cron.AddFunc(cfg.Schedule, job); err != nil {
+	if _, err := s.cron.AddFunc(cronSpec(cfg), job, cron.WithName(name)); err != nil {
 		return err
 	}
 
 	s.jobs = append(s.jobs, name)
-	slog.Info("Scheduled job registered", "job", name, "schedule", cfg.Schedule)
+	slog.Info("Scheduled job registered", "job", name, "schedule", cfg.Schedule, "timezone", cfg.GetLocation().String())
 	return nil
 }
 
+// cronSpec returns the cron specification for a job, prefixed with its timezone when one is configured.
+func cronSpec(cfg config.SchedulerConfig) string {
+	if cfg.Timezone == "" {
+		return cfg.Schedule
+	}
+	return "CRON_TZ=" + cfg.Timezone + " " + cfg.Schedule
+}
+
 // Start activates all scheduled jobs.
 func (s *Scheduler) Start() {
 	if len(s.jobs) == 0 {
@@ -74,6 +112,7 @@ func (s *Scheduler) Start() {
 
 // Stop halts the scheduler and waits for running jobs to finish.
 func (s *Scheduler) Stop() context.Context {
+	close(s.stop)
 	if len(s.jobs) == 0 {
 		return context.Background()
 	}
@@ -86,9 +125,81 @@ func (s *Scheduler) HasJobs() bool {
 	return len(s.jobs) > 0
 }
 
+// backupScheduled reports whether scheduled backups are configured.
+func (s *Scheduler) backupScheduled() bool {
+	cfg := s.service.Config().Backup
+	return cfg.Enabled && cfg.Scheduler.Enabled
+}
+
+// BackupSchedule returns the configuration and runtime state of the scheduled backup job.
+func (s *Scheduler) BackupSchedule() *BackupSchedule {
+	cfg := s.service.Config().Backup
+	if !s.backupScheduled() {
+		return &BackupSchedule{}
+	}
+
+	s.backupMu.Lock()
+	defer s.backupMu.Unlock()
+
+	schedule := &BackupSchedule{
+		Enabled:       true,
+		Schedule:      cfg.Scheduler.Schedule,
+		Paused:        s.backupPaused,
+		OverlapPolicy: cfg.GetOverlapPolicy(),
+	}
+	if entry := s.cron.EntryByName(backupJobName); entry.Valid() && !entry.Next.IsZero() {
+		next := entry.Next
+		schedule.NextRun = &next
+	}
+	return schedule
+}
+
+// PauseBackups removes the scheduled backup job from cron until ResumeBackups is called.
+// A running backup is not affected. Pausing is not persisted; after a restart backups are scheduled again.
+func (s *Scheduler) PauseBackups() (*BackupSchedule, error) {
+	if !s.backupScheduled() {
+		return nil, types.NewConfigError("backup.scheduler.enabled", "backup scheduler is not enabled")
+	}
+
+	s.backupMu.Lock()
+	if !s.backupPaused {
+		s.cron.RemoveByName(backupJobName)
+		s.backupPaused = true
+		slog.Info("Scheduled backups paused")
+	}
+	s.backupMu.Unlock()
+
+	return s.BackupSchedule(), nil
+}
+
+// ResumeBackups adds the scheduled backup job back to cron after PauseBackups.
+func (s *Scheduler) ResumeBackups() (*BackupSchedule, error) {
+	if !s.backupScheduled() {
+		return nil, types.NewConfigError("backup.scheduler.enabled", "backup scheduler is not enabled")
+	}
+
+	s.backupMu.Lock()
+	if s.backupPaused {
+		schedule := s.service.Config().Backup.Scheduler
+		if _, err := s.cron.AddFunc(cronSpec(schedule), s.runBackup, cron.WithName(backupJobName)); err != nil {
+			s.backupMu.Unlock()
+			return nil, types.NewOperationError("resume scheduled backups", err)
+		}
+		s.backupPaused = false
+		slog.Info("Scheduled backups resumed", "schedule", schedule.Schedule)
+	}
+	s.backupMu.Unlock()
+
+	return s.BackupSchedule(), nil
+}
+
 // runBackup performs a scheduled backup.
 func (s *Scheduler) runBackup() {
 	cfg := s.service.Config().Backup
+	if s.service.Backup.IsRunning() && !s.resolveBackupOverlap(&cfg) {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.GetTimeout())
 	defer cancel()
 
@@ -100,11 +211,58 @@ func (s *Scheduler) runBackup() {
 	}
 }
 
+// resolveBackupOverlap applies backup.overlap_policy when a scheduled backup finds another backup running.
+// It reports whether the scheduled backup should run now.
+func (s *Scheduler) resolveBackupOverlap(cfg *config.BackupConfig) bool {
+	switch cfg.GetOverlapPolicy() {
+	case backupOverlapQueue:
+		if !s.backupPending.CompareAndSwap(false, true) {
+			slog.Info("Scheduled backup skipped (another scheduled backup is already queued)")
+			return false
+		}
+		defer s.backupPending.Store(false)
+		slog.Info("Scheduled backup queued until the running backup finishes")
+	case backupOverlapCancelPrevious:
+		if s.service.Backup.Cancel() {
+			slog.Warn("Running backup cancelled for scheduled backup")
+		}
+	default: // backupOverlapSkip
+		slog.Info("Scheduled backup skipped (previous backup still running)")
+		return false
+	}
+
+	if !s.waitForBackup(cfg.GetOverlapGrace()) {
+		slog.Warn("Scheduled backup skipped (running backup did not end within the grace period)", "grace", cfg.GetOverlapGrace())
+		return false
+	}
+	return true
+}
+
+// waitForBackup waits until no backup is running.
+// Returns false if the grace period expires or the scheduler stops first.
+func (s *Scheduler) waitForBackup(grace time.Duration) bool {
+	deadline := time.NewTimer(grace)
+	defer deadline.Stop()
+	ticker := time.NewTicker(backupOverlapPollInterval)
+	defer ticker.Stop()
+
+	for s.service.Backup.IsRunning() {
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return false
+		case <-s.stop:
+			return false
+		}
+	}
+	return true
+}
+
 // runMaintenance performs scheduled VACUUM ANALYZE on tables that need it.
 func (s *Scheduler) runMaintenance() {
 	slog.Info("Scheduled maintenance started")
 
-	if err := s.service.Maintenance.StartVacuum(VacuumOptions{Analyze: true}); err != nil {
+	if err := s.service.Maintenance.StartVacuum(context.Background(), VacuumOptions{Analyze: true}); err != nil {
 		var conflictErr *types.ConflictError
 		if errors.As(err, &conflictErr) {
 			slog.Info("Scheduled maintenance skipped (already running)")
@@ -0,0 +1,91 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/database"
+)
+
+// Schema issue kinds.
+const (
+	SchemaIssueMissing      = "missing"
+	SchemaIssueTypeMismatch = "type_mismatch"
+)
+
+// SchemaCheckResult reports whether the database schema matches the columns the application depends on.
+type SchemaCheckResult struct {
+	SchemaName     string        `json:"schema_name"`
+	Valid          bool          `json:"valid"`
+	ColumnsChecked int           `json:"columns_checked"`
+	Issues         []SchemaIssue `json:"issues"`
+	CheckedAt      time.Time     `json:"checked_at"`
+}
+
+// SchemaIssue describes a single missing or mismatched column.
+type SchemaIssue struct {
+	Table        string `json:"table"`
+	Column       string `json:"column"`
+	Problem      string `json:"problem"`
+	ExpectedType string `json:"expected_type"`
+	ActualType   string `json:"actual_type,omitempty"`
+	Optional     bool   `json:"optional"`
+}
+
+// CheckSchema compares the artist, track, and playlist tables against the columns used by the application's queries.
+func (s *MaintenanceService) CheckSchema(ctx context.Context) (*SchemaCheckResult, error) {
+	var tables []string
+	for _, col := range database.ExpectedColumns {
+		if !slices.Contains(tables, col.Table) {
+			tables = append(tables, col.Table)
+		}
+	}
+
+	columns, err := s.repo.GetColumns(ctx, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	actual := make(map[string]string, len(columns))
+	for _, col := range columns {
+		actual[col.Table+"."+col.Column] = col.DataType
+	}
+
+	result := &SchemaCheckResult{
+		SchemaName:     s.repo.Schema(),
+		ColumnsChecked: len(database.ExpectedColumns),
+		Issues:         []SchemaIssue{},
+		CheckedAt:      time.Now(),
+	}
+
+	for _, expected := range database.ExpectedColumns {
+		dataType, exists := actual[expected.Table+"."+expected.Column]
+		switch {
+		case !exists:
+			result.Issues = append(result.Issues, SchemaIssue{
+				Table:        expected.Table,
+				Column:       expected.Column,
+				Problem:      SchemaIssueMissing,
+				ExpectedType: string(expected.Type),
+				Optional:     database.IsOptionalColumn(expected.Table, expected.Column),
+			})
+		case !expected.Type.Matches(dataType):
+			result.Issues = append(result.Issues, SchemaIssue{
+				Table:        expected.Table,
+				Column:       expected.Column,
+				Problem:      SchemaIssueTypeMismatch,
+				ExpectedType: string(expected.Type),
+				ActualType:   dataType,
+				Optional:     database.IsOptionalColumn(expected.Table, expected.Column),
+			})
+		}
+	}
+
+	// Missing optional columns are tolerated by the queries, so they do not invalidate the schema.
+	result.Valid = !slices.ContainsFunc(result.Issues, func(issue SchemaIssue) bool {
+		return !issue.Optional || issue.Problem != SchemaIssueMissing
+	})
+	return result, nil
+}
@@ -2,38 +2,77 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"encoding/base64"
+	"fmt"
 	"io"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/database"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // AeronService is the main service that provides access to all sub-services.
 type AeronService struct {
 	Media       *MediaService
+	ImageJobs   *ImageJobService
+	Reoptimize  *ReoptimizeService
 	Backup      *BackupService
 	Maintenance *MaintenanceService
 
+	// Metrics holds the Prometheus metrics of the services, for the API to add its own and serve.
+	Metrics *prometheus.Registry
+
 	repo   *database.Repository
 	config *config.Config
 }
 
 // New creates a new AeronService instance with all sub-services.
-func New(db *sqlx.DB, cfg *config.Config) (*AeronService, error) {
-	repo := database.NewRepository(db, cfg.Database.Schema)
+// healthDB is a small dedicated pool for health checks and may be nil to share db.
+func New(db, healthDB *sqlx.DB, cfg *config.Config) (*AeronService, error) {
+	repo := database.NewRepository(db, healthDB, cfg.Database.Schema)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := repo.DetectOptionalColumns(ctx); err != nil {
+		slog.Warn("Could not detect optional columns, assuming all exist", "error", err)
+	}
+
+	if cfg.Image.StoreVariants {
+		if err := prepareImageVariantTable(ctx, repo, cfg.Image.CreateVariantTable); err != nil {
+			return nil, err
+		}
+	}
 
 	backupSvc, err := newBackupService(repo, cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	mediaSvc := newMediaService(repo, cfg)
+
+	registry := prometheus.NewRegistry()
+	var healthSQLDB *sql.DB
+	if healthDB != nil {
+		healthSQLDB = healthDB.DB
+	}
+	m := newMetrics(registry, db.DB, healthSQLDB, backupSvc)
+	mediaSvc.metrics = m
+	backupSvc.metrics = m
+
 	return &AeronService{
-		Media:       newMediaService(repo, cfg),
+		Media:       mediaSvc,
+		ImageJobs:   newImageJobService(mediaSvc, cfg),
+		Reoptimize:  newReoptimizeService(mediaSvc, cfg),
 		Backup:      backupSvc,
 		Maintenance: newMaintenanceService(repo, cfg),
+		Metrics:     registry,
 		repo:        repo,
 		config:      cfg,
 	}, nil
@@ -51,14 +90,53 @@ func (s *AeronService) Repository() *database.Repository {
 
 // Close gracefully shuts down all services.
 func (s *AeronService) Close() {
+	s.ImageJobs.Close()
+	s.Reoptimize.Close()
+	s.Media.Close()
 	s.Maintenance.Close()
 	s.Backup.Close()
 }
 
+// CheckBatchSize rejects batch requests with more items than api.max_batch_size allows.
+// All batch operations share this limit, so no single request can hold an unbounded number of items.
+func CheckBatchSize(cfg *config.APIConfig, field string, size int) error {
+	if limit := cfg.GetMaxBatchSize(); size > limit {
+		return types.NewValidationError(field, fmt.Sprintf("at most %d items are allowed per request (api.max_batch_size)", limit))
+	}
+	return nil
+}
+
 // DecodeBase64 decodes a base64 string, stripping any data URL prefix if present.
-func DecodeBase64(data string) ([]byte, error) {
+// Decoding stops with an error once the decoded data exceeds maxSize bytes.
+func DecodeBase64(data string, maxSize int64) ([]byte, error) {
 	if _, after, found := strings.Cut(data, ","); found {
 		data = after
 	}
-	return io.ReadAll(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(data))
+	decoded, err := io.ReadAll(io.LimitReader(decoder, maxSize+1))
+	if err != nil {
+		return nil, types.NewValidationError("image", "invalid base64 image")
+	}
+	if int64(len(decoded)) > maxSize {
+		return nil, types.NewValidationError("image", fmt.Sprintf("image exceeds maximum size of %d bytes", maxSize))
+	}
+	return decoded, nil
+}
+
+// prepareImageVariantTable checks that the image variant table exists. The table lives in Aeron's
+// schema, so it is only created when create is set; otherwise a missing table is a configuration error.
+func prepareImageVariantTable(ctx context.Context, repo *database.Repository, create bool) error {
+	exists, err := repo.ImageVariantTableExists(ctx)
+	if err != nil || exists {
+		return err
+	}
+	if !create {
+		return types.NewConfigError("image.store_variants", "table toolbox_image_variant does not exist: create it as described in API.md, or set image.create_variant_table")
+	}
+	if err := repo.CreateImageVariantTable(ctx); err != nil {
+		return err
+	}
+	slog.Info("Created image variant table", "table", "toolbox_image_variant")
+	return nil
 }
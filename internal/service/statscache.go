@@ -0,0 +1,121 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// imageStatsKey identifies one cached set of image statistics.
+type imageStatsKey struct {
+	entityType types.EntityType
+	activeOnly bool
+}
+
+// imageStatsCache keeps image statistics computed in the background, so the statistics
+// endpoints do not have to count all rows of the artist and track tables on every request.
+type imageStatsCache struct {
+	media    *MediaService
+	interval time.Duration
+	timeout  time.Duration
+	trigger  chan struct{} // buffered, so refresh requests arriving during a refresh are coalesced
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu      sync.RWMutex
+	entries map[imageStatsKey]ImageStats
+}
+
+// newImageStatsCache creates an imageStatsCache and starts refreshing it in the background.
+func newImageStatsCache(media *MediaService, interval, timeout time.Duration) *imageStatsCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &imageStatsCache{
+		media:    media,
+		interval: interval,
+		timeout:  timeout,
+		trigger:  make(chan struct{}, 1),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		entries:  make(map[imageStatsKey]ImageStats),
+	}
+	go c.run(ctx)
+	return c
+}
+
+// Close stops the background refresh and waits for a running refresh to finish.
+func (c *imageStatsCache) Close() {
+	c.cancel()
+	<-c.done
+}
+
+// get returns the cached statistics for key, if they have been computed.
+func (c *imageStatsCache) get(key imageStatsKey) (*ImageStats, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return &stats, true
+}
+
+// set stores freshly computed statistics for key.
+func (c *imageStatsCache) set(key imageStatsKey, stats *ImageStats) {
+	c.mu.Lock()
+	c.entries[key] = *stats
+	c.mu.Unlock()
+}
+
+// refreshSoon requests a background refresh without waiting for it.
+func (c *imageStatsCache) refreshSoon() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// run refreshes the cache at startup, on every interval, and whenever a refresh is requested.
+func (c *imageStatsCache) run(ctx context.Context) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.refresh(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-c.trigger:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// refresh recomputes all cached statistics. Failed computations keep the previous values.
+func (c *imageStatsCache) refresh(ctx context.Context) {
+	start := time.Now()
+	for _, entityType := range []types.EntityType{types.EntityTypeArtist, types.EntityTypeTrack} {
+		for _, activeOnly := range []bool{false, true} {
+			if ctx.Err() != nil {
+				return
+			}
+
+			queryCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			stats, err := c.media.computeStatistics(queryCtx, entityType, activeOnly)
+			cancel()
+			if err != nil {
+				slog.Warn("Failed to refresh image statistics", "entityType", entityType, "activeOnly", activeOnly, "error", err)
+				continue
+			}
+			c.set(imageStatsKey{entityType: entityType, activeOnly: activeOnly}, stats)
+		}
+	}
+	slog.Debug("Image statistics refreshed", "duration", time.Since(start))
+}
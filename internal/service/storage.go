@@ -0,0 +1,131 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+)
+
+// blobStorage stores backup files in a remote object store, selected by backup.storage_backend.
+// Keys are relative to the configured path prefix.
+type blobStorage interface {
+	// upload transfers a local file to key.
+	upload(ctx context.Context, key, localPath string) error
+	// delete removes the object at key.
+	delete(ctx context.Context, key string) error
+	// list returns all objects whose key starts with prefix, in no particular order.
+	list(ctx context.Context, prefix string) ([]blobObject, error)
+}
+
+// blobObject describes a backup file stored in remote storage.
+type blobObject struct {
+	key          string // relative to the configured path prefix
+	lastModified time.Time
+}
+
+// newBlobStorage creates the remote storage selected by backup.storage_backend, or returns nil if it is disabled.
+func newBlobStorage(cfg *config.BackupConfig) (blobStorage, error) {
+	if !cfg.StorageEnabled() {
+		return nil, nil
+	}
+
+	switch cfg.GetStorageBackend() {
+	case "gcs":
+		return newGCSService(&cfg.GCS)
+	case "azure":
+		return newAzureService(&cfg.Azure)
+	default:
+		return newS3Service(&cfg.S3), nil
+	}
+}
+
+// deleteBlobPrefix removes all objects of a per-table backup stored under name/.
+func deleteBlobPrefix(ctx context.Context, storage blobStorage, name string) error {
+	objects, err := storage.list(ctx, name+"/")
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := storage.delete(ctx, obj.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blobBackup is a backup in remote storage: a single object, or all objects of a per-table backup.
+type blobBackup struct {
+	name         string
+	tableSet     bool
+	lastModified time.Time // of the most recently modified object
+}
+
+// groupBlobBackups groups objects into backups by the first segment of their key, so that the table
+// dumps of a per-table backup form a single backup. Objects that are not part of a backup are left out.
+func groupBlobBackups(objects []blobObject) []blobBackup {
+	byName := make(map[string]*blobBackup)
+	for _, obj := range objects {
+		name, _, nested := strings.Cut(obj.key, "/")
+		if validateBackupFilename(name) != nil || nested != IsTableSet(name) {
+			continue
+		}
+		backup, ok := byName[name]
+		if !ok {
+			backup = &blobBackup{name: name, tableSet: nested}
+			byName[name] = backup
+		}
+		if obj.lastModified.After(backup.lastModified) {
+			backup.lastModified = obj.lastModified
+		}
+	}
+
+	backups := make([]blobBackup, 0, len(byName))
+	for _, backup := range byName {
+		backups = append(backups, *backup)
+	}
+	return backups
+}
+
+// pruneBlobs removes backups older than maxAge or beyond maxBackups from remote storage.
+// The newest minBackups backups are always kept; a zero maxAge or maxBackups disables that rule.
+// Per-table backups count as one backup and are removed as a whole.
+func pruneBlobs(ctx context.Context, storage blobStorage, maxAge time.Duration, maxBackups, minBackups int) (int, error) {
+	objects, err := storage.list(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	backups := groupBlobBackups(objects)
+	slices.SortFunc(backups, func(a, b blobBackup) int {
+		return b.lastModified.Compare(a.lastModified) // Descending order
+	})
+
+	cutoff := time.Now().Add(-maxAge)
+	var deleted int
+	for i, backup := range backups {
+		if i < minBackups {
+			continue
+		}
+		expired := maxAge > 0 && backup.lastModified.Before(cutoff)
+		overLimit := maxBackups > 0 && i >= maxBackups
+		if !expired && !overLimit {
+			continue
+		}
+
+		if backup.tableSet {
+			err = deleteBlobPrefix(ctx, storage, backup.name)
+		} else {
+			err = storage.delete(ctx, backup.name)
+		}
+		if err != nil {
+			slog.Warn("Failed to prune remote backup", "key", backup.name, "error", err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+)
+
+// thumbnailKey identifies one resized image. The hash of the stored image is part of the key,
+// so replacing an image never serves a stale thumbnail; old entries simply age out.
+type thumbnailKey struct {
+	entityType types.EntityType
+	id         string
+	size       string
+	hash       string
+}
+
+// thumbnailEntry is a cached resized image, stored as the value of a list element.
+type thumbnailEntry struct {
+	key  thumbnailKey
+	data []byte
+}
+
+// thumbnailCache is a fixed-size in-memory LRU cache of images resized on the fly.
+type thumbnailCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List // most recently used at the front
+	entries map[thumbnailKey]*list.Element
+}
+
+// newThumbnailCache creates a thumbnailCache holding at most capacity images.
+func newThumbnailCache(capacity int) *thumbnailCache {
+	return &thumbnailCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[thumbnailKey]*list.Element),
+	}
+}
+
+// get returns the cached image for key and marks it as recently used.
+func (c *thumbnailCache) get(key thumbnailKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*thumbnailEntry).data, true
+}
+
+// put stores the image for key, evicting the least recently used image when the cache is full.
+func (c *thumbnailCache) put(key thumbnailKey, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*thumbnailEntry).data = data
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&thumbnailEntry{key: key, data: data})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*thumbnailEntry).key)
+	}
+}
@@ -0,0 +1,131 @@
+// Package service provides business logic for the Aeron Toolbox.
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/types"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/util"
+)
+
+// vacuumFullConfirmSharePct is the share of the free disk space above which VACUUM FULL needs confirmation.
+const vacuumFullConfirmSharePct = 50
+
+// VACUUM FULL disk space verdicts.
+const (
+	VacuumFullSafe              = "safe"
+	VacuumFullNeedsConfirmation = "needs_confirmation"
+	VacuumFullInsufficientSpace = "insufficient_space"
+)
+
+// VacuumFullEstimate describes how much disk space a VACUUM FULL run needs and whether it is safe to start.
+type VacuumFullEstimate struct {
+	Tables                []VacuumFullTableEstimate `json:"tables"`
+	RequiredBytes         int64                     `json:"required_bytes"` // largest table, as tables are rewritten one at a time
+	Required              string                    `json:"required"`
+	EstimatedReclaimBytes int64                     `json:"estimated_reclaim_bytes"`
+	EstimatedReclaim      string                    `json:"estimated_reclaim"`
+	FreeBytes             *int64                    `json:"free_bytes"` // nil when the free space could not be determined
+	Free                  string                    `json:"free,omitempty"`
+	Verdict               string                    `json:"verdict"`
+	Reason                string                    `json:"reason,omitempty"`
+	Warnings              []string                  `json:"warnings"`
+}
+
+// VacuumFullTableEstimate describes the disk space VACUUM FULL needs for a single table.
+type VacuumFullTableEstimate struct {
+	Table                 string `json:"table"`
+	RequiredBytes         int64  `json:"required_bytes"`
+	Required              string `json:"required"`
+	EstimatedReclaimBytes int64  `json:"estimated_reclaim_bytes"`
+	EstimatedReclaim      string `json:"estimated_reclaim"`
+}
+
+// EstimateVacuumFull estimates the temporary disk space VACUUM FULL needs for the given tables and compares
+// it with the free space on maintenance.data_directory. Without tables, the tables VACUUM would select are used.
+// VACUUM FULL writes a new copy of a table and its indexes before the old files are removed, so each table
+// needs free space equal to its total size. The reclaimed space is estimated from the dead tuple ratio.
+func (s *MaintenanceService) EstimateVacuumFull(ctx context.Context, tables []string) (*VacuumFullEstimate, error) {
+	mctx, err := s.newMaintenanceContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, skipped := mctx.selectTablesToProcess(tables, s.needsVacuum)
+
+	estimate := &VacuumFullEstimate{
+		Tables:   make([]VacuumFullTableEstimate, 0, len(selected)),
+		Warnings: []string{},
+	}
+	for i := range skipped {
+		estimate.Warnings = append(estimate.Warnings, skipped[i].Message)
+	}
+
+	for i := range selected {
+		required := selected[i].TotalSizeRaw
+		reclaim := int64(float64(required) * selected[i].DeadTupleRatio / 100)
+		estimate.Tables = append(estimate.Tables, VacuumFullTableEstimate{
+			Table:                 selected[i].Name,
+			RequiredBytes:         required,
+			Required:              util.FormatBytes(required),
+			EstimatedReclaimBytes: reclaim,
+			EstimatedReclaim:      util.FormatBytes(reclaim),
+		})
+		estimate.RequiredBytes = max(estimate.RequiredBytes, required)
+		estimate.EstimatedReclaimBytes += reclaim
+	}
+	estimate.Required = util.FormatBytes(estimate.RequiredBytes)
+	estimate.EstimatedReclaim = util.FormatBytes(estimate.EstimatedReclaimBytes)
+
+	s.assessVacuumFullSpace(estimate)
+	return estimate, nil
+}
+
+// assessVacuumFullSpace fills in the free disk space and the verdict of an estimate.
+func (s *MaintenanceService) assessVacuumFullSpace(estimate *VacuumFullEstimate) {
+	dataDir := s.config.Maintenance.DataDirectory
+	if dataDir == "" {
+		estimate.Verdict = VacuumFullNeedsConfirmation
+		estimate.Reason = "free disk space is unknown because maintenance.data_directory is not set"
+		return
+	}
+
+	free, err := util.FreeDiskSpace(dataDir)
+	if err != nil {
+		estimate.Verdict = VacuumFullNeedsConfirmation
+		estimate.Reason = fmt.Sprintf("free disk space of %s could not be determined: %v", dataDir, err)
+		return
+	}
+	estimate.FreeBytes = &free
+	estimate.Free = util.FormatBytes(free)
+
+	switch {
+	case estimate.RequiredBytes > free:
+		estimate.Verdict = VacuumFullInsufficientSpace
+		estimate.Reason = fmt.Sprintf("VACUUM FULL needs up to %s of free disk space, but only %s is available", estimate.Required, estimate.Free)
+	case estimate.RequiredBytes > free*vacuumFullConfirmSharePct/100:
+		estimate.Verdict = VacuumFullNeedsConfirmation
+		estimate.Reason = fmt.Sprintf("VACUUM FULL needs up to %s, more than %d%% of the %s free disk space", estimate.Required, vacuumFullConfirmSharePct, estimate.Free)
+	default:
+		estimate.Verdict = VacuumFullSafe
+	}
+}
+
+// checkVacuumFull refuses a VACUUM FULL run that would fill the disk, and one that risks it without confirmation.
+func (s *MaintenanceService) checkVacuumFull(ctx context.Context, opts VacuumOptions) error {
+	estimate, err := s.EstimateVacuumFull(ctx, opts.Tables)
+	if err != nil {
+		return err
+	}
+
+	switch estimate.Verdict {
+	case VacuumFullInsufficientSpace:
+		return types.NewConflictError("vacuum full", estimate.Reason)
+	case VacuumFullNeedsConfirmation:
+		if !opts.Confirmed {
+			return types.NewValidationError("confirm", "VACUUM FULL needs confirmation: "+estimate.Reason)
+		}
+	}
+	return nil
+}
@@ -25,8 +25,12 @@ const (
 // VoicetrackUserID is the UUID used in Aeron to identify voice tracks.
 const VoicetrackUserID = "021F097E-B504-49BB-9B89-16B64D2E8422"
 
-// SupportedFormats lists the image formats that can be processed.
-var SupportedFormats = []string{"jpeg", "jpg", "png"}
+// NoArtistID is the placeholder artist ID reported for tracks without an artist.
+const NoArtistID = "00000000-0000-0000-0000-000000000000"
+
+// SupportedFormats lists the image formats that can be processed. HEIC images are only accepted
+// with image.enable_heic, and only in builds that include the HEIC decoder.
+var SupportedFormats = []string{"jpeg", "jpg", "png", "webp", "heic"}
 
 // IDColumnForTable returns the primary key column name for the given table.
 func IDColumnForTable(table Table) string {
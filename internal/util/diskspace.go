@@ -0,0 +1,15 @@
+//go:build !windows
+
+package util
+
+import "syscall"
+
+// FreeDiskSpace returns the number of bytes available to unprivileged users on the file system holding path.
+func FreeDiskSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	// The field types differ per platform.
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
@@ -0,0 +1,8 @@
+package util
+
+import "errors"
+
+// FreeDiskSpace is not implemented on Windows; callers treat the free space as unknown.
+func FreeDiskSpace(string) (int64, error) {
+	return 0, errors.New("free disk space cannot be determined on Windows")
+}
@@ -1,6 +1,9 @@
 package util
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // FormatBytes converts bytes to a human-readable string with binary prefixes.
 func FormatBytes(bytes int64) string {
@@ -21,3 +24,30 @@ func FormatBytes(bytes int64) string {
 		return fmt.Sprintf("%d bytes", bytes)
 	}
 }
+
+// SanitizeFilename replaces characters that are unsafe in filenames or HTTP headers
+// and trims the result to a reasonable length. Returns fallback when nothing usable remains.
+func SanitizeFilename(name, fallback string) string {
+	const maxLength = 100
+
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			continue
+		case strings.ContainsRune(`/\:*?"<>|;`, r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	sanitized := strings.Trim(b.String(), " .")
+	if runes := []rune(sanitized); len(runes) > maxLength {
+		sanitized = strings.TrimSpace(string(runes[:maxLength]))
+	}
+	if sanitized == "" {
+		return fallback
+	}
+	return sanitized
+}
@@ -0,0 +1,10 @@
+//go:build !noheic
+
+package util
+
+// The HEIC decoder runs libheif as WebAssembly, so it needs no cgo. Importing it registers the
+// "heic" format with the image package. Build with the noheic tag to leave it out of the binary.
+import _ "github.com/gen2brain/heic"
+
+// heicSupported reports whether this build can decode HEIC images.
+const heicSupported = true
@@ -0,0 +1,6 @@
+//go:build noheic
+
+package util
+
+// heicSupported reports whether this build can decode HEIC images.
+const heicSupported = false
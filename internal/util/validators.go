@@ -3,6 +3,7 @@ package util
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	"io"
@@ -37,11 +38,32 @@ func ValidateEntityID(id, entityLabel string) error {
 }
 
 // newSafeHTTPClient creates an HTTP client with SSRF protection.
-func newSafeHTTPClient() *safeurl.WrappedClient {
-	config := safeurl.GetConfigBuilder().Build()
+// Every redirect hop is re-validated and the number of redirects is capped at maxRedirects.
+func newSafeHTTPClient(maxRedirects int) *safeurl.WrappedClient {
+	config := safeurl.GetConfigBuilder().
+		SetCheckRedirect(checkRedirect(maxRedirects)).
+		Build()
 	return safeurl.Client(config)
 }
 
+// checkRedirect returns a redirect policy that limits the number of hops and
+// applies the same URL validation to each redirect target as to the initial URL.
+// IP-level SSRF checks are enforced by the safeurl dialer on every connection.
+func checkRedirect(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("too many redirects (maximum %d)", maxRedirects)
+		}
+		if err := ValidateURL(req.URL.String()); err != nil {
+			return fmt.Errorf("redirect to disallowed URL: %w", err)
+		}
+		if req.URL.User != nil {
+			return errors.New("redirect to URL with credentials not allowed")
+		}
+		return nil
+	}
+}
+
 // ValidateURL validates a URL for allowed schemes and hostname presence.
 func ValidateURL(urlString string) error {
 	if urlString == "" {
@@ -72,14 +94,37 @@ func ValidateContentType(contentType string) error {
 	return nil
 }
 
-// ValidateImageData validates that byte data represents a valid image.
-func ValidateImageData(data []byte) error {
+// DecodeImageConfig reads the image header and rejects images with more than maxPixels pixels.
+// It must be called before any full decode so that oversized images never allocate their pixel buffers.
+// A maxPixels of 0 disables the pixel check.
+func DecodeImageConfig(data []byte, maxPixels int64) (image.Config, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if !heicSupported && isHEIC(data) {
+			return image.Config{}, "", errHEICNotSupported()
+		}
+		return image.Config{}, "", err
+	}
+
+	if pixels := int64(config.Width) * int64(config.Height); maxPixels > 0 && pixels > maxPixels {
+		return image.Config{}, "", types.NewValidationError("image",
+			fmt.Sprintf("image is too large: %dx%d (maximum %d pixels)", config.Width, config.Height, maxPixels))
+	}
+
+	return config, format, nil
+}
+
+// ValidateImageData validates that byte data represents a valid image within the pixel limit.
+func ValidateImageData(data []byte, maxPixels int64) error {
 	if len(data) == 0 {
 		return types.NewValidationError("image", "image is empty")
 	}
 
-	_, _, err := image.DecodeConfig(bytes.NewReader(data))
-	if err != nil {
+	if _, _, err := DecodeImageConfig(data, maxPixels); err != nil {
+		var validationErr *types.ValidationError
+		if errors.As(err, &validationErr) {
+			return err
+		}
 		return types.NewValidationError("image", fmt.Sprintf("invalid image: %v", err))
 	}
 
@@ -88,23 +133,41 @@ func ValidateImageData(data []byte) error {
 
 // ValidateImageFormat validates that an image format is supported.
 func ValidateImageFormat(format string) error {
+	if format == "heic" && !heicSupported {
+		return errHEICNotSupported()
+	}
 	if !slices.Contains(types.SupportedFormats, format) {
 		return types.NewValidationError("image", fmt.Sprintf("file format %s is not supported (use: %v)", format, types.SupportedFormats))
 	}
 	return nil
 }
 
+// heicBrands are the ISO BMFF file type brands of HEIC images, as recognized by the HEIC decoder.
+var heicBrands = []string{"heic", "heix", "hevc", "hevx", "msf1"}
+
+// isHEIC reports whether data starts with a file type box with a HEIC brand. Builds without the
+// HEIC decoder use it to tell a HEIC upload apart from data that is no image at all.
+func isHEIC(data []byte) bool {
+	return len(data) >= 12 && string(data[4:8]) == "ftyp" && slices.Contains(heicBrands, string(data[8:12]))
+}
+
+// errHEICNotSupported returns the error for HEIC images in a build without the HEIC decoder.
+func errHEICNotSupported() error {
+	return types.NewValidationError("image", "HEIC not supported in this build")
+}
+
 // ValidateAndDownloadImage validates and securely downloads an image from a URL.
-func ValidateAndDownloadImage(urlString string, maxSize int64) ([]byte, error) {
+// It also returns the Content-Type the server declared for the image.
+func ValidateAndDownloadImage(urlString string, maxSize int64, maxRedirects int, maxPixels int64) (data []byte, contentType string, err error) {
 	if err := ValidateURL(urlString); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	client := newSafeHTTPClient()
+	client := newSafeHTTPClient(maxRedirects)
 
 	resp, err := client.Get(urlString)
 	if err != nil {
-		return nil, types.NewValidationError("image", fmt.Sprintf("download failed: %v", err))
+		return nil, "", types.NewValidationError("image", fmt.Sprintf("download failed: %v", err))
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -113,23 +176,23 @@ func ValidateAndDownloadImage(urlString string, maxSize int64) ([]byte, error) {
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, types.NewValidationError("image", fmt.Sprintf("download failed: HTTP %d", resp.StatusCode))
+		return nil, "", types.NewValidationError("image", fmt.Sprintf("download failed: HTTP %d", resp.StatusCode))
 	}
 
-	contentType := resp.Header.Get("Content-Type")
+	contentType = resp.Header.Get("Content-Type")
 	if err := ValidateContentType(contentType); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	limitedReader := io.LimitReader(resp.Body, maxSize)
-	data, err := io.ReadAll(limitedReader)
+	data, err = io.ReadAll(limitedReader)
 	if err != nil {
-		return nil, types.NewValidationError("image", fmt.Sprintf("error reading: %v", err))
+		return nil, "", types.NewValidationError("image", fmt.Sprintf("error reading: %v", err))
 	}
 
-	if err := ValidateImageData(data); err != nil {
-		return nil, err
+	if err := ValidateImageData(data, maxPixels); err != nil {
+		return nil, "", err
 	}
 
-	return data, nil
+	return data, contentType, nil
 }
@@ -10,6 +10,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log/slog"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/api"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/config"
+	"github.com/oszuidwest/zwfm-aerontoolbox/internal/logbuffer"
 	"github.com/oszuidwest/zwfm-aerontoolbox/internal/service"
 )
 
@@ -38,20 +40,35 @@ func run() error {
 	configFile := flag.String("config", "", "Path to config file (default: config.json)")
 	port := flag.String("port", "8080", "API server port (default: 8080)")
 	showVersion := flag.Bool("version", false, "Show version information")
+	jsonOutput := flag.Bool("json", false, "Print version information as JSON (use with -version)")
+	checkConfig := flag.Bool("check-config", false, "Validate the config file and connectivity, then exit")
+	migrateConfig := flag.String("migrate-config", "", "Convert a legacy YAML config file to JSON, then exit")
+	migrateOut := flag.String("out", "", "Output path for -migrate-config (default: stdout)")
 	flag.Parse()
 
 	if *showVersion {
+		if *jsonOutput {
+			return printVersionJSON()
+		}
 		printVersion()
 		return nil
 	}
 
+	if *migrateConfig != "" {
+		return runConfigMigration(*migrateConfig, *migrateOut)
+	}
+
 	cfg, err := config.Load(*configFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
 		return err
 	}
 
-	initLogger(cfg)
+	if *checkConfig {
+		return runConfigCheck(cfg)
+	}
+
+	logs := initLogger(cfg)
 
 	db, dbClose, err := setupDatabase(cfg)
 	if err != nil {
@@ -59,7 +76,13 @@ func run() error {
 	}
 	defer dbClose()
 
-	svc, err := service.New(db, cfg)
+	healthDB, healthDBClose, err := setupHealthDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer healthDBClose()
+
+	svc, err := service.New(db, healthDB, cfg)
 	if err != nil {
 		slog.Error("Service initialization failed", "error", err)
 		return err
@@ -73,7 +96,7 @@ func run() error {
 	}
 	scheduler.Start()
 
-	server := api.New(svc, Version)
+	server := api.New(svc, scheduler, Version, logs)
 
 	return serveUntilShutdown(server, *port, scheduler)
 }
@@ -84,8 +107,90 @@ func printVersion() {
 	fmt.Printf("Build time: %s\n", BuildTime)
 }
 
+// printVersionJSON prints the application version, commit hash, and build time as a JSON object.
+func printVersionJSON() error {
+	return json.NewEncoder(os.Stdout).Encode(struct {
+		Version   string `json:"version"`
+		Commit    string `json:"commit"`
+		BuildTime string `json:"build_time"`
+	}{Version, Commit, BuildTime})
+}
+
+// runConfigCheck runs the connectivity checks for a loaded configuration and prints one line per check.
+// It returns an error when any check fails.
+func runConfigCheck(cfg *config.Config) error {
+	db, err := sqlx.Open("postgres", cfg.Database.ConnectionString())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Database configuration error: %v\n", err)
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var failed int
+	for _, result := range service.CheckConfig(ctx, db, cfg) {
+		switch {
+		case result.Skipped:
+			fmt.Printf("SKIP  %s\n", result.Name)
+		case result.Err != nil:
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", result.Name, result.Err)
+		default:
+			fmt.Printf("OK    %s\n", result.Name)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d configuration check(s) failed", failed)
+	}
+	fmt.Println("Configuration OK")
+	return nil
+}
+
+// runConfigMigration converts a legacy YAML config file to JSON and writes it to outPath or stdout.
+// An existing output file is never overwritten.
+func runConfigMigration(inPath, outPath string) error {
+	data, err := os.ReadFile(inPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read config file: %v\n", err)
+		return err
+	}
+
+	out, err := config.MigrateYAML(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		return err
+	}
+
+	if outPath == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	file, err := os.OpenFile(outPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
+		return err
+	}
+	if _, err := file.Write(out); err != nil {
+		_ = file.Close()
+		fmt.Fprintf(os.Stderr, "Failed to write output file: %v\n", err)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write output file: %v\n", err)
+		return err
+	}
+
+	fmt.Printf("Configuration written to %s\n", outPath)
+	return nil
+}
+
 // initLogger initializes the global slog logger with the configured level and format.
-func initLogger(cfg *config.Config) {
+// It returns the in-memory buffer of recent log records, or nil if the buffer is disabled.
+func initLogger(cfg *config.Config) *logbuffer.Buffer {
 	level := cfg.Log.GetLevel()
 	opts := &slog.HandlerOptions{Level: level}
 
@@ -96,8 +201,15 @@ func initLogger(cfg *config.Config) {
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
+	var logs *logbuffer.Buffer
+	if cfg.Log.BufferSize > 0 {
+		logs = logbuffer.New(cfg.Log.BufferSize)
+		handler = logbuffer.NewHandler(handler, logs)
+	}
+
 	slog.SetDefault(slog.New(handler))
-	slog.Info("Logger initialized", "level", level.String(), "format", cfg.Log.GetFormat())
+	slog.Info("Logger initialized", "level", level.String(), "format", cfg.Log.GetFormat(), "buffer_size", cfg.Log.BufferSize)
+	return logs
 }
 
 // setupDatabase establishes a database connection pool and returns a cleanup function.
@@ -134,6 +246,31 @@ func setupDatabase(cfg *config.Config) (*sqlx.DB, func(), error) {
 	return db, cleanup, nil
 }
 
+// setupHealthDatabase opens a small connection pool reserved for health checks,
+// so that /api/health keeps responding while the main pool is saturated.
+func setupHealthDatabase(cfg *config.Config) (*sqlx.DB, func(), error) {
+	db, err := sqlx.Open("postgres", cfg.Database.ConnectionString())
+	if err != nil {
+		slog.Error("Health check database pool failed", "error", err)
+		return nil, nil, err
+	}
+
+	conns := cfg.Database.GetHealthCheckConns()
+	db.SetMaxOpenConns(conns)
+	db.SetMaxIdleConns(conns)
+	db.SetConnMaxLifetime(cfg.Database.GetConnMaxLifetime())
+
+	slog.Info("Health check connection pool configured", "max_open", conns)
+
+	cleanup := func() {
+		if err := db.Close(); err != nil {
+			slog.Error("Failed to close health check database pool", "error", err)
+		}
+	}
+
+	return db, cleanup, nil
+}
+
 // serveUntilShutdown runs the API server until a shutdown signal or error occurs.
 func serveUntilShutdown(server *api.Server, port string, scheduler *service.Scheduler) error {
 	stop := make(chan os.Signal, 1)